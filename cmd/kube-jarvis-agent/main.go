@@ -0,0 +1,84 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"tkestack.io/kube-jarvis/pkg/agent"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+var (
+	address       string
+	port          int
+	procDir       string
+	hostRoot      string
+	execAllowList string
+	fileAllowDirs string
+)
+
+func init() {
+	flag.StringVar(&address, "address", "127.0.0.1",
+		"listening address, set it to pod ip so that agent can be reached via pod proxy of api server")
+	flag.IntVar(&port, "port", agent.DefaultPort, "listening port")
+	flag.StringVar(&procDir, "proc", "/proc", "the proc file system of host")
+	flag.StringVar(&hostRoot, "host-root", "/", "the path that host root mounted to")
+	flag.StringVar(&execAllowList, "exec-allow-list", "",
+		"the commands that can be executed in json, e.g. [[\"cat\",\"/proc/uptime\"]], default is agent.DefaultExecAllowList")
+	flag.StringVar(&fileAllowDirs, "file-allow-dirs", "",
+		"the comma separated directories of host that certificate files can be read from, default is agent.DefaultFileAllowDirs")
+}
+
+// kube-jarvis-agent should run with hostPID and hostNetwork
+// the token is read from environment variable "KUBE_JARVIS_AGENT_TOKEN", agent will not start without it
+func main() {
+	flag.Parse()
+	token := os.Getenv("KUBE_JARVIS_AGENT_TOKEN")
+	if token == "" {
+		log.Fatal("KUBE_JARVIS_AGENT_TOKEN can not be empty")
+	}
+
+	collector := agent.NewCollector()
+	collector.ProcDir = procDir
+	collector.HostRoot = hostRoot
+	if execAllowList != "" {
+		collector.ExecAllowList = nil
+		if err := json.Unmarshal([]byte(execAllowList), &collector.ExecAllowList); err != nil {
+			log.Fatalf("invalid exec-allow-list: %v", err)
+		}
+	}
+
+	if fileAllowDirs != "" {
+		collector.FileAllowDirs = strings.Split(fileAllowDirs, ",")
+	}
+
+	server := agent.NewServer(logger.NewLogger(), collector, token)
+	addr := net.JoinHostPort(address, strconv.Itoa(port))
+	log.Printf("kube-jarvis-agent listen on %s", addr)
+	if err := http.ListenAndServe(addr, server); err != nil {
+		log.Fatal(err.Error())
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"tkestack.io/kube-jarvis/pkg/translate"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
 
@@ -57,7 +58,9 @@ type Config struct {
 		Trans    string
 		Lang     string
 		HttpAddr string
-		Store    struct {
+		// ShutdownTimeout is the max time to wait for running diagnostic to stop once a signal is received
+		ShutdownTimeout string
+		Store           struct {
 			Type   string
 			Config interface{}
 		}
@@ -71,8 +74,13 @@ type Config struct {
 	}
 
 	Coordinator struct {
-		Type   string
-		Config interface{}
+		Type       string
+		Config     interface{}
+		Evaluators []struct {
+			Type   string
+			Name   string
+			Config interface{}
+		}
 	}
 
 	Diagnostics []diagnostic
@@ -196,7 +204,10 @@ func (c *Config) GetDiagnostics(cls cluster.Cluster,
 	if len(c.Diagnostics) != 0 {
 		dsCfg = c.Diagnostics
 	} else {
-		for tp := range diagnose.Factories {
+		for tp, f := range diagnose.Factories {
+			if f.NeedConfig {
+				continue
+			}
 			dsCfg = append(dsCfg, diagnostic{
 				Type: tp,
 			})
@@ -287,3 +298,44 @@ func (c *Config) GetExporters(cls cluster.Cluster,
 
 	return es, nil
 }
+
+// GetEvaluators create all target Evaluators
+func (c *Config) GetEvaluators(cls cluster.Cluster,
+	trans translate.Translator, st store.Store) ([]evaluate.Evaluator, error) {
+	es := make([]evaluate.Evaluator, 0)
+	for _, config := range c.Coordinator.Evaluators {
+		factory, exist := evaluate.Factories[config.Type]
+		if !exist {
+			return nil, fmt.Errorf("can not found evaluator type %s", config.Type)
+		}
+
+		if !plugins.IsSupportedCloud(factory.SupportedClouds, cls.CloudType()) {
+			c.Logger.Infof("evaluator [%s] don't support cloud [%s], skipped", config.Name, cls.CloudType())
+			continue
+		}
+
+		e := factory.Creator(&evaluate.MetaData{
+			MetaData: plugins.MetaData{
+				Store:      st,
+				Translator: trans.WithModule("evaluators." + config.Type),
+				Logger: c.Logger.With(map[string]string{
+					"evaluator": config.Name,
+				}),
+				Type: config.Type,
+				Name: config.Name,
+			},
+		})
+
+		if err := util.InitObjViaYaml(e, config.Config); err != nil {
+			return nil, err
+		}
+
+		if err := e.Complete(); err != nil {
+			return nil, err
+		}
+
+		es = append(es, e)
+	}
+
+	return es, nil
+}
@@ -0,0 +1,187 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+// i18n handle "kube-jarvis i18n" sub commands
+func i18n(args []string) (bool, error) {
+	if len(args) == 0 || args[0] != "check" {
+		return false, fmt.Errorf("usage: kube-jarvis i18n check [-trans dir] [-lang lang]")
+	}
+	return i18nCheck(args[1:])
+}
+
+// i18nCheck report gaps between translations of all languages
+// built-in translations and translations in the "-trans" dir are both checked
+// the message IDs listed by every registered diagnostic and evaluator are expected,
+// any missing or unused message IDs in all languages will be reported
+func i18nCheck(args []string) (bool, error) {
+	set := flag.NewFlagSet("i18n check", flag.ExitOnError)
+	dir := set.String("trans", "translation", "the translation file dir that layered on top of built-in translations")
+	lang := set.String("lang", "en", "the default language")
+	if err := set.Parse(args); err != nil {
+		return false, err
+	}
+
+	defTag, err := language.Parse(*lang)
+	if err != nil {
+		return false, err
+	}
+
+	langs := make([]language.Tag, 0)
+	ids := map[language.Tag]map[string]bool{}
+	for _, source := range translate.Sources(*dir) {
+		tags, err := translate.Languages(source)
+		if err != nil {
+			return false, err
+		}
+
+		for _, tag := range tags {
+			messages, err := translate.LoadMessages(source, tag)
+			if err != nil {
+				return false, err
+			}
+
+			if ids[tag] == nil {
+				ids[tag] = map[string]bool{}
+				langs = append(langs, tag)
+			}
+
+			for _, m := range messages {
+				ids[tag][m.ID] = true
+			}
+		}
+	}
+
+	if ids[defTag] == nil {
+		return false, fmt.Errorf("can not found translation of default language %s", *lang)
+	}
+
+	modules := map[string][]string{}
+	for typ, f := range diagnose.Factories {
+		module := "diagnostics." + typ
+		d := f.Creator(&diagnose.MetaData{
+			MetaData:  plugins.MetaData{Type: typ},
+			Catalogue: f.Catalogue,
+		})
+		modules[module] = expectedIDs(d, ids[defTag], module)
+	}
+
+	for typ, f := range evaluate.Factories {
+		module := "evaluators." + typ
+		e := f.Creator(&evaluate.MetaData{
+			MetaData: plugins.MetaData{Type: typ},
+		})
+		modules[module] = expectedIDs(e, ids[defTag], module)
+	}
+
+	gaps := make([]string, 0)
+	for module, expected := range modules {
+		if len(expected) == 0 {
+			gaps = append(gaps, fmt.Sprintf("[%s] %s has no message", defTag, module))
+			continue
+		}
+
+		for _, tag := range langs {
+			for _, id := range expected {
+				if !ids[tag][id] {
+					gaps = append(gaps, fmt.Sprintf("[%s] missing %s", tag, id))
+				}
+			}
+		}
+	}
+
+	for _, tag := range langs {
+		for id := range ids[tag] {
+			expected, exist := modules[moduleOfID(id)]
+			if !exist || !contains(expected, id) {
+				gaps = append(gaps, fmt.Sprintf("[%s] unused %s", tag, id))
+			}
+		}
+	}
+
+	sort.Strings(gaps)
+	for _, g := range gaps {
+		fmt.Println(g)
+	}
+
+	fmt.Printf("%d translation gaps found\n", len(gaps))
+	return len(gaps) == 0, nil
+}
+
+// expectedIDs return the sorted message IDs that plugin p need
+// the IDs are listed by p if it is a translate.MessageLister
+// or the IDs of module in default language are used
+func expectedIDs(p interface{}, defIDs map[string]bool, module string) []string {
+	lister, ok := p.(translate.MessageLister)
+	if !ok {
+		return idsOfModule(defIDs, module)
+	}
+
+	result := make([]string, 0)
+	for _, id := range lister.MessageIDs() {
+		if id = module + "." + id; !contains(result, id) {
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func contains(ids []string, id string) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// idsOfModule return sorted message IDs that belong to module
+func idsOfModule(ids map[string]bool, module string) []string {
+	result := make([]string, 0)
+	for id := range ids {
+		if moduleOfID(id) == module {
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// moduleOfID return the module of a full message ID
+// for example, "diagnostics.example.message" belong to module "diagnostics.example"
+func moduleOfID(id string) string {
+	index := strings.LastIndex(id, ".")
+	if index < 0 {
+		return ""
+	}
+	return id[:index]
+}
@@ -0,0 +1,87 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestI18nCheck(t *testing.T) {
+	passed, err := i18nCheck([]string{"-trans", "../../translation"})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if !passed {
+		t.Fatalf("translation gaps found")
+	}
+}
+
+func TestBuiltinMessageLister(t *testing.T) {
+	for typ, f := range diagnose.Factories {
+		if _, ok := f.Creator(&diagnose.MetaData{}).(translate.MessageLister); !ok {
+			t.Fatalf("diagnostic %s should list its messages", typ)
+		}
+	}
+
+	for typ, f := range evaluate.Factories {
+		if _, ok := f.Creator(&evaluate.MetaData{}).(translate.MessageLister); !ok {
+			t.Fatalf("evaluator %s should list its messages", typ)
+		}
+	}
+}
+
+func TestExpectedIDs(t *testing.T) {
+	var cases = []struct {
+		plugin   interface{}
+		expected []string
+	}{
+		{
+			plugin:   &lister{ids: []string{"b", "a", "b"}},
+			expected: []string{"diagnostics.test.a", "diagnostics.test.b"},
+		},
+		{
+			plugin:   struct{}{},
+			expected: []string{"diagnostics.test.c"},
+		},
+	}
+
+	defIDs := map[string]bool{"diagnostics.test.c": true, "diagnostics.other.d": true}
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%v", cs.expected), func(t *testing.T) {
+			ids := expectedIDs(cs.plugin, defIDs, "diagnostics.test")
+			if !reflect.DeepEqual(ids, cs.expected) {
+				t.Fatalf("want %v but get %v", cs.expected, ids)
+			}
+		})
+	}
+}
+
+type lister struct {
+	ids []string
+}
+
+func (l *lister) MessageIDs() []string {
+	return l.ids
+}
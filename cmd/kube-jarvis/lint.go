@@ -0,0 +1,177 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/manifests"
+	"tkestack.io/kube-jarvis/pkg/plugins/coordinate/basic"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/affinity"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/batch"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/healthcheck"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/pdb"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/requestslimits"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/export/stdout"
+	"tkestack.io/kube-jarvis/pkg/store"
+)
+
+// lintDiagnostics are the diagnostics used if no diagnostics are configured,
+// other resource diagnostics need the status of a running cluster
+var lintDiagnostics = []string{
+	requestslimits.DiagnosticType,
+	healthcheck.DiagnosticType,
+	pdb.DiagnosticType,
+	affinity.DiagnosticType,
+	batch.DiagnosticType,
+}
+
+// levelExporter record whether all results are better than Level
+type levelExporter struct {
+	*export.MetaData
+	Level  diagnose.HealthyLevel
+	passed bool
+}
+
+// Complete check and complete config items
+func (l *levelExporter) Complete() error {
+	return nil
+}
+
+// Export export result
+func (l *levelExporter) Export(ctx context.Context, result *export.AllResult) error {
+	l.passed = true
+	if l.Level == "" {
+		return nil
+	}
+
+	for _, dia := range result.Diagnostics {
+		for _, r := range dia.Results {
+			if r.Level.Compare(l.Level) <= 0 {
+				l.passed = false
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// lint run resource diagnostics on manifest files without a cluster
+// false will be returned if any result is at the target level or worse
+func lint(args []string) (bool, error) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	dir := fs.String("dir", "", "the directory of manifest files")
+	file := fs.String("config", "", "config file, only global and diagnostics are used")
+	namespace := fs.String("namespace", "default", "the namespace of objects that have no namespace")
+	format := fs.String("format", "fmt", "output format, use \"json\" to print a json")
+	level := fs.String("level", "", "exit with code 1 if any result is at this level or worse")
+	_ = fs.Parse(args)
+
+	failLevel := diagnose.HealthyLevel(*level)
+	if failLevel != "" && !failLevel.Verify() {
+		return false, fmt.Errorf("level %s is illegal", failLevel)
+	}
+
+	config := &Config{
+		Logger: logger.NewLogger(),
+	}
+	config.Global.Lang = "en"
+	if *file != "" {
+		var err error
+		config, err = GetConfig(*file)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if len(config.Diagnostics) == 0 {
+		for _, tp := range lintDiagnostics {
+			config.Diagnostics = append(config.Diagnostics, diagnostic{Type: tp})
+		}
+	}
+
+	cls := manifests.NewCluster(config.Logger.With(map[string]string{
+		"cluster": manifests.Type,
+	}), nil, nil).(*manifests.Cluster)
+	cls.Dir = *dir
+	cls.Namespace = *namespace
+	if err := cls.Complete(); err != nil {
+		return false, err
+	}
+
+	st := store.GetStore("mem", manifests.Type)
+	trans, err := config.GetTranslator()
+	if err != nil {
+		return false, err
+	}
+
+	diagnostics, err := config.GetDiagnostics(cls, trans, st)
+	if err != nil {
+		return false, err
+	}
+
+	coordinator := basic.NewCoordinator(config.Logger.With(map[string]string{
+		"coordinator": "default",
+	}), cls, st)
+	for _, d := range diagnostics {
+		if !isResourceDiagnostic(d) {
+			config.Logger.Infof("diagnostic [%s] is not a resource diagnostic, skipped", d.Meta().Type)
+			continue
+		}
+		coordinator.AddDiagnostic(d)
+	}
+
+	meta := plugins.MetaData{
+		Store:      st,
+		Translator: trans,
+		Logger:     config.Logger,
+	}
+	out := stdout.NewExporter(&export.MetaData{MetaData: meta}).(*stdout.Exporter)
+	out.Format = *format
+	if err := out.Complete(); err != nil {
+		return false, err
+	}
+	checker := &levelExporter{
+		MetaData: &export.MetaData{MetaData: meta},
+		Level:    failLevel,
+	}
+	coordinator.AddExporter(out)
+	coordinator.AddExporter(checker)
+
+	if err := coordinator.Run(context.Background()); err != nil {
+		return false, err
+	}
+	return checker.passed, nil
+}
+
+func isResourceDiagnostic(d diagnose.Diagnostic) bool {
+	for _, c := range d.Meta().Catalogue {
+		for _, r := range diagnose.CatalogueResource {
+			if c == r {
+				return true
+			}
+		}
+	}
+	return false
+}
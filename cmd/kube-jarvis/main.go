@@ -18,14 +18,18 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"tkestack.io/kube-jarvis/pkg/httpserver"
 	_ "tkestack.io/kube-jarvis/pkg/plugins/cluster/all"
 	_ "tkestack.io/kube-jarvis/pkg/plugins/coordinate/all"
 	_ "tkestack.io/kube-jarvis/pkg/plugins/diagnose/all"
+	_ "tkestack.io/kube-jarvis/pkg/plugins/evaluate/all"
 	_ "tkestack.io/kube-jarvis/pkg/plugins/export/all"
 )
 
@@ -33,19 +37,52 @@ var configFile string
 
 func init() {
 	flag.StringVar(&configFile, "config", "conf/default.yaml", "config file")
-	flag.Parse()
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "lint" {
+		passed, err := lint(os.Args[2:])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "i18n" {
+		passed, err := i18n(os.Args[2:])
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		if !passed {
+			os.Exit(1)
+		}
+		return
+	}
+
+	flag.Parse()
 	config, err := GetConfig(configFile)
 	if err != nil {
 		panic(err)
 	}
 
-	cls, err := config.GetCluster()
+	shutdownTimeout := defaultShutdownTimeout
+	if config.Global.ShutdownTimeout != "" {
+		shutdownTimeout, err = time.ParseDuration(config.Global.ShutdownTimeout)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	c, err := config.GetCluster()
 	if err != nil {
 		panic(err)
 	}
+	cls := &syncCluster{Cluster: c}
 
 	store, err := config.GetStore()
 	if err != nil {
@@ -71,6 +108,15 @@ func main() {
 		coordinator.AddDiagnostic(d)
 	}
 
+	evaluators, err := config.GetEvaluators(cls, trans, store)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, e := range evaluators {
+		coordinator.AddEvaluator(e)
+	}
+
 	exporters, err := config.GetExporters(cls, trans, store)
 	if err != nil {
 		panic(err)
@@ -80,8 +126,12 @@ func main() {
 		coordinator.AddExporter(e)
 	}
 
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
 	go httpserver.Default.Start(config.Logger, config.Global.HttpAddr)
-	if err := coordinator.Run(context.Background()); err != nil {
+	if err := runUntilSignal(config.Logger, signals, shutdownTimeout,
+		coordinator.Run, cls.Finish); err != nil {
 		log.Fatal(err.Error())
 	}
 }
@@ -0,0 +1,97 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+)
+
+// defaultShutdownTimeout is used if global.shutdowntimeout is not set
+const defaultShutdownTimeout = time.Second * 30
+
+// syncCluster serialize Finish of a Cluster
+// Finish may be called by coordinator and shutdown at the same time
+type syncCluster struct {
+	cluster.Cluster
+	lock sync.Mutex
+}
+
+// Finish call Finish of Cluster with lock
+func (s *syncCluster) Finish() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.Cluster.Finish()
+}
+
+// runUntilSignal call run until it returns or a signal is received
+// once a signal is received, the ctx of run is canceled and run has timeout to return,
+// otherwise finish is called directly, so that the resources created in cluster are always cleaned
+func runUntilSignal(logger logger.Logger, signals <-chan os.Signal, timeout time.Duration,
+	run func(ctx context.Context) error, finish func() error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case sig := <-signals:
+		logger.Infof("receive signal %v, shutting down", sig)
+		cancel()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		// being canceled by signal is a graceful shutdown
+		if errors.Cause(err) == context.Canceled {
+			return nil
+		}
+		return err
+	case <-timer.C:
+	}
+
+	logger.Errorf("shutdown timeout, finish cluster directly")
+	finished := make(chan error, 1)
+	go func() {
+		finished <- finish()
+	}()
+
+	select {
+	case err := <-finished:
+		if err != nil {
+			return fmt.Errorf("shutdown timeout, finish cluster failed: %v", err)
+		}
+		return fmt.Errorf("shutdown timeout")
+	case <-time.After(timeout):
+		return fmt.Errorf("shutdown timeout, finish cluster timeout")
+	}
+}
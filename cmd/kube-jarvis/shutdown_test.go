@@ -0,0 +1,95 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+func TestRunUntilSignal(t *testing.T) {
+	var cases = []struct {
+		name     string
+		signal   bool
+		run      func(ctx context.Context) error
+		hasErr   bool
+		finished bool
+	}{
+		{
+			name: "run done",
+			run: func(ctx context.Context) error {
+				return nil
+			},
+		},
+		{
+			name: "run failed",
+			run: func(ctx context.Context) error {
+				return fmt.Errorf("failed")
+			},
+			hasErr: true,
+		},
+		{
+			name:   "canceled by signal",
+			signal: true,
+			run: func(ctx context.Context) error {
+				<-ctx.Done()
+				return errors.Wrap(ctx.Err(), "diagnostic canceled")
+			},
+		},
+		{
+			name:   "shutdown timeout",
+			signal: true,
+			run: func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+			hasErr:   true,
+			finished: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			signals := make(chan os.Signal, 1)
+			if cs.signal {
+				signals <- syscall.SIGTERM
+			}
+
+			finished := false
+			err := runUntilSignal(logger.NewLogger(), signals, time.Millisecond*100, cs.run, func() error {
+				finished = true
+				return nil
+			})
+
+			if (err != nil) != cs.hasErr {
+				t.Fatalf("want hasErr %v but get %v", cs.hasErr, err)
+			}
+
+			if finished != cs.finished {
+				t.Fatalf("want finished %v but get %v", cs.finished, finished)
+			}
+		})
+	}
+}
@@ -1,18 +1,22 @@
 module tkestack.io/kube-jarvis
 
-go 1.12
+go 1.16
 
 require (
 	github.com/fatih/color v1.7.0
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/golang/protobuf v1.3.4
+	github.com/google/cel-go v0.4.2
 	github.com/jinzhu/gorm v1.9.12
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.10 // indirect
 	github.com/nicksnyder/go-i18n/v2 v2.0.3
 	github.com/pkg/errors v0.8.1
 	github.com/robfig/cron/v3 v3.0.0
+	golang.org/x/crypto v0.0.0-20191205180655-e7c4368fe9dd
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
 	golang.org/x/text v0.3.2
+	google.golang.org/grpc v1.27.1
 	gopkg.in/yaml.v2 v2.2.4
 	k8s.io/api v0.0.0-20191121015604-11707872ac1c
 	k8s.io/apimachinery v0.0.0-20191203211716-adc6f4cd9e7d
@@ -0,0 +1,159 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// kubeConfig is the part of kubeconfig file that contains certificates
+// client keys are not declared so that they are never decoded
+type kubeConfig struct {
+	Clusters []struct {
+		Cluster struct {
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		User struct {
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// kubeletConfig is the part of KubeletConfiguration that contains certificate settings
+type kubeletConfig struct {
+	Kind               string `yaml:"kind"`
+	TLSCertFile        string `yaml:"tlsCertFile"`
+	RotateCertificates bool   `yaml:"rotateCertificates"`
+	ServerTLSBootstrap bool   `yaml:"serverTLSBootstrap"`
+	Authentication     struct {
+		X509 struct {
+			ClientCAFile string `yaml:"clientCAFile"`
+		} `yaml:"x509"`
+	} `yaml:"authentication"`
+}
+
+// ParseCertFile parse the certificates in a PEM encoded file, a kubeconfig file or a KubeletConfiguration file
+// only the parsed fields of certificates are returned, private keys are never decoded
+func ParseCertFile(path string, data []byte) (*CertFile, error) {
+	f := &CertFile{Path: path, Certificates: make([]Certificate, 0)}
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		kc := &kubeletConfig{}
+		if err := yaml.Unmarshal(data, kc); err == nil && kc.Kind == "KubeletConfiguration" {
+			f.Kubelet = &KubeletCerts{
+				TLSCertFile:        kc.TLSCertFile,
+				ClientCAFile:       kc.Authentication.X509.ClientCAFile,
+				RotateCertificates: kc.RotateCertificates,
+				ServerTLSBootstrap: kc.ServerTLSBootstrap,
+			}
+			return f, nil
+		}
+
+		if err := f.parseKubeConfig(data); err != nil {
+			return nil, errors.Wrapf(err, "parse %s failed", path)
+		}
+		return f, nil
+	}
+
+	if err := f.parsePEM(data); err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", path)
+	}
+
+	if len(f.Certificates) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return f, nil
+}
+
+// parsePEM append all "CERTIFICATE" blocks in data, other blocks such as private keys are skipped
+func (f *CertFile) parsePEM(data []byte) error {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+
+		c := Certificate{
+			Subject:  cert.Subject.CommonName,
+			DNSNames: cert.DNSNames,
+			NotAfter: cert.NotAfter,
+		}
+		for _, ip := range cert.IPAddresses {
+			c.IPAddresses = append(c.IPAddresses, ip.String())
+		}
+		f.Certificates = append(f.Certificates, c)
+	}
+}
+
+// parseKubeConfig append the certificate authorities and client certificates in kubeconfig
+// the certificate files referenced by kubeconfig are added to Files
+func (f *CertFile) parseKubeConfig(data []byte) error {
+	config := &kubeConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return err
+	}
+
+	add := func(file string, encoded string) error {
+		if file != "" {
+			f.Files = append(f.Files, file)
+		}
+
+		if encoded == "" {
+			return nil
+		}
+
+		pemData, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+		return f.parsePEM(pemData)
+	}
+
+	for _, c := range config.Clusters {
+		if err := add(c.Cluster.CertificateAuthority, c.Cluster.CertificateAuthorityData); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range config.Users {
+		if err := add(u.User.ClientCertificate, u.User.ClientCertificateData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Doer send a request to agent and return the response body
+// an error should be returned if response status is not 2xx
+type Doer interface {
+	Do(method string, path string, query url.Values, body []byte) ([]byte, error)
+}
+
+// HTTPDoer send requests to agent directly
+type HTTPDoer struct {
+	// BaseURL is the url of agent, e.g. "http://10.0.0.1:9876"
+	BaseURL string
+	// Token is the token of agent
+	Token string
+	// Client is the http client, default is http.DefaultClient
+	Client *http.Client
+}
+
+// Do send a request to agent and return the response body
+func (h *HTTPDoer) Do(method string, path string, query url.Values, body []byte) ([]byte, error) {
+	u := strings.TrimRight(h.BaseURL, "/") + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(TokenHeader, h.Token)
+
+	cli := h.Client
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// Client call the agent API
+type Client struct {
+	doer Doer
+}
+
+// NewClient return a new Client
+func NewClient(doer Doer) *Client {
+	return &Client{doer: doer}
+}
+
+func (c *Client) get(path string, query url.Values, obj interface{}) error {
+	data, err := c.doer.Do(http.MethodGet, path, query, nil)
+	if err != nil {
+		return errors.Wrapf(err, "get %s failed", path)
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return errors.Wrapf(err, "unmarshal %s failed", path)
+	}
+	return nil
+}
+
+// SysCtl return all kernel parameters
+func (c *Client) SysCtl() (map[string]string, error) {
+	result := map[string]string{}
+	if err := c.get(PathSysCtl, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IPTables return all iptables rules
+func (c *Client) IPTables() (*IPTables, error) {
+	result := &IPTables{}
+	if err := c.get(PathIPTables, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Processes return processes with target name, all processes will be returned if name is empty
+func (c *Client) Processes(name string) ([]Process, error) {
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
+	}
+
+	result := make([]Process, 0)
+	if err := c.get(PathProcesses, query, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Mounts return all mount points
+func (c *Client) Mounts() ([]Mount, error) {
+	result := make([]Mount, 0)
+	if err := c.get(PathMounts, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Disks return the usage of all mounted block devices
+func (c *Client) Disks() ([]Disk, error) {
+	result := make([]Disk, 0)
+	if err := c.get(PathDisks, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Kernel return the kernel and os information
+func (c *Client) Kernel() (*Kernel, error) {
+	result := &Kernel{}
+	if err := c.get(PathKernel, nil, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Runtimes return all container runtimes
+func (c *Client) Runtimes() ([]Runtime, error) {
+	result := make([]Runtime, 0)
+	if err := c.get(PathRuntimes, nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CertFile return the certificates in a PEM encoded file or a kubeconfig file on node
+func (c *Client) CertFile(path string) (*CertFile, error) {
+	result := &CertFile{}
+	if err := c.get(PathCerts, url.Values{"path": []string{path}}, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Exec execute a command on node
+func (c *Client) Exec(req *ExecRequest) (*ExecResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.doer.Do(http.MethodPost, PathExec, nil, body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "post %s failed", PathExec)
+	}
+
+	resp := &ExecResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal %s failed", PathExec)
+	}
+	return resp, nil
+}
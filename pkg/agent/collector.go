@@ -0,0 +1,460 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// runtimeSockets are the well known sockets of container runtimes
+var runtimeSockets = []Runtime{
+	{Name: "docker", Socket: "/var/run/docker.sock"},
+	{Name: "containerd", Socket: "/run/containerd/containerd.sock"},
+	{Name: "crio", Socket: "/var/run/crio/crio.sock"},
+}
+
+// runtimeDaemons are the daemon process names of container runtimes
+var runtimeDaemons = map[string]string{
+	"docker":     "dockerd",
+	"containerd": "containerd",
+	"crio":       "crio",
+}
+
+// DefaultExecAllowList is the commands that can be executed by Exec if agent is started without "-exec-allow-list"
+// they are the commands of built-in machine collectors of custom cluster,
+// node executor "agent" passes the commands of all registered machine collectors by "-exec-allow-list" instead,
+// commands that need host files or host tools are executed in the mount namespace of host by "nsenter"
+var DefaultExecAllowList = [][]string{
+	{"sh", "-c", "uname -r; uname -v; uname -m"},
+	{"cat", "/proc/uptime"},
+	{"nsenter", "-t", "1", "-m", "--", "df", "-PTk"},
+	{"cat", "/proc/meminfo"},
+	{"cat", "/proc/1/limits"},
+	{"cat", "/proc/sys/net/netfilter/nf_conntrack_count", "/proc/sys/net/netfilter/nf_conntrack_max"},
+	{"nsenter", "-t", "1", "-m", "--", "timedatectl", "status"},
+	{"nsenter", "-t", "1", "-m", "--", "sh", "-c", "stat -fc %T /sys/fs/cgroup/; " +
+		"docker info 2>/dev/null | grep -i 'cgroup driver'; grep -s SystemdCgroup /etc/containerd/config.toml; true"},
+	{"sh", "-c", "command -v ipvsadm >/dev/null || { echo 'ipvsadm: command not found' >&2; exit 127; }; " +
+		"ipvsadm -Ln 2>/dev/null; true"},
+}
+
+// DefaultFileAllowDirs is the directories that certificate files can be read by CertFile by default
+// they are the well known directories of certificates and kubeconfig files of core components
+var DefaultFileAllowDirs = []string{
+	"/etc/kubernetes",
+	"/var/lib/kubelet",
+	"/etc/etcd",
+	"/etc/ssl/etcd",
+}
+
+// Collector collect node information from proc file system and host root
+type Collector struct {
+	// ProcDir is the proc file system of host, default is "/proc"
+	// agent must run with hostPID and hostNetwork so that "/proc" is the proc of host
+	ProcDir string
+	// HostRoot is the path that host root mounted to, default is "/"
+	HostRoot string
+	// IPTablesSave is the command to dump iptables rules, default is "iptables-save"
+	IPTablesSave string
+	// ExecAllowList is the commands that can be executed by Exec, default is DefaultExecAllowList
+	// a command is allowed only if it is exactly the same as one of them
+	ExecAllowList [][]string
+	// FileAllowDirs is the directories of host that certificate files can be read by CertFile, default is DefaultFileAllowDirs
+	FileAllowDirs []string
+}
+
+// NewCollector return a Collector with default values
+func NewCollector() *Collector {
+	return &Collector{
+		ProcDir:       "/proc",
+		HostRoot:      "/",
+		IPTablesSave:  "iptables-save",
+		ExecAllowList: DefaultExecAllowList,
+		FileAllowDirs: DefaultFileAllowDirs,
+	}
+}
+
+func (c *Collector) procPath(elem ...string) string {
+	return filepath.Join(append([]string{c.ProcDir}, elem...)...)
+}
+
+func (c *Collector) hostPath(path string) string {
+	return filepath.Join(c.HostRoot, path)
+}
+
+// SysCtl return all kernel parameters, like "sysctl -a"
+// unreadable parameters are skipped
+func (c *Collector) SysCtl() (map[string]string, error) {
+	root := c.procPath("sys")
+	result := map[string]string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Mode().Perm()&0444 == 0 {
+			return nil
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+
+		key := strings.Replace(rel, string(filepath.Separator), ".", -1)
+		result[key] = strings.TrimSpace(string(data))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walk %s failed", root)
+	}
+	return result, nil
+}
+
+// IPTables return all iptables rules
+func (c *Collector) IPTables() (*IPTables, error) {
+	out, err := exec.Command(c.IPTablesSave).Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "run %s failed", c.IPTablesSave)
+	}
+
+	return &IPTables{
+		Save:   string(out),
+		Tables: ParseIPTablesSave(string(out)),
+	}, nil
+}
+
+// Processes return all processes, or processes with target name if name is not empty
+// name can be the name in /proc/[pid]/comm or the base name of executable
+func (c *Collector) Processes(name string) ([]Process, error) {
+	dirs, err := ioutil.ReadDir(c.ProcDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", c.ProcDir)
+	}
+
+	result := make([]Process, 0)
+	for _, d := range dirs {
+		pid, err := strconv.Atoi(d.Name())
+		if err != nil || !d.IsDir() {
+			continue
+		}
+
+		comm, err := ioutil.ReadFile(c.procPath(d.Name(), "comm"))
+		if err != nil {
+			// the process may exit
+			continue
+		}
+
+		cmdline, err := ioutil.ReadFile(c.procPath(d.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		p := Process{
+			PID:     pid,
+			Name:    strings.TrimSpace(string(comm)),
+			Cmdline: splitCmdline(cmdline),
+		}
+
+		if name != "" && p.Name != name &&
+			(len(p.Cmdline) == 0 || filepath.Base(p.Cmdline[0]) != name) {
+			continue
+		}
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PID < result[j].PID
+	})
+	return result, nil
+}
+
+func splitCmdline(data []byte) []string {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return []string{}
+	}
+	return strings.Split(string(data), "\x00")
+}
+
+// Mounts return all mount points in the mount namespace of host init process
+func (c *Collector) Mounts() ([]Mount, error) {
+	path := c.procPath("1", "mounts")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", path)
+	}
+
+	result := make([]Mount, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+
+		result = append(result, Mount{
+			Device:     unescapeMount(fields[0]),
+			MountPoint: unescapeMount(fields[1]),
+			FSType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+		})
+	}
+	return result, nil
+}
+
+// unescapeMount replace octal escapes like "\040" in /proc/mounts
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	buf := bytes.NewBuffer(nil)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				buf.WriteByte(byte(v))
+				i += 3
+				continue
+			}
+		}
+		buf.WriteByte(s[i])
+	}
+	return buf.String()
+}
+
+// Disks return the usage of all mounted block devices
+// a device mounted more than once is only reported once
+func (c *Collector) Disks() ([]Disk, error) {
+	mounts, err := c.Mounts()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Disk, 0)
+	devices := map[string]bool{}
+	for _, m := range mounts {
+		if !strings.HasPrefix(m.Device, "/dev/") || devices[m.Device] {
+			continue
+		}
+
+		d, err := statDisk(c.hostPath(m.MountPoint))
+		if err != nil {
+			continue
+		}
+
+		devices[m.Device] = true
+		d.Device = m.Device
+		d.MountPoint = m.MountPoint
+		d.FSType = m.FSType
+		result = append(result, *d)
+	}
+	return result, nil
+}
+
+// Kernel return the kernel and os information
+func (c *Collector) Kernel() (*Kernel, error) {
+	k := &Kernel{
+		Arch: runtime.GOARCH,
+	}
+
+	for path, value := range map[string]*string{
+		"sys/kernel/ostype":    &k.OSType,
+		"sys/kernel/osrelease": &k.Release,
+		"sys/kernel/version":   &k.Version,
+		"sys/kernel/hostname":  &k.Hostname,
+	} {
+		data, err := ioutil.ReadFile(c.procPath(path))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read %s failed", path)
+		}
+		*value = strings.TrimSpace(string(data))
+	}
+
+	data, err := ioutil.ReadFile(c.procPath("uptime"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read uptime failed")
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 0 {
+		k.Uptime, _ = strconv.ParseFloat(fields[0], 64)
+	}
+
+	if data, err := ioutil.ReadFile(c.hostPath("/etc/os-release")); err == nil {
+		k.OSImage = parseOSRelease(string(data))["PRETTY_NAME"]
+	}
+	return k, nil
+}
+
+func parseOSRelease(data string) map[string]string {
+	result := map[string]string{}
+	for _, line := range strings.Split(data, "\n") {
+		kv := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		result[kv[0]] = strings.Trim(kv[1], `"'`)
+	}
+	return result
+}
+
+// Runtimes return all container runtimes found on node
+// a runtime is found if its socket exists or its daemon is running
+func (c *Collector) Runtimes() ([]Runtime, error) {
+	processes, err := c.Processes("")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Runtime, 0)
+	for _, r := range runtimeSockets {
+		rt := Runtime{Name: r.Name}
+		if _, err := os.Stat(c.hostPath(r.Socket)); err == nil {
+			rt.Socket = r.Socket
+		}
+
+		for _, p := range processes {
+			if p.Name == runtimeDaemons[r.Name] {
+				rt.PID = p.PID
+				break
+			}
+		}
+
+		if rt.Socket != "" || rt.PID != 0 {
+			result = append(result, rt)
+		}
+	}
+	return result, nil
+}
+
+// CertFile return the certificates in a PEM encoded file or a kubeconfig file of host
+// the file must be in FileAllowDirs, symbolic links are followed but the target must be in FileAllowDirs too
+// the content of file is never returned, private keys and client keys of kubeconfig are not decoded
+func (c *Collector) CertFile(path string) (*CertFile, error) {
+	if !filepath.IsAbs(path) {
+		return nil, fmt.Errorf("path %s is not absolute", path)
+	}
+	path = filepath.Clean(path)
+
+	target, err := filepath.EvalSymlinks(c.hostPath(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", path)
+	}
+
+	if !c.fileAllowed(target) {
+		return nil, fmt.Errorf("file %s is not allowed", path)
+	}
+
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read %s failed", path)
+	}
+	return ParseCertFile(path, data)
+}
+
+// fileAllowed return true if target is in FileAllowDirs of host root
+func (c *Collector) fileAllowed(target string) bool {
+	for _, dir := range c.FileAllowDirs {
+		d, err := filepath.EvalSymlinks(c.hostPath(dir))
+		if err != nil {
+			continue
+		}
+
+		if rel, err := filepath.Rel(d, target); err == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecAllowed return true if cmd is in ExecAllowList
+func (c *Collector) ExecAllowed(cmd []string) bool {
+	for _, allowed := range c.ExecAllowList {
+		if len(allowed) != len(cmd) {
+			continue
+		}
+
+		match := true
+		for i := range cmd {
+			if cmd[i] != allowed[i] {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// Exec execute a command in ExecAllowList and return its output
+func (c *Collector) Exec(ctx context.Context, req *ExecRequest) *ExecResponse {
+	if len(req.Command) == 0 {
+		return &ExecResponse{Error: "command can not be empty"}
+	}
+
+	if !c.ExecAllowed(req.Command) {
+		return &ExecResponse{Error: fmt.Sprintf("command %v is not allowed", req.Command)}
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = 60
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd := exec.CommandContext(ctx, req.Command[0], req.Command[1:]...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	resp := &ExecResponse{}
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && ctx.Err() == nil {
+			resp.ExitCode = exitErr.ExitCode()
+		} else {
+			resp.Error = err.Error()
+		}
+	}
+
+	resp.Stdout = stdout.String()
+	resp.Stderr = stderr.String()
+	return resp
+}
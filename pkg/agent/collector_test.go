@@ -0,0 +1,331 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFakeCollector create a Collector with a fake proc dir and host root
+func newFakeCollector(t *testing.T) *Collector {
+	root, err := ioutil.TempDir("", "agent")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(root) })
+
+	files := map[string]string{
+		"proc/sys/net/ipv4/ip_forward":   "1\n",
+		"proc/sys/kernel/ostype":         "Linux\n",
+		"proc/sys/kernel/osrelease":      "4.14.105\n",
+		"proc/sys/kernel/version":        "#1 SMP\n",
+		"proc/sys/kernel/hostname":       "node1\n",
+		"proc/uptime":                    "350.27 1000.00\n",
+		"proc/1/comm":                    "systemd\n",
+		"proc/1/cmdline":                 "/sbin/init\x00",
+		"proc/1/mounts":                  "/dev/root / ext4 rw,relatime 0 0\nproc /proc proc rw 0 0\n/dev/root /data\\040dir ext4 rw 0 0\n",
+		"proc/100/comm":                  "kubelet\n",
+		"proc/100/cmdline":               "/usr/bin/kubelet\x00--v=2\x00",
+		"proc/200/comm":                  "dockerd\n",
+		"proc/200/cmdline":               "/usr/bin/dockerd\x00",
+		"host/etc/os-release":            "NAME=Ubuntu\nPRETTY_NAME=\"Ubuntu 18.04.3 LTS\"\n",
+		"host/var/run/docker.sock":       "",
+		"host/data dir/placeholder.txt":  "",
+		"host/etc/kubernetes/pki/ca.crt": string(newTestCert(t, "ca")),
+		"host/etc/kubernetes/pki/ca.key": testKeyPEM,
+		"host/etc/kubernetes/admin.conf": string(newTestKubeConfig(t)),
+		"host/etc/shadow":                "secret",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	if err := os.Symlink("../shadow", filepath.Join(root, "host/etc/kubernetes/shadow")); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	return &Collector{
+		ProcDir:      filepath.Join(root, "proc"),
+		HostRoot:     filepath.Join(root, "host"),
+		IPTablesSave: "iptables-save",
+		ExecAllowList: [][]string{
+			{"/bin/sh", "-c", "echo -n 123; exit 2"},
+		},
+		FileAllowDirs: []string{"/etc/kubernetes"},
+	}
+}
+
+func TestCollector_SysCtl(t *testing.T) {
+	c := newFakeCollector(t)
+	result, err := c.SysCtl()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if result["net.ipv4.ip_forward"] != "1" {
+		t.Fatalf("want net.ipv4.ip_forward=1 but get %s", result["net.ipv4.ip_forward"])
+	}
+
+	if result["kernel.hostname"] != "node1" {
+		t.Fatalf("want kernel.hostname=node1 but get %s", result["kernel.hostname"])
+	}
+}
+
+func TestCollector_Processes(t *testing.T) {
+	var cases = []struct {
+		name string
+		pids []int
+	}{
+		{
+			name: "",
+			pids: []int{1, 100, 200},
+		},
+		{
+			name: "kubelet",
+			pids: []int{100},
+		},
+		{
+			name: "init",
+			pids: []int{1},
+		},
+		{
+			name: "kube-proxy",
+			pids: []int{},
+		},
+	}
+
+	c := newFakeCollector(t)
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			ps, err := c.Processes(cs.name)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if len(ps) != len(cs.pids) {
+				t.Fatalf("want %d processes but get %d", len(cs.pids), len(ps))
+			}
+
+			for i, p := range ps {
+				if p.PID != cs.pids[i] {
+					t.Fatalf("want pid %d but get %d", cs.pids[i], p.PID)
+				}
+			}
+		})
+	}
+
+	ps, _ := c.Processes("kubelet")
+	if len(ps[0].Cmdline) != 2 || ps[0].Cmdline[1] != "--v=2" {
+		t.Fatalf("wrong cmdline %v", ps[0].Cmdline)
+	}
+}
+
+func TestCollector_Mounts(t *testing.T) {
+	c := newFakeCollector(t)
+	ms, err := c.Mounts()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(ms) != 3 {
+		t.Fatalf("want 3 mounts but get %d", len(ms))
+	}
+
+	if ms[2].MountPoint != "/data dir" {
+		t.Fatalf("want mount point '/data dir' but get '%s'", ms[2].MountPoint)
+	}
+
+	ds, err := c.Disks()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(ds) != 1 || ds[0].MountPoint != "/" {
+		t.Fatalf("want only one disk mounted at / but get %v", ds)
+	}
+}
+
+func TestCollector_Kernel(t *testing.T) {
+	c := newFakeCollector(t)
+	k, err := c.Kernel()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if k.Release != "4.14.105" || k.Hostname != "node1" || k.Uptime != 350.27 {
+		t.Fatalf("wrong kernel info %+v", k)
+	}
+
+	if k.OSImage != "Ubuntu 18.04.3 LTS" {
+		t.Fatalf("want os image Ubuntu 18.04.3 LTS but get %s", k.OSImage)
+	}
+}
+
+func TestCollector_Runtimes(t *testing.T) {
+	c := newFakeCollector(t)
+	rs, err := c.Runtimes()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(rs) != 1 {
+		t.Fatalf("want 1 runtime but get %d", len(rs))
+	}
+
+	if rs[0].Name != "docker" || rs[0].PID != 200 || rs[0].Socket != "/var/run/docker.sock" {
+		t.Fatalf("wrong runtime %+v", rs[0])
+	}
+}
+
+func TestCollector_Exec(t *testing.T) {
+	var cases = []struct {
+		name     string
+		req      *ExecRequest
+		stdout   string
+		exitCode int
+		hasErr   bool
+	}{
+		{
+			name:   "success",
+			req:    &ExecRequest{Command: []string{"/bin/sh", "-c", "echo -n 123"}},
+			stdout: "123",
+		},
+		{
+			name:     "exit code",
+			req:      &ExecRequest{Command: []string{"/bin/sh", "-c", "exit 3"}},
+			exitCode: 3,
+		},
+		{
+			name:   "empty",
+			req:    &ExecRequest{},
+			hasErr: true,
+		},
+		{
+			name:   "not allowed",
+			req:    &ExecRequest{Command: []string{"/bin/sh", "-c", "echo -n 456"}},
+			hasErr: true,
+		},
+		{
+			name:   "timeout",
+			req:    &ExecRequest{Command: []string{"/bin/sh", "-c", "exec sleep 5"}, Timeout: 1},
+			hasErr: true,
+		},
+	}
+
+	c := NewCollector()
+	c.ExecAllowList = [][]string{
+		{"/bin/sh", "-c", "echo -n 123"},
+		{"/bin/sh", "-c", "exit 3"},
+		{"/bin/sh", "-c", "exec sleep 5"},
+	}
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			resp := c.Exec(context.Background(), cs.req)
+			if (resp.Error != "") != cs.hasErr {
+				t.Fatalf("want hasErr %v but get error '%s'", cs.hasErr, resp.Error)
+			}
+
+			if resp.Stdout != cs.stdout {
+				t.Fatalf("want stdout %s but get %s", cs.stdout, resp.Stdout)
+			}
+
+			if resp.ExitCode != cs.exitCode {
+				t.Fatalf("want exit code %d but get %d", cs.exitCode, resp.ExitCode)
+			}
+		})
+	}
+}
+
+func TestCollector_CertFile(t *testing.T) {
+	var cases = []struct {
+		name     string
+		path     string
+		subjects int
+		hasErr   bool
+	}{
+		{
+			name:     "allowed",
+			path:     "/etc/kubernetes/pki/ca.crt",
+			subjects: 1,
+		},
+		{
+			name:     "not clean",
+			path:     "/etc/kubernetes/../kubernetes/pki/ca.crt",
+			subjects: 1,
+		},
+		{
+			name:     "kubeconfig",
+			path:     "/etc/kubernetes/admin.conf",
+			subjects: 1,
+		},
+		{
+			name:   "private key",
+			path:   "/etc/kubernetes/pki/ca.key",
+			hasErr: true,
+		},
+		{
+			name:   "not allowed",
+			path:   "/etc/shadow",
+			hasErr: true,
+		},
+		{
+			name:   "escape by dot dot",
+			path:   "/etc/kubernetes/../shadow",
+			hasErr: true,
+		},
+		{
+			name:   "escape by symbolic link",
+			path:   "/etc/kubernetes/shadow",
+			hasErr: true,
+		},
+		{
+			name:   "relative",
+			path:   "etc/kubernetes/pki/ca.crt",
+			hasErr: true,
+		},
+		{
+			name:   "not exist",
+			path:   "/etc/kubernetes/pki/none.crt",
+			hasErr: true,
+		},
+	}
+
+	c := newFakeCollector(t)
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			f, err := c.CertFile(cs.path)
+			if (err != nil) != cs.hasErr {
+				t.Fatalf("want hasErr %v but get error %v", cs.hasErr, err)
+			}
+
+			if err == nil && len(f.Certificates) != cs.subjects {
+				t.Fatalf("want %d certificates but get %d", cs.subjects, len(f.Certificates))
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"strings"
+)
+
+// ParseIPTablesSave parse the output of "iptables-save" to tables
+func ParseIPTablesSave(out string) map[string]IPTable {
+	tables := map[string]IPTable{}
+	var cur IPTable
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || line == "COMMIT":
+			continue
+		case strings.HasPrefix(line, "*"):
+			cur = IPTable{Chains: map[string]IPChain{}}
+			tables[line[1:]] = cur
+		case cur.Chains == nil:
+			continue
+		case strings.HasPrefix(line, ":"):
+			// :INPUT ACCEPT [0:0]
+			fields := strings.Fields(line[1:])
+			if len(fields) < 2 {
+				continue
+			}
+			chain := cur.Chains[fields[0]]
+			chain.Policy = fields[1]
+			cur.Chains[fields[0]] = chain
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.SplitN(line[3:], " ", 2)
+			rule := ""
+			if len(fields) == 2 {
+				rule = fields[1]
+			}
+			chain := cur.Chains[fields[0]]
+			chain.Rules = append(chain.Rules, rule)
+			cur.Chains[fields[0]] = chain
+		}
+	}
+	return tables
+}
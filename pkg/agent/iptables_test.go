@@ -0,0 +1,53 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import "testing"
+
+func TestParseIPTablesSave(t *testing.T) {
+	out := `# Generated by iptables-save v1.6.1
+*nat
+:PREROUTING ACCEPT [0:0]
+:KUBE-SERVICES - [0:0]
+-A PREROUTING -m comment --comment "kubernetes service portals" -j KUBE-SERVICES
+-A KUBE-SERVICES -d 10.0.0.1/32 -p tcp -j KUBE-SVC-NPX46M4PTMTKRN6Y
+COMMIT
+*filter
+:INPUT DROP [0:0]
+-A INPUT -j KUBE-FIREWALL
+COMMIT
+`
+	tables := ParseIPTablesSave(out)
+	if len(tables) != 2 {
+		t.Fatalf("want 2 tables but get %d", len(tables))
+	}
+
+	nat := tables["nat"]
+	if nat.Chains["PREROUTING"].Policy != "ACCEPT" || len(nat.Chains["PREROUTING"].Rules) != 1 {
+		t.Fatalf("wrong PREROUTING chain %+v", nat.Chains["PREROUTING"])
+	}
+
+	if nat.Chains["KUBE-SERVICES"].Policy != "-" || len(nat.Chains["KUBE-SERVICES"].Rules) != 1 {
+		t.Fatalf("wrong KUBE-SERVICES chain %+v", nat.Chains["KUBE-SERVICES"])
+	}
+
+	if tables["filter"].Chains["INPUT"].Policy != "DROP" ||
+		tables["filter"].Chains["INPUT"].Rules[0] != "-j KUBE-FIREWALL" {
+		t.Fatalf("wrong INPUT chain %+v", tables["filter"].Chains["INPUT"])
+	}
+}
@@ -0,0 +1,129 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+// Server serve the agent API over http
+type Server struct {
+	collector *Collector
+	token     string
+	logger    logger.Logger
+	mux       *http.ServeMux
+}
+
+// NewServer return a new agent Server
+// all requests except health checking must carry the token in TokenHeader
+// all requests are rejected if token is empty
+func NewServer(logger logger.Logger, collector *Collector, token string) *Server {
+	s := &Server{
+		collector: collector,
+		token:     token,
+		logger:    logger,
+		mux:       http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc(PathHealthz, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	s.handle(PathSysCtl, func(r *http.Request) (interface{}, error) {
+		return s.collector.SysCtl()
+	})
+	s.handle(PathIPTables, func(r *http.Request) (interface{}, error) {
+		return s.collector.IPTables()
+	})
+	s.handle(PathProcesses, func(r *http.Request) (interface{}, error) {
+		return s.collector.Processes(r.URL.Query().Get("name"))
+	})
+	s.handle(PathMounts, func(r *http.Request) (interface{}, error) {
+		return s.collector.Mounts()
+	})
+	s.handle(PathDisks, func(r *http.Request) (interface{}, error) {
+		return s.collector.Disks()
+	})
+	s.handle(PathKernel, func(r *http.Request) (interface{}, error) {
+		return s.collector.Kernel()
+	})
+	s.handle(PathRuntimes, func(r *http.Request) (interface{}, error) {
+		return s.collector.Runtimes()
+	})
+	s.handle(PathCerts, func(r *http.Request) (interface{}, error) {
+		return s.collector.CertFile(r.URL.Query().Get("path"))
+	})
+	s.mux.HandleFunc(PathExec, s.exec)
+	return s
+}
+
+// ServeHTTP implement http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PathHealthz && (s.token == "" ||
+		subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(s.token)) != 1) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handle(path string, collect func(r *http.Request) (interface{}, error)) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		obj, err := collect(r)
+		if err != nil {
+			s.logger.Errorf("collect %s failed: %v", path, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, obj)
+	})
+}
+
+func (s *Server) exec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req := &ExecRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.writeJSON(w, s.collector.Exec(r.Context(), req))
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, obj interface{}) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
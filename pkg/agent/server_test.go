@@ -0,0 +1,130 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+func TestServer_EmptyToken(t *testing.T) {
+	s := httptest.NewServer(NewServer(logger.NewLogger(), newFakeCollector(t), ""))
+	defer s.Close()
+
+	cli := NewClient(&HTTPDoer{BaseURL: s.URL})
+	if _, err := cli.SysCtl(); err == nil || !strings.Contains(err.Error(), "invalid token") {
+		t.Fatalf("should return invalid token error but get %v", err)
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := httptest.NewServer(NewServer(logger.NewLogger(), newFakeCollector(t), "token"))
+	defer s.Close()
+
+	t.Run("invalid token", func(t *testing.T) {
+		cli := NewClient(&HTTPDoer{BaseURL: s.URL, Token: "wrong"})
+		if _, err := cli.SysCtl(); err == nil || !strings.Contains(err.Error(), "invalid token") {
+			t.Fatalf("should return invalid token error but get %v", err)
+		}
+	})
+
+	t.Run("healthz", func(t *testing.T) {
+		out, err := (&HTTPDoer{BaseURL: s.URL}).Do("GET", PathHealthz, nil, nil)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if string(out) != "ok" {
+			t.Fatalf("want ok but get %s", string(out))
+		}
+	})
+
+	cli := NewClient(&HTTPDoer{BaseURL: s.URL, Token: "token"})
+	t.Run("sysctl", func(t *testing.T) {
+		result, err := cli.SysCtl()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if result["net.ipv4.ip_forward"] != "1" {
+			t.Fatalf("want net.ipv4.ip_forward=1 but get %s", result["net.ipv4.ip_forward"])
+		}
+	})
+
+	t.Run("processes", func(t *testing.T) {
+		ps, err := cli.Processes("kubelet")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if len(ps) != 1 || ps[0].PID != 100 {
+			t.Fatalf("want kubelet process 100 but get %v", ps)
+		}
+	})
+
+	t.Run("kernel", func(t *testing.T) {
+		k, err := cli.Kernel()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if k.Hostname != "node1" {
+			t.Fatalf("want hostname node1 but get %s", k.Hostname)
+		}
+	})
+
+	t.Run("certs", func(t *testing.T) {
+		f, err := cli.CertFile("/etc/kubernetes/pki/ca.crt")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if len(f.Certificates) != 1 || f.Certificates[0].Subject != "ca" {
+			t.Fatalf("want certificate ca but get %+v", f.Certificates)
+		}
+
+		if _, err := cli.CertFile("/etc/shadow"); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Fatalf("file should not be allowed but get %v", err)
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		resp, err := cli.Exec(&ExecRequest{Command: []string{"/bin/sh", "-c", "echo -n 123; exit 2"}})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if resp.Stdout != "123" || resp.ExitCode != 2 {
+			t.Fatalf("wrong exec response %+v", resp)
+		}
+	})
+
+	t.Run("exec not allowed", func(t *testing.T) {
+		resp, err := cli.Exec(&ExecRequest{Command: []string{"/bin/sh", "-c", "id"}})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if !strings.Contains(resp.Error, "not allowed") {
+			t.Fatalf("command should not be allowed but get %+v", resp)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package agent
+
+import "time"
+
+const (
+	// APIVersion is the version of agent API, all paths are prefixed with "/" + APIVersion
+	APIVersion = "v1"
+	// TokenHeader is the http header that carry the token of agent
+	TokenHeader = "X-Kube-Jarvis-Token"
+	// DefaultPort is the default listening port of agent
+	DefaultPort = 9876
+)
+
+// paths of agent API
+const (
+	PathHealthz   = "/healthz"
+	PathSysCtl    = "/" + APIVersion + "/sysctl"
+	PathIPTables  = "/" + APIVersion + "/iptables"
+	PathProcesses = "/" + APIVersion + "/processes"
+	PathMounts    = "/" + APIVersion + "/mounts"
+	PathDisks     = "/" + APIVersion + "/disks"
+	PathKernel    = "/" + APIVersion + "/kernel"
+	PathRuntimes  = "/" + APIVersion + "/runtimes"
+	PathExec      = "/" + APIVersion + "/exec"
+	PathCerts     = "/" + APIVersion + "/certs"
+)
+
+// IPTables is the iptables rules of node
+type IPTables struct {
+	// Save is the raw output of "iptables-save"
+	Save string
+	// Tables is the parsed tables, the key is table name, e.g. "filter", "nat"
+	Tables map[string]IPTable
+}
+
+// IPTable is a table of iptables
+type IPTable struct {
+	// Chains is all chains of the table, the key is chain name
+	Chains map[string]IPChain
+}
+
+// IPChain is a chain of iptables
+type IPChain struct {
+	// Policy is the policy of built-in chain, e.g. "ACCEPT", it is "-" for user defined chains
+	Policy string
+	// Rules are rules of chain without "-A CHAIN"
+	Rules []string
+}
+
+// Process is a process running on node
+type Process struct {
+	// PID is the process id
+	PID int
+	// Name is the name of process, that is the content of /proc/[pid]/comm
+	Name string
+	// Cmdline is the command line of process
+	Cmdline []string
+}
+
+// Mount is a mount point on node
+type Mount struct {
+	// Device is the mounted device, e.g. "/dev/vda1", "tmpfs"
+	Device string
+	// MountPoint is the mount point on node
+	MountPoint string
+	// FSType is the file system type, e.g. "ext4", "xfs"
+	FSType string
+	// Options are the mount options, e.g. "rw", "relatime"
+	Options []string
+}
+
+// Disk is the usage of a mounted block device
+type Disk struct {
+	// Device is the block device, e.g. "/dev/vda1"
+	Device string
+	// MountPoint is the mount point on node
+	MountPoint string
+	// FSType is the file system type, e.g. "ext4", "xfs"
+	FSType string
+	// Total is the total bytes of disk
+	Total uint64
+	// Free is the free bytes of disk
+	Free uint64
+	// Available is the bytes available to unprivileged user
+	Available uint64
+	// Inodes is the total inodes of disk
+	Inodes uint64
+	// InodesFree is the free inodes of disk
+	InodesFree uint64
+}
+
+// Kernel is the kernel and os information of node
+type Kernel struct {
+	// OSType is the kernel name, e.g. "Linux"
+	OSType string
+	// Release is the kernel release, e.g. "4.14.105-19-0012"
+	Release string
+	// Version is the kernel version
+	Version string
+	// Hostname is the hostname of node
+	Hostname string
+	// Arch is the cpu architecture, e.g. "amd64"
+	Arch string
+	// OSImage is the PRETTY_NAME in /etc/os-release, e.g. "CentOS Linux 7 (Core)"
+	OSImage string
+	// Uptime is the seconds since node booted
+	Uptime float64
+}
+
+// Runtime is a container runtime on node
+type Runtime struct {
+	// Name is the runtime name, e.g. "docker", "containerd", "crio"
+	Name string
+	// Socket is the path of runtime socket, it is empty if socket not found
+	Socket string
+	// PID is the pid of runtime daemon, it is 0 if daemon not running
+	PID int
+}
+
+// CertFile is the certificates in a PEM encoded file or a kubeconfig file on node
+type CertFile struct {
+	// Path is the path of file on node
+	Path string
+	// Certificates is the certificates in file
+	Certificates []Certificate
+	// Files is the certificate files referenced by kubeconfig, they are not read
+	Files []string
+	// Kubelet is the certificate settings if file is a KubeletConfiguration
+	Kubelet *KubeletCerts
+}
+
+// KubeletCerts is the certificate settings in KubeletConfiguration
+type KubeletCerts struct {
+	// TLSCertFile is the serving certificate file of kubelet
+	TLSCertFile string
+	// ClientCAFile is the certificate authorities of kubelet authentication
+	ClientCAFile string
+	// RotateCertificates indicate whether the client certificate of kubelet is rotated
+	RotateCertificates bool
+	// ServerTLSBootstrap indicate whether the serving certificate of kubelet is requested from api server
+	ServerTLSBootstrap bool
+}
+
+// Certificate is the parsed fields of a x509 certificate
+type Certificate struct {
+	// Subject is the common name of certificate
+	Subject string
+	// DNSNames is the DNS subject alternative names
+	DNSNames []string
+	// IPAddresses is the IP subject alternative names
+	IPAddresses []string
+	// NotAfter is the expiration time of certificate
+	NotAfter time.Time
+}
+
+// ExecRequest is the request of exec API
+type ExecRequest struct {
+	// Command is the command to execute
+	Command []string
+	// Timeout is the max running time of command in seconds, default is 60
+	Timeout int
+}
+
+// ExecResponse is the response of exec API
+type ExecResponse struct {
+	// Stdout is the stdout of command
+	Stdout string
+	// Stderr is the stderr of command
+	Stderr string
+	// ExitCode is the exit code of command
+	ExitCode int
+	// Error is not empty if command can not be executed
+	Error string
+}
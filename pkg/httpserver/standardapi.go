@@ -80,6 +80,10 @@ type QueryRequest struct {
 	Offset int
 	// Limit is the max line of results
 	Limit int
+	// Lang is the target language of results
+	// if Lang is empty, url parameter "lang" or http header "Accept-Language" will be used
+	// the default language will be used if none of them is set
+	Lang string
 }
 
 // QueryResponse is the response of querying results
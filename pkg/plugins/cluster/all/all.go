@@ -20,8 +20,10 @@ package all
 import (
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/manifests"
 )
 
 func init() {
 	cluster.Add(custom.Type, cluster.Factory{Creator: custom.NewCluster})
+	cluster.Add(manifests.Type, cluster.Factory{Creator: manifests.NewCluster})
 }
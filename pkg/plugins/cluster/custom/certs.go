@@ -0,0 +1,209 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package custom
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"tkestack.io/kube-jarvis/pkg/agent"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/nodeexec"
+)
+
+// defaultKubeletCertDir is the default "--cert-dir" of kubelet
+const defaultKubeletCertDir = "/var/lib/kubelet/pki"
+
+// certArgs is the arguments of components that reference certificate files or kubeconfig files
+var certArgs = map[string][]string{
+	cluster.ComponentApiserver: {"tls-cert-file", "client-ca-file", "kubelet-client-certificate",
+		"kubelet-certificate-authority", "etcd-certfile", "etcd-cafile", "proxy-client-cert-file",
+		"requestheader-client-ca-file"},
+	cluster.ComponentControllerManager: {"cluster-signing-cert-file", "root-ca-file", "tls-cert-file", "kubeconfig"},
+	cluster.ComponentScheduler:         {"tls-cert-file", "kubeconfig"},
+	cluster.ComponentETCD:              {"cert-file", "peer-cert-file", "trusted-ca-file", "peer-trusted-ca-file"},
+	cluster.ComponentKubelet:           {"tls-cert-file", "client-ca-file", "kubeconfig"},
+}
+
+// initCertificates read certificate files referenced by arguments of components via node executor
+// components that are not fetched yet will be skipped once ctx is done
+// certificates of kubelet are only read on sampled nodes if sampled is not nil
+func (c *Cluster) initCertificates(ctx context.Context, stepName string, sampled map[string]bool) error {
+	var g errgroup.Group
+	conCtl := make(chan struct{}, nodeexec.Concurrency(c.nodeExecutor, 200))
+	for name, args := range certArgs {
+		comps := c.resources.CoreComponents[name]
+		for i := range comps {
+			comp := &comps[i]
+			if comp.Node == "" || comp.Error != nil || !comp.IsRunning {
+				continue
+			}
+
+			if name == cluster.ComponentKubelet && sampled != nil && !sampled[comp.Node] {
+				continue
+			}
+
+			compArgs := args
+			g.Go(func() error {
+				conCtl <- struct{}{}
+				defer func() { <-conCtl }()
+
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				if name == cluster.ComponentKubelet {
+					comp.Certificates = c.getKubeletCerts(comp.Node, comp.Args)
+					return nil
+				}
+				comp.Certificates = c.getComponentCerts(comp.Node, comp.Args, compArgs)
+				return nil
+			})
+		}
+		c.progress.AddStepPercent(stepName, 1)
+	}
+	return g.Wait()
+}
+
+// getComponentCerts read certificates in files referenced by target args
+func (c *Cluster) getComponentCerts(node string, args map[string]string, names []string) []cluster.Certificate {
+	result := make([]cluster.Certificate, 0)
+	read := map[string]bool{}
+	for _, arg := range names {
+		result = append(result, c.getFileCerts(node, arg, args[arg], read)...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// getKubeletCerts read certificates of kubelet
+// certificate files in the KubeletConfiguration of "--config" are used if they are not set by arguments,
+// the serving certificate and the rotated client certificate in "--cert-dir" are read if they are not specified
+func (c *Cluster) getKubeletCerts(node string, args map[string]string) []cluster.Certificate {
+	files := map[string]string{}
+	for _, arg := range certArgs[cluster.ComponentKubelet] {
+		files[arg] = args[arg]
+	}
+
+	certDir := args["cert-dir"]
+	if certDir == "" {
+		certDir = defaultKubeletCertDir
+	}
+	rotate := args["rotate-certificates"] == "true"
+	serverBootstrap := args["rotate-server-certificates"] == "true"
+
+	var result []cluster.Certificate
+	if path := args["config"]; path != "" {
+		f, err := c.readCertFile(node, path)
+		if err != nil {
+			c.logger.Errorf("read kubelet config %s on node %s failed: %v", path, node, err)
+			result = append(result, cluster.Certificate{Arg: "config", Path: path, Error: err.Error()})
+		} else if f.Kubelet != nil {
+			setDefault := func(arg, file string) {
+				if files[arg] == "" && file != "" {
+					if !filepath.IsAbs(file) {
+						file = filepath.Join(filepath.Dir(path), file)
+					}
+					files[arg] = file
+				}
+			}
+			setDefault("tls-cert-file", f.Kubelet.TLSCertFile)
+			setDefault("client-ca-file", f.Kubelet.ClientCAFile)
+
+			if _, exist := args["rotate-certificates"]; !exist {
+				rotate = f.Kubelet.RotateCertificates
+			}
+			if _, exist := args["rotate-server-certificates"]; !exist {
+				serverBootstrap = f.Kubelet.ServerTLSBootstrap
+			}
+		}
+	}
+
+	if files["tls-cert-file"] == "" {
+		files["tls-cert-file"] = filepath.Join(certDir, "kubelet.crt")
+		if serverBootstrap {
+			files["tls-cert-file"] = filepath.Join(certDir, "kubelet-server-current.pem")
+		}
+	}
+
+	names := certArgs[cluster.ComponentKubelet]
+	if rotate {
+		files["rotate-certificates"] = filepath.Join(certDir, "kubelet-client-current.pem")
+		names = append(append([]string{}, names...), "rotate-certificates")
+	}
+
+	result = append(result, c.getComponentCerts(node, files, names)...)
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+	return result
+}
+
+// readCertFile read the certificates in a PEM file or kubeconfig file of node via agent if node executor supports it,
+// or via "cat" in the mount namespace of host
+func (c *Cluster) readCertFile(node, path string) (*agent.CertFile, error) {
+	if fe, ok := c.nodeExecutor.(nodeexec.FactExecutor); ok {
+		return fe.CertFile(node, path)
+	}
+
+	out, errStr, err := c.nodeExecutor.DoCmd(node, nodeexec.HostCmd(c.nodeExecutor, []string{"cat", path}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cat failed: %s", strings.TrimSpace(errStr))
+	}
+	return agent.ParseCertFile(path, []byte(out))
+}
+
+// getFileCerts read all certificates of a PEM file or kubeconfig file on node
+// certificate files referenced by kubeconfig are read too
+func (c *Cluster) getFileCerts(node, arg, path string, read map[string]bool) []cluster.Certificate {
+	if path == "" || read[path] {
+		return nil
+	}
+	read[path] = true
+
+	f, err := c.readCertFile(node, path)
+	if err != nil {
+		c.logger.Errorf("read certificate %s on node %s failed: %v", path, node, err)
+		return []cluster.Certificate{{Arg: arg, Path: path, Error: err.Error()}}
+	}
+
+	result := make([]cluster.Certificate, 0, len(f.Certificates))
+	for _, cert := range f.Certificates {
+		result = append(result, cluster.Certificate{
+			Arg:      arg,
+			Path:     path,
+			Subject:  cert.Subject,
+			NotAfter: cert.NotAfter,
+		})
+	}
+
+	for _, file := range f.Files {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		result = append(result, c.getFileCerts(node, arg, file, read)...)
+	}
+	return result
+}
@@ -24,6 +24,8 @@ import (
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -31,6 +33,7 @@ import (
 	"tkestack.io/kube-jarvis/pkg/plugins"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/compexplorer"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/machine"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/nodeexec"
 )
 
@@ -45,6 +48,11 @@ type Cluster struct {
 	Node *nodeexec.Config
 	// Components is the components that will be explored
 	Components map[string]*compexplorer.Auto
+	// Collectors is the names of machine collectors that will be run on every node
+	// all registered collectors will be used if it is nil
+	Collectors []string
+	// Sampling collect machines of part of nodes per group instead of all nodes if it is not nil
+	Sampling *Sampling
 	// KubeConfig is the config file of kube-apiserver
 	KubeConfig string
 
@@ -130,6 +138,22 @@ func (c *Cluster) Complete() error {
 
 	c.Node.Complete()
 
+	if c.Collectors == nil {
+		c.Collectors = machine.Names()
+	}
+
+	for _, name := range c.Collectors {
+		if _, exist := machine.Collectors[name]; !exist {
+			return errors.Errorf("unknown machine collector %s", name)
+		}
+	}
+
+	if c.Sampling != nil {
+		if err := c.Sampling.complete(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -139,13 +163,31 @@ func (c *Cluster) Init(ctx context.Context, progress *plugins.Progress) error {
 	c.resources = cluster.NewResources()
 	// create all steps and calculate steps value
 	c.progress.CreateStep("init_env", "Preparing environment", 2)
-	c.progress.CreateStep("init_k8s_resources", "Fetching k8s resources..", 20)
+	c.progress.CreateStep("init_k8s_resources", "Fetching k8s resources..", 27)
 	c.progress.CreateStep("init_components", "Fetching all components..", len(c.Components))
+	c.progress.CreateStep("init_certificates", "Fetching certificates of components..", len(certArgs))
 	nodes, err := c.cli.CoreV1().Nodes().List(v1.ListOptions{})
 	if err != nil {
 		return errors.Wrapf(err, "get nodes from k8s failed")
 	}
-	c.progress.CreateStep("init_machines", "Fetching all machines..", len(nodes.Items))
+
+	machineNodes := make([]string, 0, len(nodes.Items))
+	var sampled map[string]bool
+	if c.Sampling != nil {
+		c.resources.MachineGroups = c.Sampling.groups(nodes.Items)
+		sampled = map[string]bool{}
+		for _, g := range c.resources.MachineGroups {
+			machineNodes = append(machineNodes, g.Sampled...)
+			for _, n := range g.Sampled {
+				sampled[n] = true
+			}
+		}
+	} else {
+		for _, n := range nodes.Items {
+			machineNodes = append(machineNodes, n.Name)
+		}
+	}
+	c.progress.CreateStep("init_machines", "Fetching all machines..", len(machineNodes))
 
 	// now start init steps
 	c.logger.Infof("Start preparing environment...........")
@@ -154,21 +196,45 @@ func (c *Cluster) Init(ctx context.Context, progress *plugins.Progress) error {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	c.logger.Infof("Start fetching all k8s resources...........")
 	c.progress.SetCurStep("init_k8s_resources")
 	if err := c.initK8sResources("init_k8s_resources"); err != nil {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	c.logger.Infof("Start fetching all components...........")
 	c.progress.SetCurStep("init_components")
 	if err := c.initComponents("init_components"); err != nil {
 		return err
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if c.nodeExecutor != nil {
+		c.logger.Infof("Start fetching certificates of components...........")
+		c.progress.SetCurStep("init_certificates")
+		if err := c.initCertificates(ctx, "init_certificates", sampled); err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
 	c.logger.Infof("Start fetching all machines...........")
 	c.progress.SetCurStep("init_machines")
-	if err := c.initMachines("init_machines"); err != nil {
+	if err := c.initMachines(ctx, "init_machines", machineNodes); err != nil {
 		return err
 	}
 
@@ -179,6 +245,13 @@ func (c *Cluster) initExecutors(stepName string) error {
 	// create a node executor according to config
 	var err error
 	if c.nodeExecutor == nil {
+		if c.Node.Type == "agent" {
+			// agent only executes the commands of registered machine collectors and the configured ones
+			if c.Node.Agent == nil {
+				c.Node.Agent = &nodeexec.AgentConfig{}
+			}
+			c.Node.Agent.ExecAllowList = append(machine.Commands(true), c.Node.Agent.ExecAllowList...)
+		}
 		c.nodeExecutor, err = c.Node.Executor(c.logger, c.cli, c.restConfig)
 		if err != nil && err != nodeexec.NoneExecutor {
 			return errors.Wrap(err, "create node executor failed")
@@ -503,6 +576,50 @@ func (c *Cluster) initK8sResources(stepName string) error {
 		return
 	})
 
+	g.Go(func() (err error) {
+		c.resources.StorageClasses, err =
+			c.cli.StorageV1().StorageClasses().List(v1.ListOptions{})
+		if err != nil {
+			err = errors.Wrapf(err, "list StorageClasses failed")
+		} else {
+			c.progress.AddStepPercent(stepName, 1)
+			c.logger.Infof("Fetching (%d) StorageClasses",
+				len(c.resources.StorageClasses.Items))
+		}
+		return
+	})
+
+	g.Go(func() (err error) {
+		c.resources.Endpoints, err =
+			client.Endpoints(v1.NamespaceAll).List(opts)
+		if err != nil {
+			err = errors.Wrapf(err, "list Endpoints failed")
+		} else {
+			c.progress.AddStepPercent(stepName, 1)
+			c.logger.Infof("Fetching (%d) Endpoints",
+				len(c.resources.Endpoints.Items))
+		}
+		return
+	})
+
+	g.Go(func() (err error) {
+		c.resources.EndpointSlices, err =
+			c.cli.DiscoveryV1beta1().EndpointSlices("").List(v1.ListOptions{})
+		if k8serrors.IsNotFound(err) {
+			c.logger.Infof("EndpointSlices is not supported by api server")
+			c.resources.EndpointSlices, err = &discoveryv1beta1.EndpointSliceList{}, nil
+		}
+
+		if err != nil {
+			err = errors.Wrapf(err, "list EndpointSlices failed")
+		} else {
+			c.progress.AddStepPercent(stepName, 1)
+			c.logger.Infof("Fetching (%d) EndpointSlices",
+				len(c.resources.EndpointSlices.Items))
+		}
+		return
+	})
+
 	g.Go(func() (err error) {
 		c.resources.PodDisruptionBudgets, err =
 			c.cli.PolicyV1beta1().PodDisruptionBudgets("").List(v1.ListOptions{})
@@ -542,24 +659,28 @@ func (c *Cluster) initComponents(stepName string) error {
 	return g.Wait()
 }
 
-// initMachines get all machines information by node executor
-func (c *Cluster) initMachines(stepName string) error {
-	nodes, err := c.cli.CoreV1().Nodes().List(v1.ListOptions{})
-	if err != nil {
-		return errors.Wrapf(err, "get nodes from k8s failed")
-	}
-
+// initMachines get machines information of target nodes by node executor
+// nodes that are not fetched yet will be skipped once ctx is done
+func (c *Cluster) initMachines(ctx context.Context, stepName string, nodes []string) error {
 	var g errgroup.Group
-	conCtl := make(chan struct{}, 200)
-	for _, n := range nodes.Items {
-		node := n
+	conCtl := make(chan struct{}, nodeexec.Concurrency(c.nodeExecutor, 200))
+	for _, n := range nodes {
+		nodeName := n
 		g.Go(func() error {
 			conCtl <- struct{}{}
 			defer func() { <-conCtl }()
 
-			m := c.getOneNodeInfo(node.Name)
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			m := c.getOneNodeInfo(nodeName)
+			if m.Error == nil {
+				machine.Collect(c.logger, c.nodeExecutor, nodeName, c.Collectors, &m)
+			}
+
 			c.resLock.Lock()
-			c.resources.Machines[node.Name] = m
+			c.resources.Machines[nodeName] = m
 			c.resLock.Unlock()
 
 			c.progress.AddStepPercent(stepName, 1)
@@ -572,6 +693,10 @@ func (c *Cluster) initMachines(stepName string) error {
 
 // getOneNodeInfo get one machine information by node executor
 func (c *Cluster) getOneNodeInfo(nodeName string) cluster.Machine {
+	if fe, ok := c.nodeExecutor.(nodeexec.FactExecutor); ok {
+		return c.getOneNodeFacts(fe, nodeName)
+	}
+
 	out, errStr, err := c.nodeExecutor.DoCmd(nodeName,
 		[]string{"sh", "-c", "sysctl -a | grep -v error"})
 	if err != nil {
@@ -602,6 +727,33 @@ func (c *Cluster) getOneNodeInfo(nodeName string) cluster.Machine {
 	}
 }
 
+// getOneNodeFacts get one machine information by typed facts of node executor
+func (c *Cluster) getOneNodeFacts(fe nodeexec.FactExecutor, nodeName string) cluster.Machine {
+	sysctlSet, err := fe.SysCtl(nodeName)
+	if err != nil {
+		c.logger.Errorf("Failed to get node %s sysctl set: %v", nodeName, err)
+		return cluster.Machine{
+			Error: errors.Wrapf(err, "get sysctl failed"),
+		}
+	}
+
+	ipt, err := fe.IPTables(nodeName)
+	if err != nil {
+		c.logger.Errorf("Failed to get node %s iptables info: %v", nodeName, err)
+		return cluster.Machine{
+			Error: errors.Wrapf(err, "get iptables failed"),
+		}
+	}
+
+	iptablesInfo := GetIPTablesInfo(ipt.Save)
+	c.logger.Debugf("Get node %s iptables result: %v",
+		nodeName, iptablesInfo)
+	return cluster.Machine{
+		SysCtl:   sysctlSet,
+		IPTables: iptablesInfo,
+	}
+}
+
 // Resources return fetched resources
 func (c *Cluster) Resources() *cluster.Resources {
 	return c.resources
@@ -656,12 +808,49 @@ func GetSysCtlMap(out string) map[string]string {
 func GetIPTablesInfo(out string) (result cluster.IPTablesInfo) {
 	lines := strings.Split(out, "\n")
 
-	var idx int
-	result.NAT, idx = getNATTableInfo(lines)
-	result.Filter = getFilterTableInfo(lines[idx+1:])
+	// tables may be in any order
+	result.NAT, _ = getNATTableInfo(lines)
+	result.Filter = getFilterTableInfo(lines)
+	result.Tables = getIPTables(lines)
 	return
 }
 
+// getIPTables parse all tables and chains from lines of "iptables-save"
+func getIPTables(lines []string) map[string]cluster.IPTablesTable {
+	tables := map[string]cluster.IPTablesTable{}
+	var cur cluster.IPTablesTable
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || line == "COMMIT":
+			continue
+		case strings.HasPrefix(line, "*"):
+			cur = cluster.IPTablesTable{Chains: map[string]cluster.IPTablesChain{}}
+			tables[line[1:]] = cur
+		case cur.Chains == nil:
+			continue
+		case strings.HasPrefix(line, ":"):
+			// :INPUT ACCEPT [0:0]
+			fields := strings.Fields(line[1:])
+			if len(fields) < 2 {
+				continue
+			}
+			chain := cur.Chains[fields[0]]
+			chain.Policy = fields[1]
+			cur.Chains[fields[0]] = chain
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.SplitN(line[3:], " ", 2)
+			chain := cur.Chains[fields[0]]
+			chain.Count++
+			if chain.Policy != "" && chain.Policy != "-" && len(fields) == 2 {
+				chain.Rules = append(chain.Rules, fields[1])
+			}
+			cur.Chains[fields[0]] = chain
+		}
+	}
+	return tables
+}
+
 func getNATTableInfo(lines []string) (nat cluster.NATTable, end int) {
 	end = -1
 	var found bool
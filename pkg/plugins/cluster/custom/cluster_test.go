@@ -19,9 +19,15 @@ package custom
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
-	"testing"
 	"tkestack.io/kube-jarvis/pkg/logger"
 	"tkestack.io/kube-jarvis/pkg/plugins"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
@@ -63,6 +69,32 @@ func (f *fakeNodeExecutor) Finish() error {
 	return nil
 }
 
+// limitedNodeExecutor record the max number of commands that are executed at the same time
+type limitedNodeExecutor struct {
+	fakeNodeExecutor
+	concurrency int
+	running     int32
+	maxRunning  int32
+}
+
+func (l *limitedNodeExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	running := atomic.AddInt32(&l.running, 1)
+	defer atomic.AddInt32(&l.running, -1)
+	for {
+		max := atomic.LoadInt32(&l.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&l.maxRunning, max, running) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond)
+	return l.fakeNodeExecutor.DoCmd(nodeName, cmd)
+}
+
+func (l *limitedNodeExecutor) Concurrency() int {
+	return l.concurrency
+}
+
 func TestGetSysCtlMap(t *testing.T) {
 	out := `
 	a = 1
@@ -83,6 +115,48 @@ func TestGetSysCtlMap(t *testing.T) {
 	}
 }
 
+func TestGetIPTablesInfo(t *testing.T) {
+	out := `# Generated by iptables-save
+*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+:KUBE-FORWARD - [0:0]
+-A FORWARD -j KUBE-FORWARD
+-A KUBE-FORWARD -m mark --mark 0x4000/0x4000 -j ACCEPT
+COMMIT
+*nat
+:PREROUTING ACCEPT [0:0]
+:KUBE-SERVICES - [0:0]
+:KUBE-SVC-A - [0:0]
+-A PREROUTING -j KUBE-SERVICES
+-A KUBE-SERVICES -d 10.96.0.1/32 -j KUBE-SVC-A
+-A KUBE-SERVICES -d 10.96.0.2/32 -j KUBE-SVC-A
+COMMIT
+`
+	info := GetIPTablesInfo(out)
+	if info.Filter.ForwardPolicy != cluster.DropPolicy {
+		t.Fatalf("want forward policy DROP but get %s", info.Filter.ForwardPolicy)
+	}
+
+	if len(info.Tables) != 2 {
+		t.Fatalf("want 2 tables but get %d", len(info.Tables))
+	}
+
+	forward, _ := info.Chain("filter", "FORWARD")
+	if forward.Policy != "DROP" || forward.Count != 1 || forward.Rules[0] != "-j KUBE-FORWARD" {
+		t.Fatalf("unexpected chain FORWARD %+v", forward)
+	}
+
+	svc, exist := info.Chain("nat", "KUBE-SERVICES")
+	if !exist || svc.Policy != "-" || svc.Count != 2 || len(svc.Rules) != 0 {
+		t.Fatalf("unexpected chain KUBE-SERVICES %+v", svc)
+	}
+
+	if _, exist := info.Chain("mangle", "INPUT"); exist {
+		t.Fatalf("table mangle should not exist")
+	}
+}
+
 func TestCluster_Resources(t *testing.T) {
 	fk := fake.NewSimpleClientset()
 	pod := &v1.Pod{}
@@ -133,6 +207,11 @@ func TestCluster_Resources(t *testing.T) {
 		t.Fatalf("want 1 Machines")
 	}
 
+	m := res.Machines["node1"]
+	if len(m.Facts)+len(m.FactErrors) != len(cls.Collectors) {
+		t.Fatalf("all collectors should be run")
+	}
+
 	if len(res.CoreComponents) != 1 {
 		t.Fatalf("want 1 CoreComponents")
 	}
@@ -141,3 +220,75 @@ func TestCluster_Resources(t *testing.T) {
 		t.Fatalf(err.Error())
 	}
 }
+
+func TestCluster_initMachinesConcurrency(t *testing.T) {
+	cls := NewCluster(logger.NewLogger(), fake.NewSimpleClientset(), nil).(*Cluster)
+	if err := cls.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	exe := &limitedNodeExecutor{fakeNodeExecutor: fakeNodeExecutor{success: true}, concurrency: 2}
+	cls.nodeExecutor = exe
+	cls.progress = plugins.NewProgress()
+
+	var nodes []string
+	for i := 0; i < 20; i++ {
+		nodes = append(nodes, fmt.Sprintf("node%d", i))
+	}
+	cls.progress.CreateStep("init_machines", "", len(nodes))
+
+	if err := cls.initMachines(context.Background(), "init_machines", nodes); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(cls.resources.Machines) != len(nodes) {
+		t.Fatalf("want %d Machines but get %d", len(nodes), len(cls.resources.Machines))
+	}
+
+	if exe.maxRunning > int32(exe.concurrency) {
+		t.Fatalf("want at most %d nodes at the same time but get %d", exe.concurrency, exe.maxRunning)
+	}
+}
+
+func TestCluster_initExecutorsAgent(t *testing.T) {
+	fk := fake.NewSimpleClientset()
+	cls := NewCluster(logger.NewLogger(), fk, nil).(*Cluster)
+	cls.Node.Type = "agent"
+	cls.Node.AutoCreate = true
+	if err := cls.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cls.Components = map[string]*compexplorer.Auto{}
+	cls.progress = plugins.NewProgress()
+	cls.progress.CreateStep("init_env", "", 2)
+	if err := cls.initExecutors("init_env"); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ds, err := fk.AppsV1().DaemonSets(cls.Node.Namespace).Get(cls.Node.DaemonSet, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cmd := strings.Join(ds.Spec.Template.Spec.Containers[0].Command, " ")
+	if !strings.Contains(cmd, `["cat","/proc/uptime"]`) {
+		t.Fatalf("commands of machine collectors should be allowed by agent, but get %s", cmd)
+	}
+}
+
+func TestCluster_CompleteCollectors(t *testing.T) {
+	cls := NewCluster(logger.NewLogger(), fake.NewSimpleClientset(), nil).(*Cluster)
+	if err := cls.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(cls.Collectors) == 0 {
+		t.Fatalf("all collectors should be used by default")
+	}
+
+	cls.Collectors = []string{"not-exist"}
+	if err := cls.Complete(); err == nil {
+		t.Fatalf("should return an error for unknown collector")
+	}
+}
@@ -54,7 +54,7 @@ func (b *Bare) Component() ([]cluster.Component, error) {
 		b.cmdName, b.cmdName)
 	result := make([]cluster.Component, 0)
 	lk := sync.Mutex{}
-	conCtl := make(chan struct{}, 200)
+	conCtl := make(chan struct{}, nodeexec.Concurrency(b.nodeExecutor, 200))
 	g := errgroup.Group{}
 
 	for _, tempN := range b.nodes {
@@ -63,45 +63,17 @@ func (b *Bare) Component() ([]cluster.Component, error) {
 			conCtl <- struct{}{}
 			defer func() { <-conCtl }()
 
-			out, _, err := b.nodeExecutor.DoCmd(n, []string{
-				"/bin/sh", "-c", cmd,
-			})
+			var cmp cluster.Component
+			var err error
+			if fe, ok := b.nodeExecutor.(nodeexec.FactExecutor); ok {
+				cmp, err = b.componentViaProcesses(fe, n)
+			} else {
+				cmp, err = b.componentViaCmd(n, cmd)
+			}
 			if err != nil {
-				if !strings.Contains(err.Error(), "terminated with exit code") {
-					b.logger.Errorf("do command on node %s failed :%v", n, err)
-				}
 				return err
 			}
 
-			cmp := cluster.Component{
-				Name: b.cmdName,
-				Node: n,
-				Args: map[string]string{},
-			}
-
-			lines := strings.Split(out, "\n")
-			for i, line := range lines {
-				line = strings.TrimSpace(line)
-				line = strings.TrimLeft(line, "-")
-				if line == "" {
-					continue
-				}
-
-				if i == 0 {
-					cmp.IsRunning = true
-					continue
-				}
-
-				spIndex := strings.IndexAny(line, "=")
-				if spIndex == -1 {
-					continue
-				}
-
-				k := line[0:spIndex]
-				v := line[spIndex+1:]
-				cmp.Args[strings.TrimSpace(k)] = strings.TrimSpace(v)
-			}
-
 			lk.Lock()
 			result = append(result, cmp)
 			lk.Unlock()
@@ -113,6 +85,89 @@ func (b *Bare) Component() ([]cluster.Component, error) {
 	return result, nil
 }
 
+func (b *Bare) componentViaCmd(n string, cmd string) (cluster.Component, error) {
+	cmp := cluster.Component{
+		Name: b.cmdName,
+		Node: n,
+		Args: map[string]string{},
+	}
+
+	out, _, err := b.nodeExecutor.DoCmd(n, []string{
+		"/bin/sh", "-c", cmd,
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "terminated with exit code") {
+			b.logger.Errorf("do command on node %s failed :%v", n, err)
+		}
+		return cmp, err
+	}
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 0 {
+		cmp.IsRunning = true
+		ParseArgs(lines[1:], cmp.Args)
+	}
+	return cmp, nil
+}
+
+func (b *Bare) componentViaProcesses(fe nodeexec.FactExecutor, n string) (cluster.Component, error) {
+	cmp := cluster.Component{
+		Name: b.cmdName,
+		Node: n,
+		Args: map[string]string{},
+	}
+
+	ps, err := fe.Processes(n, b.cmdName)
+	if err != nil {
+		b.logger.Errorf("get processes on node %s failed :%v", n, err)
+		return cmp, err
+	}
+
+	if len(ps) == 0 {
+		return cmp, fmt.Errorf("process %s not found on node %s", b.cmdName, n)
+	}
+
+	cmp.IsRunning = true
+	if len(ps[0].Cmdline) != 0 {
+		ParseArgs(ps[0].Cmdline[1:], cmp.Args)
+	}
+	return cmp, nil
+}
+
+// ParseArgs parse command line arguments like "--key=value", "--key value" and "--key" into result
+// a flag without value is treated as a boolean flag with value "true", other words are ignored
+func ParseArgs(args []string, result map[string]string) {
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimSpace(args[i])
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		arg = strings.TrimLeft(arg, "-")
+		if spIndex := strings.IndexAny(arg, "="); spIndex != -1 {
+			result[strings.TrimSpace(arg[0:spIndex])] = strings.TrimSpace(arg[spIndex+1:])
+			continue
+		}
+
+		if arg == "" {
+			continue
+		}
+
+		if i+1 < len(args) && !strings.HasPrefix(strings.TrimSpace(args[i+1]), "-") {
+			result[arg] = strings.TrimSpace(args[i+1])
+			i++
+			continue
+		}
+		result[arg] = "true"
+	}
+}
+
 // Finish will be called once every thing done
 func (b *Bare) Finish() error {
 	return nil
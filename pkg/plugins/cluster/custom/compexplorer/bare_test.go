@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"testing"
 
+	"tkestack.io/kube-jarvis/pkg/agent"
 	"tkestack.io/kube-jarvis/pkg/logger"
 )
 
@@ -43,6 +44,44 @@ func (f *fakeNodeExecutor) Finish() error {
 	return nil
 }
 
+type fakeFactExecutor struct {
+	fakeNodeExecutor
+}
+
+func (f *fakeFactExecutor) SysCtl(nodeName string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (f *fakeFactExecutor) IPTables(nodeName string) (*agent.IPTables, error) {
+	return &agent.IPTables{}, nil
+}
+
+func (f *fakeFactExecutor) Kernel(nodeName string) (*agent.Kernel, error) {
+	return &agent.Kernel{}, nil
+}
+
+func (f *fakeFactExecutor) Disks(nodeName string) ([]agent.Disk, error) {
+	return []agent.Disk{}, nil
+}
+
+func (f *fakeFactExecutor) CertFile(nodeName string, path string) (*agent.CertFile, error) {
+	return nil, fmt.Errorf("no such file")
+}
+
+func (f *fakeFactExecutor) Processes(nodeName string, name string) ([]agent.Process, error) {
+	if nodeName != "node1" {
+		return []agent.Process{}, nil
+	}
+
+	return []agent.Process{
+		{
+			PID:     1,
+			Name:    name,
+			Cmdline: []string{name, "--a=123", "-b=321", "c"},
+		},
+	}, nil
+}
+
 func TestBare_Component(t *testing.T) {
 	cases := []struct {
 		success bool
@@ -87,3 +126,47 @@ func TestBare_Component(t *testing.T) {
 		})
 	}
 }
+
+func TestBare_ComponentViaProcesses(t *testing.T) {
+	b := NewBare(logger.NewLogger(), "kube-apiserver", []string{"node1", "node2"}, &fakeFactExecutor{})
+	cmp, err := b.Component()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(cmp) != 1 {
+		t.Fatalf("want len 1 but get %d", len(cmp))
+	}
+
+	if !cmp[0].IsRunning || cmp[0].Node != "node1" {
+		t.Fatalf("want running component on node1 but get %+v", cmp[0])
+	}
+
+	if len(cmp[0].Args) != 2 || cmp[0].Args["a"] != "123" || cmp[0].Args["b"] != "321" {
+		t.Fatalf("wrong args %v", cmp[0].Args)
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	result := map[string]string{}
+	ParseArgs([]string{
+		"--a=1",
+		"-b = 2 ",
+		"--profiling",
+		"--authorization-mode", "Node,RBAC",
+		"ignored",
+		"--",
+		"--anonymous-auth",
+	}, result)
+
+	want := map[string]string{
+		"a":                  "1",
+		"b":                  "2",
+		"profiling":          "true",
+		"authorization-mode": "Node,RBAC",
+		"anonymous-auth":     "true",
+	}
+	if fmt.Sprint(result) != fmt.Sprint(want) {
+		t.Fatalf("want %v but get %v", want, result)
+	}
+}
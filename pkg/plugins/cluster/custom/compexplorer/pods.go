@@ -18,7 +18,6 @@
 package compexplorer
 
 import (
-	"strings"
 	"sync"
 
 	"golang.org/x/sync/errgroup"
@@ -95,17 +94,8 @@ func GetPodArgs(name string, pod *v1.Pod) map[string]string {
 	result := make(map[string]string)
 	for _, c := range pod.Spec.Containers {
 		if c.Name == name {
-			for _, arg := range c.Args {
-				arg = strings.TrimLeft(arg, "-")
-				spIndex := strings.IndexAny(arg, "=")
-				if spIndex == -1 {
-					continue
-				}
-
-				k := arg[0:spIndex]
-				v := arg[spIndex+1:]
-				result[strings.TrimSpace(k)] = strings.TrimSpace(v)
-			}
+			// flags may be in command, e.g. static pods created by kubeadm
+			ParseArgs(append(append([]string{}, c.Command...), c.Args...), result)
 		}
 	}
 	return result
@@ -0,0 +1,416 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package machine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/nodeexec"
+)
+
+func init() {
+	Add(cluster.FactKernel, Collector{
+		Command: []string{"sh", "-c", "uname -r; uname -v; uname -m"},
+		Parse:   parseKernel,
+		Fact:    kernelFact,
+	})
+	Add(cluster.FactUptime, Collector{
+		Command: []string{"cat", "/proc/uptime"},
+		Parse:   parseUptime,
+		Fact:    uptimeFact,
+	})
+	Add(cluster.FactDisks, Collector{
+		Command: []string{"df", "-PTk"},
+		Host:    true,
+		Parse:   parseDisks,
+		Fact:    disksFact,
+	})
+	Add(cluster.FactMemory, Collector{
+		Command: []string{"cat", "/proc/meminfo"},
+		Parse:   parseMemory,
+	})
+	Add(cluster.FactULimits, Collector{
+		Command: []string{"cat", "/proc/1/limits"},
+		Parse:   parseULimits,
+	})
+	Add(cluster.FactConntrack, Collector{
+		Command: []string{"cat", "/proc/sys/net/netfilter/nf_conntrack_count",
+			"/proc/sys/net/netfilter/nf_conntrack_max"},
+		Parse: parseConntrack,
+	})
+	Add(cluster.FactTimeSync, Collector{
+		Command: []string{"timedatectl", "status"},
+		Host:    true,
+		Parse:   parseTimeSync,
+	})
+	Add(cluster.FactCgroup, Collector{
+		Command: []string{"sh", "-c", "stat -fc %T /sys/fs/cgroup/; " +
+			"docker info 2>/dev/null | grep -i 'cgroup driver'; " +
+			"grep -s SystemdCgroup /etc/containerd/config.toml; true"},
+		Host:  true,
+		Parse: parseCgroup,
+	})
+	Add(cluster.FactIPVS, Collector{
+		Command: []string{"sh", "-c", "command -v ipvsadm >/dev/null || { echo 'ipvsadm: command not found' >&2; exit 127; }; " +
+			"ipvsadm -Ln 2>/dev/null; true"},
+		Parse: parseIPVS,
+	})
+}
+
+// unameArch convert the cpu architecture of golang to the machine hardware name of "uname -m"
+var unameArch = map[string]string{
+	"amd64": "x86_64",
+	"arm64": "aarch64",
+	"386":   "i686",
+}
+
+func kernelFact(fe nodeexec.FactExecutor, nodeName string) (interface{}, error) {
+	k, err := fe.Kernel(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	arch := k.Arch
+	if name, exist := unameArch[arch]; exist {
+		arch = name
+	}
+
+	return cluster.KernelFact{
+		Release: k.Release,
+		Version: k.Version,
+		Arch:    arch,
+	}, nil
+}
+
+func uptimeFact(fe nodeexec.FactExecutor, nodeName string) (interface{}, error) {
+	k, err := fe.Kernel(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.UptimeFact{Seconds: k.Uptime}, nil
+}
+
+// disksFact convert the disks of agent, the used bytes is total minus free like "df"
+func disksFact(fe nodeexec.FactExecutor, nodeName string) (interface{}, error) {
+	disks, err := fe.Disks(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]cluster.DiskFact, 0, len(disks))
+	for _, d := range disks {
+		result = append(result, cluster.DiskFact{
+			FileSystem: d.Device,
+			Type:       d.FSType,
+			MountPoint: d.MountPoint,
+			Total:      int64(d.Total),
+			Used:       int64(d.Total - d.Free),
+			Available:  int64(d.Available),
+		})
+	}
+	return result, nil
+}
+
+// nonEmptyLines return all trimmed lines that are not empty
+func nonEmptyLines(out string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// splitKV split a line like "key: value" or "key = value"
+func splitKV(line string, sep string) (string, string, bool) {
+	idx := strings.Index(line, sep)
+	if idx == -1 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+len(sep):]), true
+}
+
+func parseKernel(out string) (interface{}, error) {
+	lines := nonEmptyLines(out)
+	if len(lines) != 3 {
+		return nil, fmt.Errorf("want 3 lines but get %d", len(lines))
+	}
+
+	return cluster.KernelFact{
+		Release: lines[0],
+		Version: lines[1],
+		Arch:    lines[2],
+	}, nil
+}
+
+func parseUptime(out string) (interface{}, error) {
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty output")
+	}
+
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.UptimeFact{Seconds: seconds}, nil
+}
+
+// parseDisks parse the output of "df -PTk", only block devices are returned
+func parseDisks(out string) (interface{}, error) {
+	result := make([]cluster.DiskFact, 0)
+	for i, line := range nonEmptyLines(out) {
+		// the first line is the header
+		if i == 0 {
+			continue
+		}
+
+		// Filesystem Type 1024-blocks Used Available Capacity Mounted on
+		fields := strings.Fields(line)
+		if len(fields) < 7 || !strings.HasPrefix(fields[0], "/dev/") {
+			continue
+		}
+
+		var values [3]int64
+		for j := range values {
+			v, err := strconv.ParseInt(fields[2+j], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse line '%s' failed", line)
+			}
+			values[j] = v * 1024
+		}
+
+		result = append(result, cluster.DiskFact{
+			FileSystem: fields[0],
+			Type:       fields[1],
+			MountPoint: strings.Join(fields[6:], " "),
+			Total:      values[0],
+			Used:       values[1],
+			Available:  values[2],
+		})
+	}
+	return result, nil
+}
+
+func parseMemory(out string) (interface{}, error) {
+	values := map[string]int64{}
+	for _, line := range nonEmptyLines(out) {
+		// MemTotal:       16267428 kB
+		k, v, ok := splitKV(line, ":")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			continue
+		}
+
+		n, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if len(fields) > 1 && fields[1] == "kB" {
+			n *= 1024
+		}
+		values[k] = n
+	}
+
+	if _, exist := values["MemTotal"]; !exist {
+		return nil, fmt.Errorf("MemTotal not found")
+	}
+
+	return cluster.MemoryFact{
+		Total:     values["MemTotal"],
+		Available: values["MemAvailable"],
+		SwapTotal: values["SwapTotal"],
+		SwapFree:  values["SwapFree"],
+	}, nil
+}
+
+// parseULimits parse the content of /proc/[pid]/limits, columns are aligned with the header
+func parseULimits(out string) (interface{}, error) {
+	lines := strings.Split(out, "\n")
+	header := lines[0]
+	softIdx := strings.Index(header, "Soft Limit")
+	hardIdx := strings.Index(header, "Hard Limit")
+	unitIdx := strings.Index(header, "Units")
+	if softIdx == -1 || hardIdx < softIdx || unitIdx < hardIdx {
+		return nil, fmt.Errorf("unknown header '%s'", header)
+	}
+
+	column := func(line string, start, end int) string {
+		if start >= len(line) {
+			return ""
+		}
+		if end > len(line) || end < 0 {
+			end = len(line)
+		}
+		return strings.TrimSpace(line[start:end])
+	}
+
+	result := cluster.ULimitsFact{}
+	for _, line := range lines[1:] {
+		name := column(line, 0, softIdx)
+		if name == "" {
+			continue
+		}
+
+		result[name] = cluster.ULimit{
+			Soft: column(line, softIdx, hardIdx),
+			Hard: column(line, hardIdx, unitIdx),
+			Unit: column(line, unitIdx, -1),
+		}
+	}
+	return result, nil
+}
+
+func parseConntrack(out string) (interface{}, error) {
+	lines := nonEmptyLines(out)
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("want 2 lines but get %d", len(lines))
+	}
+
+	count, err := strconv.ParseInt(lines[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	max, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.ConntrackFact{Count: count, Max: max}, nil
+}
+
+// parseTimeSync parse the output of "timedatectl status" of different systemd versions
+func parseTimeSync(out string) (interface{}, error) {
+	result := cluster.TimeSyncFact{}
+	found := false
+	for _, line := range nonEmptyLines(out) {
+		k, v, ok := splitKV(line, ":")
+		if !ok {
+			continue
+		}
+
+		yes := v == "yes" || v == "active"
+		switch strings.ToLower(k) {
+		case "system clock synchronized", "ntp synchronized":
+			result.Synchronized = yes
+			found = true
+		case "ntp service", "network time on", "ntp enabled", "systemd-timesyncd.service active":
+			result.NTPService = result.NTPService || yes
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("synchronized status not found")
+	}
+	return result, nil
+}
+
+// parseCgroup parse the cgroup file system type and the cgroup driver of docker or containerd
+func parseCgroup(out string) (interface{}, error) {
+	lines := nonEmptyLines(out)
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty output")
+	}
+
+	result := cluster.CgroupFact{Version: 1}
+	if lines[0] == "cgroup2fs" {
+		result.Version = 2
+	}
+
+	for _, line := range lines[1:] {
+		if k, v, ok := splitKV(line, ":"); ok && strings.EqualFold(k, "cgroup driver") {
+			result.Runtime = "docker"
+			result.Driver = v
+			break
+		}
+
+		if k, v, ok := splitKV(line, "="); ok && k == "SystemdCgroup" {
+			result.Runtime = "containerd"
+			result.Driver = "cgroupfs"
+			if v == "true" {
+				result.Driver = "systemd"
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseIPVS parse the output of "ipvsadm -Ln", an empty output means IPVS module is not loaded
+// the command fails if ipvsadm is not installed, so that it is recorded as a fact error
+func parseIPVS(out string) (interface{}, error) {
+	result := cluster.IPVSFact{Services: make([]cluster.IPVSService, 0)}
+	lines := nonEmptyLines(out)
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "IP Virtual Server") {
+		return result, nil
+	}
+
+	result.Available = true
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		switch {
+		case strings.HasPrefix(line, "Prot ") || strings.HasPrefix(line, "-> RemoteAddress"):
+			continue
+		case fields[0] == "->":
+			// -> 10.0.0.2:6443     Masq    1      0          0
+			if len(fields) != 6 || len(result.Services) == 0 {
+				return nil, fmt.Errorf("unknown real server line: %s", line)
+			}
+
+			nums := make([]int, 3)
+			for i := range nums {
+				n, err := strconv.Atoi(fields[3+i])
+				if err != nil {
+					return nil, errors.Wrapf(err, "parse real server line %s failed", line)
+				}
+				nums[i] = n
+			}
+
+			svc := &result.Services[len(result.Services)-1]
+			svc.RealServers = append(svc.RealServers, cluster.IPVSRealServer{
+				Address:    fields[1],
+				Forward:    fields[2],
+				Weight:     nums[0],
+				ActiveConn: nums[1],
+				InActConn:  nums[2],
+			})
+		default:
+			// TCP  10.96.0.1:443 rr persistent 10800
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("unknown virtual server line: %s", line)
+			}
+			result.Services = append(result.Services, cluster.IPVSService{
+				Protocol:    fields[0],
+				Address:     fields[1],
+				Scheduler:   fields[2],
+				RealServers: make([]cluster.IPVSRealServer, 0),
+			})
+		}
+	}
+	return result, nil
+}
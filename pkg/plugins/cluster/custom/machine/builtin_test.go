@@ -0,0 +1,205 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package machine
+
+import (
+	"reflect"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+)
+
+func TestBuiltinCollectors(t *testing.T) {
+	var cases = []struct {
+		name   string
+		out    string
+		want   interface{}
+		hasErr bool
+	}{
+		{
+			name: cluster.FactKernel,
+			out:  "4.14.105\n#1 SMP Thu Jan 9 15:27:10 CST 2020\nx86_64\n",
+			want: cluster.KernelFact{
+				Release: "4.14.105",
+				Version: "#1 SMP Thu Jan 9 15:27:10 CST 2020",
+				Arch:    "x86_64",
+			},
+		},
+		{
+			name:   cluster.FactKernel,
+			out:    "4.14.105\n",
+			hasErr: true,
+		},
+		{
+			name: cluster.FactUptime,
+			out:  "350.27 1000.00\n",
+			want: cluster.UptimeFact{Seconds: 350.27},
+		},
+		{
+			name: cluster.FactDisks,
+			out: `Filesystem     Type     1024-blocks      Used Available Capacity Mounted on
+/dev/vda1      ext4        51473868  20314100  28847228      42% /
+tmpfs          tmpfs        8133712         0   8133712       0% /dev/shm
+/dev/vdb       xfs         10475520     32992  10442528       1% /data dir
+`,
+			want: []cluster.DiskFact{
+				{
+					FileSystem: "/dev/vda1",
+					Type:       "ext4",
+					MountPoint: "/",
+					Total:      51473868 * 1024,
+					Used:       20314100 * 1024,
+					Available:  28847228 * 1024,
+				},
+				{
+					FileSystem: "/dev/vdb",
+					Type:       "xfs",
+					MountPoint: "/data dir",
+					Total:      10475520 * 1024,
+					Used:       32992 * 1024,
+					Available:  10442528 * 1024,
+				},
+			},
+		},
+		{
+			name: cluster.FactMemory,
+			out: `MemTotal:       16267428 kB
+MemFree:          451260 kB
+MemAvailable:   10185712 kB
+SwapTotal:             0 kB
+SwapFree:              0 kB
+HugePages_Total:       0
+`,
+			want: cluster.MemoryFact{
+				Total:     16267428 * 1024,
+				Available: 10185712 * 1024,
+			},
+		},
+		{
+			name:   cluster.FactMemory,
+			out:    "",
+			hasErr: true,
+		},
+		{
+			name: cluster.FactULimits,
+			out: `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max open files            1048576              1048576              files     
+Max processes             63405                63405                processes 
+`,
+			want: cluster.ULimitsFact{
+				"Max cpu time":   {Soft: "unlimited", Hard: "unlimited", Unit: "seconds"},
+				"Max open files": {Soft: "1048576", Hard: "1048576", Unit: "files"},
+				"Max processes":  {Soft: "63405", Hard: "63405", Unit: "processes"},
+			},
+		},
+		{
+			name: cluster.FactConntrack,
+			out:  "1024\n262144\n",
+			want: cluster.ConntrackFact{Count: 1024, Max: 262144},
+		},
+		{
+			name:   cluster.FactConntrack,
+			out:    "1024\n",
+			hasErr: true,
+		},
+		{
+			name: cluster.FactTimeSync,
+			out: `               Local time: Mon 2020-03-02 11:22:33 CST
+           Universal time: Mon 2020-03-02 03:22:33 UTC
+                Time zone: Asia/Shanghai (CST, +0800)
+System clock synchronized: yes
+              NTP service: active
+          RTC in local TZ: no
+`,
+			want: cluster.TimeSyncFact{Synchronized: true, NTPService: true},
+		},
+		{
+			name: cluster.FactTimeSync,
+			out: `      Local time: Mon 2020-03-02 11:22:33 CST
+  Network time on: no
+NTP synchronized: no
+`,
+			want: cluster.TimeSyncFact{},
+		},
+		{
+			name:   cluster.FactTimeSync,
+			out:    "",
+			hasErr: true,
+		},
+		{
+			name: cluster.FactCgroup,
+			out:  "tmpfs\n Cgroup Driver: cgroupfs\n",
+			want: cluster.CgroupFact{Version: 1, Runtime: "docker", Driver: "cgroupfs"},
+		},
+		{
+			name: cluster.FactCgroup,
+			out:  "cgroup2fs\n            SystemdCgroup = true\n",
+			want: cluster.CgroupFact{Version: 2, Runtime: "containerd", Driver: "systemd"},
+		},
+		{
+			name: cluster.FactIPVS,
+			out:  "",
+			want: cluster.IPVSFact{Services: []cluster.IPVSService{}},
+		},
+		{
+			name: cluster.FactIPVS,
+			out: `IP Virtual Server version 1.2.1 (size=4096)
+Prot LocalAddress:Port Scheduler Flags
+  -> RemoteAddress:Port           Forward Weight ActiveConn InActConn
+TCP  10.96.0.1:443 rr
+  -> 192.168.0.10:6443            Masq    1      3          0
+UDP  10.96.0.10:53 rr
+`,
+			want: cluster.IPVSFact{Available: true, Services: []cluster.IPVSService{
+				{
+					Protocol:  "TCP",
+					Address:   "10.96.0.1:443",
+					Scheduler: "rr",
+					RealServers: []cluster.IPVSRealServer{
+						{Address: "192.168.0.10:6443", Forward: "Masq", Weight: 1, ActiveConn: 3},
+					},
+				},
+				{
+					Protocol:    "UDP",
+					Address:     "10.96.0.10:53",
+					Scheduler:   "rr",
+					RealServers: []cluster.IPVSRealServer{},
+				},
+			}},
+		},
+		{
+			name:   cluster.FactIPVS,
+			out:    "IP Virtual Server version 1.2.1 (size=4096)\n  -> 192.168.0.10:6443 Masq 1 0 0\n",
+			hasErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			fact, err := Collectors[cs.name].Parse(cs.out)
+			if (err != nil) != cs.hasErr {
+				t.Fatalf("want hasErr %v but get %v", cs.hasErr, err)
+			}
+
+			if !cs.hasErr && !reflect.DeepEqual(fact, cs.want) {
+				t.Fatalf("want %+v but get %+v", cs.want, fact)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package machine
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/nodeexec"
+)
+
+// Collector collect one kind of fact of a machine by executing a command on node
+type Collector struct {
+	// Command is the command that will be executed on node
+	Command []string
+	// Host is true if Command reads host files or uses host tools
+	// it will be executed in the mount namespace of host if node executor executes commands in container
+	Host bool
+	// Parse convert the stdout of Command to a typed fact
+	Parse func(out string) (interface{}, error)
+	// Fact return the typed fact directly if node executor is a FactExecutor, Command is used if it is nil
+	Fact func(fe nodeexec.FactExecutor, nodeName string) (interface{}, error)
+}
+
+// command return the command that will be executed by exe
+func (c *Collector) command(exe nodeexec.Executor) []string {
+	if c.Host {
+		return nodeexec.HostCmd(exe, c.Command)
+	}
+	return c.Command
+}
+
+// Collectors store all registered Collector, the key is the fact name
+var Collectors = map[string]Collector{}
+
+// Add register a Collector
+func Add(name string, c Collector) {
+	Collectors[name] = c
+}
+
+// Names return the names of all registered Collector in order
+func Names() []string {
+	names := make([]string, 0, len(Collectors))
+	for name := range Collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Commands return the commands of all registered Collector in order of names
+// commands of host are executed by "nsenter" if inContainer is true
+func Commands(inContainer bool) [][]string {
+	result := make([][]string, 0, len(Collectors))
+	for _, name := range Names() {
+		c := Collectors[name]
+		if c.Host && inContainer {
+			result = append(result, nodeexec.NSEnterCmd(c.Command))
+			continue
+		}
+		result = append(result, c.Command)
+	}
+	return result
+}
+
+// Collect run target collectors on node and store facts into Machine
+// a failed collector will not stop others, its error is recorded in Machine.FactErrors
+func Collect(logger logger.Logger, exe nodeexec.Executor,
+	nodeName string, names []string, m *cluster.Machine) {
+	if m.Facts == nil {
+		m.Facts = map[string]interface{}{}
+	}
+
+	for _, name := range names {
+		fact, err := collectOne(exe, nodeName, name)
+		if err != nil {
+			logger.Errorf("collect fact %s of node %s failed: %v", name, nodeName, err)
+			if m.FactErrors == nil {
+				m.FactErrors = map[string]string{}
+			}
+			m.FactErrors[name] = err.Error()
+			continue
+		}
+		m.Facts[name] = fact
+	}
+}
+
+func collectOne(exe nodeexec.Executor, nodeName string, name string) (interface{}, error) {
+	c, exist := Collectors[name]
+	if !exist {
+		return nil, errors.Errorf("unknown collector %s", name)
+	}
+
+	if fe, ok := exe.(nodeexec.FactExecutor); ok && c.Fact != nil {
+		fact, err := c.Fact(fe, nodeName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get fact failed")
+		}
+		return fact, nil
+	}
+
+	out, errStr, err := exe.DoCmd(nodeName, c.command(exe))
+	if err != nil {
+		return nil, errors.Wrapf(err, "do command failed: %s", errStr)
+	}
+
+	fact, err := c.Parse(out)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse output failed")
+	}
+	return fact, nil
+}
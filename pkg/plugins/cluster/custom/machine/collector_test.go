@@ -0,0 +1,206 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package machine
+
+import (
+	"fmt"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/agent"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster/custom/nodeexec"
+)
+
+type fakeExecutor struct {
+	outs map[string]string
+}
+
+func (f *fakeExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	out, exist := f.outs[cmd[len(cmd)-1]]
+	if !exist {
+		return "", "not found", fmt.Errorf("command terminated with exit code 1")
+	}
+	return out, "", nil
+}
+
+func (f *fakeExecutor) Finish() error {
+	return nil
+}
+
+func TestCollect(t *testing.T) {
+	exe := &fakeExecutor{outs: map[string]string{
+		"/proc/uptime": "100.5 200.1",
+		"/proc/sys/net/netfilter/nf_conntrack_max": "bad",
+	}}
+
+	m := &cluster.Machine{}
+	Collect(logger.NewLogger(), exe, "node1",
+		[]string{cluster.FactUptime, cluster.FactConntrack, cluster.FactMemory, "not-exist"}, m)
+
+	uptime := cluster.UptimeFact{}
+	if ok, err := m.Fact(cluster.FactUptime, &uptime); !ok || err != nil {
+		t.Fatalf("uptime fact should be collected: %v", err)
+	}
+
+	if uptime.Seconds != 100.5 {
+		t.Fatalf("want uptime 100.5 but get %v", uptime.Seconds)
+	}
+
+	for _, name := range []string{cluster.FactConntrack, cluster.FactMemory, "not-exist"} {
+		if m.FactErrors[name] == "" {
+			t.Fatalf("want error of %s", name)
+		}
+
+		if ok, _ := m.Fact(name, &struct{}{}); ok {
+			t.Fatalf("fact %s should not be collected", name)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	if len(names) != 9 {
+		t.Fatalf("want 9 built-in collectors but get %d", len(names))
+	}
+
+	for i := 1; i < len(names); i++ {
+		if names[i-1] > names[i] {
+			t.Fatalf("names should be sorted")
+		}
+	}
+}
+
+func TestAgentAllowed(t *testing.T) {
+	c := agent.NewCollector()
+	for name, collector := range Collectors {
+		if !c.ExecAllowed(collector.command(&nodeexec.AgentExecutor{})) {
+			t.Fatalf("command of collector %s should be allowed by agent", name)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	Add("test-custom", Collector{Command: []string{"cat", "/etc/custom"}, Host: true})
+	defer delete(Collectors, "test-custom")
+
+	c := &agent.Collector{ExecAllowList: Commands(true)}
+	for name, collector := range Collectors {
+		if !c.ExecAllowed(collector.command(&nodeexec.AgentExecutor{})) {
+			t.Fatalf("command of collector %s should be in Commands", name)
+		}
+	}
+
+	if !c.ExecAllowed([]string{"nsenter", "-t", "1", "-m", "--", "cat", "/etc/custom"}) {
+		t.Fatalf("command of host should be executed by nsenter in container")
+	}
+
+	if len(Commands(false)) != len(Collectors) {
+		t.Fatalf("want %d commands but get %d", len(Collectors), len(Commands(false)))
+	}
+}
+
+type fakeContainerExecutor struct {
+	fakeExecutor
+	cmds [][]string
+}
+
+func (f *fakeContainerExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	f.cmds = append(f.cmds, cmd)
+	return f.fakeExecutor.DoCmd(nodeName, cmd)
+}
+
+func (f *fakeContainerExecutor) InContainer() bool {
+	return true
+}
+
+type fakeFactExecutor struct {
+	fakeContainerExecutor
+}
+
+func (f *fakeFactExecutor) SysCtl(nodeName string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (f *fakeFactExecutor) IPTables(nodeName string) (*agent.IPTables, error) {
+	return &agent.IPTables{}, nil
+}
+
+func (f *fakeFactExecutor) Processes(nodeName string, name string) ([]agent.Process, error) {
+	return []agent.Process{}, nil
+}
+
+func (f *fakeFactExecutor) Kernel(nodeName string) (*agent.Kernel, error) {
+	return &agent.Kernel{Release: "4.14.105", Version: "#1 SMP", Arch: "amd64", Uptime: 100.5}, nil
+}
+
+func (f *fakeFactExecutor) Disks(nodeName string) ([]agent.Disk, error) {
+	return []agent.Disk{
+		{Device: "/dev/vda1", MountPoint: "/", FSType: "ext4", Total: 100, Free: 40, Available: 30},
+	}, nil
+}
+
+func (f *fakeFactExecutor) CertFile(nodeName string, path string) (*agent.CertFile, error) {
+	return nil, fmt.Errorf("no such file")
+}
+
+func TestCollect_DefaultExecutor(t *testing.T) {
+	exe := &fakeContainerExecutor{fakeExecutor: fakeExecutor{outs: map[string]string{
+		"status": "NTP synchronized: yes",
+	}}}
+
+	m := &cluster.Machine{}
+	Collect(logger.NewLogger(), exe, "node1", []string{cluster.FactTimeSync, cluster.FactMemory}, m)
+
+	want := [][]string{
+		{"nsenter", "-t", "1", "-m", "--", "timedatectl", "status"},
+		{"cat", "/proc/meminfo"},
+	}
+	if fmt.Sprint(exe.cmds) != fmt.Sprint(want) {
+		t.Fatalf("want commands %v but get %v", want, exe.cmds)
+	}
+
+	if _, exist := m.Facts[cluster.FactTimeSync]; !exist {
+		t.Fatalf("time sync fact should be collected: %v", m.FactErrors)
+	}
+}
+
+func TestCollect_FactExecutor(t *testing.T) {
+	exe := &fakeFactExecutor{}
+	m := &cluster.Machine{}
+	Collect(logger.NewLogger(), exe, "node1", []string{cluster.FactKernel, cluster.FactUptime, cluster.FactDisks}, m)
+
+	if len(exe.cmds) != 0 {
+		t.Fatalf("typed facts should be used but get commands %v", exe.cmds)
+	}
+
+	kernel := cluster.KernelFact{}
+	if ok, err := m.Fact(cluster.FactKernel, &kernel); !ok || err != nil || kernel.Arch != "x86_64" {
+		t.Fatalf("wrong kernel fact %+v: %v", kernel, err)
+	}
+
+	uptime := cluster.UptimeFact{}
+	if ok, err := m.Fact(cluster.FactUptime, &uptime); !ok || err != nil || uptime.Seconds != 100.5 {
+		t.Fatalf("wrong uptime fact %+v: %v", uptime, err)
+	}
+
+	disks := make([]cluster.DiskFact, 0)
+	if ok, err := m.Fact(cluster.FactDisks, &disks); !ok || err != nil || len(disks) != 1 || disks[0].Used != 60 {
+		t.Fatalf("wrong disks fact %+v: %v", disks, err)
+	}
+}
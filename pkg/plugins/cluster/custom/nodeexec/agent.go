@@ -0,0 +1,428 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	v12 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"tkestack.io/kube-jarvis/pkg/agent"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+// AgentConfig is the config of node executor "agent"
+type AgentConfig struct {
+	// Port is the listening port of agent, default is 9876
+	Port int
+	// ExecAllowList is the extra commands that agent can execute besides the commands of registered machine collectors
+	// it is written into the agent DaemonSet created by AgentExecutor
+	ExecAllowList [][]string
+	// FileAllowDirs is the directories of node that agent can read certificate files from,
+	// default is agent.DefaultFileAllowDirs, it is written into the agent DaemonSet created by AgentExecutor
+	FileAllowDirs []string
+}
+
+// podProxyDoer send requests to agent via the pod proxy of api server
+type podProxyDoer struct {
+	cli       kubernetes.Interface
+	namespace string
+	pod       string
+	port      int
+	token     string
+}
+
+// Do send a request to agent and return the response body
+func (p *podProxyDoer) Do(method string, path string, query url.Values, body []byte) ([]byte, error) {
+	req := p.cli.CoreV1().RESTClient().Verb(method).
+		Namespace(p.namespace).
+		Resource("pods").
+		Name(fmt.Sprintf("%s:%d", p.pod, p.port)).
+		SubResource("proxy").
+		Suffix(path).
+		SetHeader(agent.TokenHeader, p.token)
+
+	for k, vs := range query {
+		for _, v := range vs {
+			req = req.Param(k, v)
+		}
+	}
+
+	if body != nil {
+		req = req.Body(body)
+	}
+	return req.DoRaw()
+}
+
+// AgentExecutor query the kube-jarvis-agent DaemonSet via the pod proxy of api server
+// agent return typed facts, so AgentExecutor implements FactExecutor
+type AgentExecutor struct {
+	logger     logger.Logger
+	cli        kubernetes.Interface
+	namespace  string
+	dsName     string
+	secretName string
+	image      string
+	port       int
+	autoCreate bool
+	podConfig  *PodConfig
+	agentConf  *AgentConfig
+	token      string
+	newDoer    func(pod string) agent.Doer
+
+	lock sync.Mutex
+	pods map[string]string
+}
+
+// NewAgentExecutor create and init a new AgentExecutor
+// the token of agent is stored in Secret "[ds]-token"
+func NewAgentExecutor(logger logger.Logger, cli kubernetes.Interface, namespace string,
+	ds string, image string, autoCreate bool, conf *AgentConfig, podConfig *PodConfig) (*AgentExecutor, error) {
+	if conf == nil {
+		conf = &AgentConfig{}
+	}
+
+	if conf.Port == 0 {
+		conf.Port = agent.DefaultPort
+	}
+
+	a := &AgentExecutor{
+		logger:     logger,
+		cli:        cli,
+		namespace:  namespace,
+		dsName:     ds,
+		secretName: ds + "-token",
+		image:      image,
+		port:       conf.Port,
+		autoCreate: autoCreate,
+		podConfig:  podConfig,
+		agentConf:  conf,
+		pods:       map[string]string{},
+	}
+
+	a.newDoer = func(pod string) agent.Doer {
+		return &podProxyDoer{
+			cli:       a.cli,
+			namespace: a.namespace,
+			pod:       pod,
+			port:      a.port,
+			token:     a.token,
+		}
+	}
+
+	if a.autoCreate {
+		return a, a.tryCreateAgent()
+	}
+
+	secret, err := a.cli.CoreV1().Secrets(a.namespace).Get(a.secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get agent token %s/%s failed", a.namespace, a.secretName)
+	}
+	a.token = string(secret.Data["token"])
+	return a, nil
+}
+
+func (a *AgentExecutor) tryCreateAgent() error {
+	ns := &v1.Namespace{}
+	ns.Name = a.namespace
+	if _, err := a.cli.CoreV1().Namespaces().Create(ns); err != nil {
+		if !k8serr.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create namespace %s failed", a.namespace)
+		}
+	}
+
+	// create token secret or use the existing one
+	if err := a.createToken(); err != nil {
+		return err
+	}
+
+	dsYaml := fmt.Sprintf(agentYaml, a.dsName, a.namespace, a.image, a.port, a.secretName)
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	obj, _, err := decode([]byte(dsYaml), nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "decode agent yaml failed")
+	}
+
+	ds, ok := obj.(*v12.DaemonSet)
+	if !ok {
+		return fmt.Errorf("covert to app/v1 DaemonSet failed")
+	}
+
+	if err := a.applyAllowList(&ds.Spec.Template.Spec.Containers[0]); err != nil {
+		return err
+	}
+
+	if err := a.podConfig.apply(&ds.Spec.Template.Spec); err != nil {
+		return errors.Wrapf(err, "apply pod config failed")
+	}
+
+	if _, err := a.cli.AppsV1().DaemonSets(a.namespace).Create(ds); err != nil {
+		if !k8serr.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "create DaemonSet %s failed", a.dsName)
+		}
+	}
+	return nil
+}
+
+// applyAllowList pass the allowed commands and directories to agent by command line flags
+// "$" is escaped so that they are not expanded as environment variables by kubelet
+func (a *AgentExecutor) applyAllowList(c *v1.Container) error {
+	if len(a.agentConf.ExecAllowList) != 0 {
+		data, err := json.Marshal(a.agentConf.ExecAllowList)
+		if err != nil {
+			return errors.Wrapf(err, "marshal exec allow list failed")
+		}
+		c.Command = append(c.Command, "-exec-allow-list="+strings.Replace(string(data), "$", "$$", -1))
+	}
+
+	if len(a.agentConf.FileAllowDirs) != 0 {
+		c.Command = append(c.Command, "-file-allow-dirs="+
+			strings.Replace(strings.Join(a.agentConf.FileAllowDirs, ","), "$", "$$", -1))
+	}
+	return nil
+}
+
+func (a *AgentExecutor) createToken() error {
+	secret, err := a.cli.CoreV1().Secrets(a.namespace).Get(a.secretName, metav1.GetOptions{})
+	if err == nil {
+		a.token = string(secret.Data["token"])
+		return nil
+	}
+
+	if !k8serr.IsNotFound(err) {
+		return errors.Wrapf(err, "get agent token %s failed", a.secretName)
+	}
+
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return errors.Wrapf(err, "generate token failed")
+	}
+	a.token = hex.EncodeToString(data)
+
+	secret = &v1.Secret{}
+	secret.Name = a.secretName
+	secret.Namespace = a.namespace
+	secret.Labels = map[string]string{
+		OwnerLabel: OwnerValue,
+	}
+	secret.Data = map[string][]byte{
+		"token": []byte(a.token),
+	}
+	if _, err := a.cli.CoreV1().Secrets(a.namespace).Create(secret); err != nil {
+		return errors.Wrapf(err, "create agent token %s failed", a.secretName)
+	}
+	return nil
+}
+
+// Agent return the agent client of target node
+// it will wait until the agent pod on node is ready
+func (a *AgentExecutor) Agent(nodeName string) (*agent.Client, error) {
+	a.lock.Lock()
+	pod, exist := a.pods[nodeName]
+	a.lock.Unlock()
+	if exist {
+		return agent.NewClient(a.newDoer(pod)), nil
+	}
+
+	err := util.RetryUntilTimeout(time.Second*10, time.Minute, func() error {
+		pods, err := a.cli.CoreV1().Pods(a.namespace).List(metav1.ListOptions{
+			FieldSelector: "spec.nodeName=" + nodeName,
+			LabelSelector: labels.SelectorFromSet(map[string]string{
+				"k8s-app": "kube-jarvis-node-agent",
+			}).String(),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "get agent pod failed")
+		}
+
+		for _, p := range pods.Items {
+			if terminating(&p) {
+				continue
+			}
+
+			for _, c := range p.Status.Conditions {
+				if c.Type == v1.PodReady && c.Status == v1.ConditionTrue {
+					pod = p.Name
+					return nil
+				}
+			}
+		}
+
+		a.logger.Infof("ready agent pod on node %s not found, it may be scheduled later", nodeName)
+		return util.RetryAbleErr
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "get agent pod on node %s failed", nodeName)
+	}
+
+	a.lock.Lock()
+	a.pods[nodeName] = pod
+	a.lock.Unlock()
+	return agent.NewClient(a.newDoer(pod)), nil
+}
+
+// forget remove the cached agent pod of node, so that it will be re-fetched next time
+func (a *AgentExecutor) forget(nodeName string) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	delete(a.pods, nodeName)
+}
+
+// DoCmd executes command on target node
+func (a *AgentExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := cli.Exec(&agent.ExecRequest{Command: cmd})
+	if err != nil {
+		a.forget(nodeName)
+		return "", "", err
+	}
+
+	if resp.Error != "" {
+		return resp.Stdout, resp.Stderr, fmt.Errorf(resp.Error)
+	}
+
+	if resp.ExitCode != 0 {
+		return resp.Stdout, resp.Stderr, fmt.Errorf("command terminated with exit code %d", resp.ExitCode)
+	}
+	return resp.Stdout, resp.Stderr, nil
+}
+
+// SysCtl return all kernel parameters of node
+func (a *AgentExecutor) SysCtl(nodeName string) (map[string]string, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.SysCtl()
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// IPTables return iptables rules of node
+func (a *AgentExecutor) IPTables(nodeName string) (*agent.IPTables, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.IPTables()
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// Processes return processes with target name on node
+func (a *AgentExecutor) Processes(nodeName string, name string) ([]agent.Process, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.Processes(name)
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// Kernel return the kernel and os information of node
+func (a *AgentExecutor) Kernel(nodeName string) (*agent.Kernel, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.Kernel()
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// Disks return the usage of all mounted block devices of node
+func (a *AgentExecutor) Disks(nodeName string) ([]agent.Disk, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.Disks()
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// CertFile return the certificates in a PEM file or kubeconfig file of node
+func (a *AgentExecutor) CertFile(nodeName string, path string) (*agent.CertFile, error) {
+	cli, err := a.Agent(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cli.CertFile(path)
+	if err != nil {
+		a.forget(nodeName)
+	}
+	return result, err
+}
+
+// InContainer return true since agent executes commands in its container
+func (a *AgentExecutor) InContainer() bool {
+	return true
+}
+
+// Finish delete agent DaemonSet and token if they are created by AgentExecutor
+func (a *AgentExecutor) Finish() error {
+	if !a.autoCreate {
+		return nil
+	}
+
+	if err := a.cli.AppsV1().DaemonSets(a.namespace).
+		Delete(a.dsName, &metav1.DeleteOptions{}); err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+
+	if err := a.cli.CoreV1().Secrets(a.namespace).
+		Delete(a.secretName, &metav1.DeleteOptions{}); err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
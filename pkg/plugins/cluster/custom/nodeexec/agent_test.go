@@ -0,0 +1,217 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"tkestack.io/kube-jarvis/pkg/agent"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+// newFakeAgent start an agent server with a fake proc dir
+func newFakeAgent(t *testing.T, token string) *httptest.Server {
+	root, err := ioutil.TempDir("", "nodeexec")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(root) })
+
+	files := map[string]string{
+		"proc/sys/net/ipv4/ip_forward": "1",
+		"proc/100/comm":                "kubelet",
+		"proc/100/cmdline":             "/usr/bin/kubelet\x00--v=2\x00",
+		"iptables-save":                "#!/bin/sh\nprintf '*nat\\n:PREROUTING ACCEPT [0:0]\\nCOMMIT\\n'",
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	c := &agent.Collector{
+		ProcDir:      filepath.Join(root, "proc"),
+		HostRoot:     root,
+		IPTablesSave: filepath.Join(root, "iptables-save"),
+		ExecAllowList: [][]string{
+			{"/bin/sh", "-c", "echo -n 123"},
+			{"/bin/sh", "-c", "exit 1"},
+		},
+	}
+	s := httptest.NewServer(agent.NewServer(logger.NewLogger(), c, token))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestAgentExecutor(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	a, err := NewAgentExecutor(logger.NewLogger(), cli, "kube-jarvis",
+		"kube-jarvis-node-agent", "test", true, nil, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if a.port != agent.DefaultPort {
+		t.Fatalf("want default port %d but get %d", agent.DefaultPort, a.port)
+	}
+
+	if _, err := cli.AppsV1().DaemonSets("kube-jarvis").
+		Get("kube-jarvis-node-agent", metav1.GetOptions{}); err != nil {
+		t.Fatalf("agent DaemonSet should be created: %v", err)
+	}
+
+	secret, err := cli.CoreV1().Secrets("kube-jarvis").
+		Get("kube-jarvis-node-agent-token", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("agent token should be created: %v", err)
+	}
+
+	if string(secret.Data["token"]) != a.token || len(a.token) != 64 {
+		t.Fatalf("wrong token %s", a.token)
+	}
+
+	pod := &v1.Pod{}
+	pod.Name = "agent-1"
+	pod.Namespace = "kube-jarvis"
+	pod.Labels = map[string]string{"k8s-app": "kube-jarvis-node-agent"}
+	pod.Spec.NodeName = "node1"
+	pod.Status.Conditions = []v1.PodCondition{
+		{Type: v1.PodReady, Status: v1.ConditionTrue},
+	}
+	if _, err := cli.CoreV1().Pods("kube-jarvis").Create(pod); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s := newFakeAgent(t, a.token)
+	a.newDoer = func(p string) agent.Doer {
+		if p != "agent-1" {
+			t.Fatalf("want pod agent-1 but get %s", p)
+		}
+		return &agent.HTTPDoer{BaseURL: s.URL, Token: a.token}
+	}
+
+	t.Run("DoCmd", func(t *testing.T) {
+		out, _, err := a.DoCmd("node1", []string{"/bin/sh", "-c", "echo -n 123"})
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if out != "123" {
+			t.Fatalf("want 123 but get %s", out)
+		}
+
+		_, _, err = a.DoCmd("node1", []string{"/bin/sh", "-c", "exit 1"})
+		if err == nil || err.Error() != "command terminated with exit code 1" {
+			t.Fatalf("want exit code error but get %v", err)
+		}
+	})
+
+	t.Run("facts", func(t *testing.T) {
+		var fe FactExecutor = a
+		sysctl, err := fe.SysCtl("node1")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if sysctl["net.ipv4.ip_forward"] != "1" {
+			t.Fatalf("want net.ipv4.ip_forward=1 but get %s", sysctl["net.ipv4.ip_forward"])
+		}
+
+		ipt, err := fe.IPTables("node1")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if ipt.Tables["nat"].Chains["PREROUTING"].Policy != "ACCEPT" {
+			t.Fatalf("wrong iptables %+v", ipt.Tables)
+		}
+
+		ps, err := fe.Processes("node1", "kubelet")
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		if len(ps) != 1 || ps[0].PID != 100 {
+			t.Fatalf("want kubelet process 100 but get %v", ps)
+		}
+	})
+
+	// executor without autoCreate should use the exist token
+	b, err := NewAgentExecutor(logger.NewLogger(), cli, "kube-jarvis",
+		"kube-jarvis-node-agent", "test", false, nil, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if b.token != a.token {
+		t.Fatalf("want token %s but get %s", a.token, b.token)
+	}
+
+	if err := a.Finish(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, err := cli.AppsV1().DaemonSets("kube-jarvis").
+		Get("kube-jarvis-node-agent", metav1.GetOptions{}); err == nil {
+		t.Fatalf("agent DaemonSet should be deleted")
+	}
+
+	if _, err := NewAgentExecutor(logger.NewLogger(), cli, "kube-jarvis",
+		"kube-jarvis-node-agent", "test", false, nil, nil); err == nil {
+		t.Fatalf("should return an error if agent token not found")
+	}
+}
+
+func TestAgentExecutor_AllowList(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	_, err := NewAgentExecutor(logger.NewLogger(), cli, "kube-jarvis",
+		"kube-jarvis-node-agent", "test", true, &AgentConfig{
+			ExecAllowList: [][]string{{"sh", "-c", "echo $(hostname)"}},
+			FileAllowDirs: []string{"/etc/kubernetes", "/opt/pki"},
+		}, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ds, err := cli.AppsV1().DaemonSets("kube-jarvis").Get("kube-jarvis-node-agent", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cmd := strings.Join(ds.Spec.Template.Spec.Containers[0].Command, " ")
+	for _, want := range []string{
+		`-exec-allow-list=[["sh","-c","echo $$(hostname)"]]`,
+		"-file-allow-dirs=/etc/kubernetes,/opt/pki",
+	} {
+		if !strings.Contains(cmd, want) {
+			t.Fatalf("want %s in agent command but get %s", want, cmd)
+		}
+	}
+}
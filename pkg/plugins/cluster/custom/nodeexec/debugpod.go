@@ -0,0 +1,401 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	v12 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+const (
+	// debugPodLabel is the label value of "k8s-app" of debug pods
+	debugPodLabel = "kube-jarvis-debug"
+)
+
+// DebugPodConfig is the config of node executor "debug"
+type DebugPodConfig struct {
+	// Concurrency is the max number of debug pods that exist at the same time, default is 10
+	Concurrency int
+	// TTL is the max lifetime of a debug pod, default is "10m"
+	// leftover debug pods older than TTL will be deleted when executor is created
+	TTL string
+	// StartTimeout is the max time to wait for a debug pod to be ready, default is "2m"
+	StartTimeout string
+}
+
+// complete check and complete config fields
+func (c *DebugPodConfig) complete() error {
+	if c.Concurrency <= 0 {
+		c.Concurrency = 10
+	}
+
+	if c.TTL == "" {
+		c.TTL = "10m"
+	}
+
+	if c.StartTimeout == "" {
+		c.StartTimeout = "2m"
+	}
+	return nil
+}
+
+// debugPod is a debug pod on a node
+type debugPod struct {
+	name     string
+	node     string
+	users    int
+	created  time.Time
+	lastUsed time.Time
+	ready    chan struct{}
+	err      error
+}
+
+// DebugPodExecutor create a short-lived pod on target node to do cmd, like "kubectl debug node"
+// the pod is reused by following commands on the same node, and will be deleted if
+// the number of debug pods reach Concurrency or Finish is called
+type DebugPodExecutor struct {
+	logger         logger.Logger
+	cli            kubernetes.Interface
+	config         *restclient.Config
+	namespace      string
+	image          string
+	concurrency    int
+	ttl            time.Duration
+	startTimeout   time.Duration
+	remoteExecutor remoteExecutor
+	podConfig      *PodConfig
+
+	lock *sync.Mutex
+	cond *sync.Cond
+	pods map[string]*debugPod
+}
+
+// NewDebugPodExecutor create and init a new DebugPodExecutor
+// leftover debug pods older than TTL will be deleted
+func NewDebugPodExecutor(logger logger.Logger, cli kubernetes.Interface,
+	config *restclient.Config, namespace string, image string,
+	conf *DebugPodConfig, podConfig *PodConfig) (*DebugPodExecutor, error) {
+	if conf == nil {
+		conf = &DebugPodConfig{}
+	}
+
+	if err := conf.complete(); err != nil {
+		return nil, err
+	}
+
+	ttl, err := time.ParseDuration(conf.TTL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse ttl failed")
+	}
+
+	startTimeout, err := time.ParseDuration(conf.StartTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse start timeout failed")
+	}
+
+	lock := &sync.Mutex{}
+	d := &DebugPodExecutor{
+		logger:       logger,
+		cli:          cli,
+		config:       config,
+		namespace:    namespace,
+		image:        image,
+		concurrency:  conf.Concurrency,
+		ttl:          ttl,
+		startTimeout: startTimeout,
+		podConfig:    podConfig,
+		remoteExecutor: &defaultExecutor{
+			newSPDYExecutor: remotecommand.NewSPDYExecutor,
+		},
+		lock: lock,
+		cond: sync.NewCond(lock),
+		pods: map[string]*debugPod{},
+	}
+
+	ns := &v1.Namespace{}
+	ns.Name = d.namespace
+	if _, err := d.cli.CoreV1().Namespaces().Create(ns); err != nil {
+		if !k8serr.IsAlreadyExists(err) {
+			return nil, errors.Wrapf(err, "create namespace %s failed", d.namespace)
+		}
+	}
+
+	return d, d.sweep()
+}
+
+// sweep delete leftover debug pods that older than TTL
+func (d *DebugPodExecutor) sweep() error {
+	pods, err := d.cli.CoreV1().Pods(d.namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{
+			"k8s-app": debugPodLabel,
+		}).String(),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "list debug pods failed")
+	}
+
+	for _, pod := range pods.Items {
+		if time.Since(pod.CreationTimestamp.Time) < d.ttl {
+			continue
+		}
+
+		d.logger.Infof("delete leftover debug pod %s/%s", pod.Namespace, pod.Name)
+		if err := d.cli.CoreV1().Pods(d.namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			if !k8serr.IsNotFound(err) {
+				return errors.Wrapf(err, "delete debug pod %s failed", pod.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// DoCmd executes command on target node
+func (d *DebugPodExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	pod, err := d.acquire(nodeName)
+	if err != nil {
+		return "", "", err
+	}
+	defer d.release(pod)
+
+	return d.remoteExecutor.doCmdOnPod(d.cli, d.config, d.namespace, pod.name, cmd)
+}
+
+// Concurrency return the max number of debug pods that exist at the same time
+// callers should not work on more nodes than it at the same time, otherwise
+// debug pods will be deleted and recreated between commands of the same node
+func (d *DebugPodExecutor) Concurrency() int {
+	return d.concurrency
+}
+
+// InContainer return true since commands are executed in debug pods
+func (d *DebugPodExecutor) InContainer() bool {
+	return true
+}
+
+// Finish delete all debug pods
+func (d *DebugPodExecutor) Finish() error {
+	d.lock.Lock()
+	pods := d.pods
+	d.pods = map[string]*debugPod{}
+	d.lock.Unlock()
+
+	var lastErr error
+	for _, pod := range pods {
+		if err := d.deletePod(pod); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// acquire return a ready debug pod on target node
+// a new debug pod will be created if not exist, and an idle debug pod of
+// other node will be deleted if the number of debug pods reach concurrency
+func (d *DebugPodExecutor) acquire(nodeName string) (*debugPod, error) {
+	d.lock.Lock()
+	for {
+		pod, exist := d.pods[nodeName]
+		if exist && pod.users == 0 && time.Since(pod.created) > d.ttl/2 {
+			// the pod will be killed soon because of ActiveDeadlineSeconds, recreate it
+			delete(d.pods, nodeName)
+			d.lock.Unlock()
+			if err := d.deletePod(pod); err != nil {
+				d.logger.Errorf("delete debug pod %s failed: %v", pod.name, err)
+			}
+			d.lock.Lock()
+			continue
+		}
+
+		if exist {
+			pod.users++
+			d.lock.Unlock()
+			<-pod.ready
+			if pod.err != nil {
+				d.release(pod)
+				return nil, pod.err
+			}
+			return pod, nil
+		}
+
+		if len(d.pods) < d.concurrency {
+			break
+		}
+
+		idle := d.idlePod()
+		if idle == nil {
+			d.cond.Wait()
+			continue
+		}
+
+		delete(d.pods, idle.node)
+		d.lock.Unlock()
+		if err := d.deletePod(idle); err != nil {
+			d.logger.Errorf("delete debug pod %s failed: %v", idle.name, err)
+		}
+		d.lock.Lock()
+	}
+
+	pod := &debugPod{
+		name:    debugPodName(nodeName),
+		node:    nodeName,
+		users:   1,
+		created: time.Now(),
+		ready:   make(chan struct{}),
+	}
+	d.pods[nodeName] = pod
+	d.lock.Unlock()
+
+	pod.err = d.createPod(pod)
+	close(pod.ready)
+	if pod.err != nil {
+		d.lock.Lock()
+		if d.pods[nodeName] == pod {
+			delete(d.pods, nodeName)
+		}
+		d.lock.Unlock()
+		d.release(pod)
+		if err := d.deletePod(pod); err != nil {
+			d.logger.Errorf("delete debug pod %s failed: %v", pod.name, err)
+		}
+		return nil, pod.err
+	}
+	return pod, nil
+}
+
+// release mark pod as unused by caller
+func (d *DebugPodExecutor) release(pod *debugPod) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	pod.users--
+	pod.lastUsed = time.Now()
+	d.cond.Broadcast()
+}
+
+// idlePod return the least recently used pod that is not in use
+// d.lock must be locked by caller
+func (d *DebugPodExecutor) idlePod() *debugPod {
+	var idle *debugPod
+	for _, pod := range d.pods {
+		if pod.users != 0 {
+			continue
+		}
+
+		if idle == nil || pod.lastUsed.Before(idle.lastUsed) {
+			idle = pod
+		}
+	}
+	return idle
+}
+
+// createPod create debug pod and wait for it to be ready
+func (d *DebugPodExecutor) createPod(pod *debugPod) error {
+	dsYaml := fmt.Sprintf(proxyYaml, debugPodLabel, d.namespace, d.image)
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	obj, _, err := decode([]byte(dsYaml), nil, nil)
+	if err != nil {
+		return errors.Wrapf(err, "decode proxy yaml failed")
+	}
+
+	ds, ok := obj.(*v12.DaemonSet)
+	if !ok {
+		return fmt.Errorf("covert to app/v1 DaemonSet failed")
+	}
+
+	deadline := int64(d.ttl.Seconds())
+	p := &v1.Pod{
+		ObjectMeta: ds.Spec.Template.ObjectMeta,
+		Spec:       ds.Spec.Template.Spec,
+	}
+	p.Name = pod.name
+	p.Namespace = d.namespace
+	p.Labels = map[string]string{
+		"k8s-app": debugPodLabel,
+	}
+	if err := d.podConfig.apply(&p.Spec); err != nil {
+		return errors.Wrapf(err, "apply pod config failed")
+	}
+
+	// debug pods are bound to node directly
+	p.Spec.NodeSelector = nil
+	p.Spec.NodeName = pod.node
+	p.Spec.RestartPolicy = v1.RestartPolicyNever
+	p.Spec.ActiveDeadlineSeconds = &deadline
+
+	d.logger.Infof("create debug pod %s on node %s", pod.name, pod.node)
+	if _, err := d.cli.CoreV1().Pods(d.namespace).Create(p); err != nil {
+		return errors.Wrapf(err, "create debug pod on node %s failed", pod.node)
+	}
+
+	err = util.RetryUntilTimeout(time.Second, d.startTimeout, func() error {
+		p, err := d.cli.CoreV1().Pods(d.namespace).Get(pod.name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "get debug pod %s failed", pod.name)
+		}
+
+		switch p.Status.Phase {
+		case v1.PodFailed, v1.PodSucceeded:
+			return fmt.Errorf("debug pod %s is %s: %s", pod.name, p.Status.Phase, p.Status.Message)
+		case v1.PodRunning:
+			for _, c := range p.Status.Conditions {
+				if c.Type == v1.PodReady && c.Status == v1.ConditionTrue {
+					return nil
+				}
+			}
+		}
+		return util.RetryAbleErr
+	})
+	if err != nil {
+		return errors.Wrapf(err, "wait debug pod %s ready failed", pod.name)
+	}
+	return nil
+}
+
+func (d *DebugPodExecutor) deletePod(pod *debugPod) error {
+	if err := d.cli.CoreV1().Pods(d.namespace).Delete(pod.name, &metav1.DeleteOptions{}); err != nil {
+		if !k8serr.IsNotFound(err) {
+			return errors.Wrapf(err, "delete debug pod %s failed", pod.name)
+		}
+	}
+	return nil
+}
+
+// debugPodName return a unique pod name for target node
+// the name is not longer than 63, so that it can be used as hostname
+func debugPodName(nodeName string) string {
+	if len(nodeName) > 39 {
+		nodeName = nodeName[:39]
+	}
+	nodeName = strings.TrimRight(strings.ToLower(nodeName), "-.")
+	return fmt.Sprintf("%s-%s-%s", debugPodLabel, nodeName, utilrand.String(5))
+}
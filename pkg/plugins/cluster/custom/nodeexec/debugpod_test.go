@@ -0,0 +1,249 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+// fakePodExecutor check that target pod is on the right node
+// and record the max number of debug pods
+type fakePodExecutor struct {
+	maxPods int32
+}
+
+func (f *fakePodExecutor) doCmdOnPod(cli kubernetes.Interface, config *rest.Config,
+	namespace string, podName string, cmd []string) (string, string, error) {
+	pod, err := cli.CoreV1().Pods(namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(pod.Spec.NodeSelector) != 0 {
+		return "", "", fmt.Errorf("debug pod should not have node selector")
+	}
+
+	pods, err := cli.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		max := atomic.LoadInt32(&f.maxPods)
+		if int32(len(pods.Items)) <= max ||
+			atomic.CompareAndSwapInt32(&f.maxPods, max, int32(len(pods.Items))) {
+			break
+		}
+	}
+
+	time.Sleep(time.Millisecond * 10)
+	return pod.Spec.NodeName, strings.Join(cmd, " "), nil
+}
+
+func newDebugPodClient(objs ...runtime.Object) (*fake.Clientset, *int32) {
+	created := int32(0)
+	cli := fake.NewSimpleClientset(objs...)
+	cli.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*v1.Pod)
+		atomic.AddInt32(&created, 1)
+		if pod.Spec.NodeName == "bad-node" {
+			pod.Status.Phase = v1.PodFailed
+			pod.Status.Message = "node not found"
+			return false, nil, nil
+		}
+
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.Conditions = []v1.PodCondition{
+			{
+				Type:   v1.PodReady,
+				Status: v1.ConditionTrue,
+			},
+		}
+		return false, nil, nil
+	})
+	return cli, &created
+}
+
+func TestDebugPodExecutor_DoCmd(t *testing.T) {
+	var cases = []struct {
+		concurrency int
+		nodes       []string
+		created     int32
+		wantErr     bool
+	}{
+		{
+			concurrency: 10,
+			nodes:       []string{"node1", "node1", "node2", "node1"},
+			created:     2,
+		},
+		{
+			concurrency: 1,
+			nodes:       []string{"node1", "node2", "node1"},
+			created:     3,
+		},
+		{
+			concurrency: 1,
+			nodes:       []string{"bad-node"},
+			created:     1,
+			wantErr:     true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			cli, created := newDebugPodClient()
+			d, err := NewDebugPodExecutor(logger.NewLogger(), cli, nil, "kube-jarvis", "xxx", &DebugPodConfig{
+				Concurrency:  cs.concurrency,
+				StartTimeout: "1s",
+			}, nil)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			exe := &fakePodExecutor{}
+			d.remoteExecutor = exe
+
+			for _, n := range cs.nodes {
+				out, outErr, err := d.DoCmd(n, []string{"sysctl", "-a"})
+				if cs.wantErr {
+					if err == nil {
+						t.Fatalf("should return an error")
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf(err.Error())
+				}
+
+				if out != n || outErr != "sysctl -a" {
+					t.Fatalf("want %s and 'sysctl -a' but get %s and %s", n, out, outErr)
+				}
+			}
+
+			if *created != cs.created {
+				t.Fatalf("want %d pods created but get %d", cs.created, *created)
+			}
+
+			if int(exe.maxPods) > cs.concurrency {
+				t.Fatalf("the number of pods %d is large than concurrency", exe.maxPods)
+			}
+
+			if err := d.Finish(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			pods, _ := cli.CoreV1().Pods("kube-jarvis").List(metav1.ListOptions{})
+			if len(pods.Items) != 0 {
+				t.Fatalf("all debug pods should be deleted")
+			}
+		})
+	}
+}
+
+func TestDebugPodExecutor_Concurrency(t *testing.T) {
+	cli, created := newDebugPodClient()
+	d, err := NewDebugPodExecutor(logger.NewLogger(), cli, nil, "kube-jarvis", "xxx", &DebugPodConfig{
+		Concurrency: 2,
+	}, &PodConfig{
+		NodeSelector:      map[string]string{"gpu": "true"},
+		PriorityClassName: "system-node-critical",
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	exe := &fakePodExecutor{}
+	d.remoteExecutor = exe
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		node := fmt.Sprintf("node%d", i%5)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out, _, err := d.DoCmd(node, []string{"ps"}); err != nil || out != node {
+				t.Errorf("want %s but get %s, %v", node, out, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if exe.maxPods > 2 || *created < 5 {
+		t.Fatalf("want at least 5 pods created and at most 2 pods at the same time, but get %d and %d",
+			*created, exe.maxPods)
+	}
+
+	if err := d.Finish(); err != nil {
+		t.Fatalf(err.Error())
+	}
+}
+
+func TestDebugPodExecutor_sweep(t *testing.T) {
+	newPod := func(name string, app string, age time.Duration) *v1.Pod {
+		pod := &v1.Pod{}
+		pod.Name = name
+		pod.Namespace = "kube-jarvis"
+		pod.Labels = map[string]string{
+			"k8s-app": app,
+		}
+		pod.CreationTimestamp = metav1.NewTime(time.Now().Add(-age))
+		return pod
+	}
+
+	cli, _ := newDebugPodClient(
+		newPod("old", debugPodLabel, time.Hour),
+		newPod("young", debugPodLabel, time.Minute),
+		newPod("other", "kube-jarvis-agent", time.Hour),
+	)
+
+	if _, err := NewDebugPodExecutor(logger.NewLogger(), cli, nil, "kube-jarvis", "xxx", nil, nil); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	pods, _ := cli.CoreV1().Pods("kube-jarvis").List(metav1.ListOptions{})
+	names := make([]string, 0)
+	for _, p := range pods.Items {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+
+	if fmt.Sprint(names) != "[other young]" {
+		t.Fatalf("want [other young] but get %v", names)
+	}
+}
+
+func TestDebugPodName(t *testing.T) {
+	name := debugPodName("Node-" + strings.Repeat("a", 40))
+	if len(name) > 63 || !strings.HasPrefix(name, "kube-jarvis-debug-node-aaa") {
+		t.Fatalf("wrong name %s", name)
+	}
+}
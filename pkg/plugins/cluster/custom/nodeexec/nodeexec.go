@@ -19,9 +19,12 @@ package nodeexec
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/pkg/errors"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
+	"tkestack.io/kube-jarvis/pkg/agent"
 	"tkestack.io/kube-jarvis/pkg/logger"
 )
 
@@ -40,19 +43,85 @@ type Executor interface {
 	Finish() error
 }
 
+// LimitedExecutor is an Executor that can only work on limited nodes at the same time
+type LimitedExecutor interface {
+	Executor
+	// Concurrency return the max number of nodes that commands can be executed on at the same time
+	Concurrency() int
+}
+
+// Concurrency return the number of nodes that callers should work on at the same time
+// it is the Concurrency of exe if exe is a LimitedExecutor and less than max
+func Concurrency(exe Executor, max int) int {
+	if l, ok := exe.(LimitedExecutor); ok && l.Concurrency() > 0 && l.Concurrency() < max {
+		return l.Concurrency()
+	}
+	return max
+}
+
+// FactExecutor is an Executor that can return typed node facts without parsing command output
+type FactExecutor interface {
+	Executor
+	// SysCtl return all kernel parameters of node
+	SysCtl(nodeName string) (map[string]string, error)
+	// IPTables return iptables rules of node
+	IPTables(nodeName string) (*agent.IPTables, error)
+	// Processes return processes with target name on node
+	Processes(nodeName string, name string) ([]agent.Process, error)
+	// Kernel return the kernel and os information of node
+	Kernel(nodeName string) (*agent.Kernel, error)
+	// Disks return the usage of all mounted block devices of node
+	Disks(nodeName string) ([]agent.Disk, error)
+	// CertFile return the certificates in a PEM file or kubeconfig file of node
+	CertFile(nodeName string, path string) (*agent.CertFile, error)
+}
+
+// ContainerExecutor is an Executor that executes commands in a privileged container with host pid namespace
+// commands that read host files or use host tools should be wrapped by HostCmd
+type ContainerExecutor interface {
+	Executor
+	// InContainer return true if commands are executed in container
+	InContainer() bool
+}
+
+// HostCmd return a command that runs cmd in the mount namespace of host if exe executes commands in container
+// cmd is returned directly for other Executors
+func HostCmd(exe Executor, cmd []string) []string {
+	if c, ok := exe.(ContainerExecutor); ok && c.InContainer() {
+		return NSEnterCmd(cmd)
+	}
+	return cmd
+}
+
+// NSEnterCmd return the command that executes cmd in the mount namespace of host
+func NSEnterCmd(cmd []string) []string {
+	return append([]string{"nsenter", "-t", "1", "-m", "--"}, cmd...)
+}
+
 // Config is the config of node executor
 type Config struct {
 	// Type is the node executor type
 	Type string
-	// Namespace is the namespace to install node agent if node executor type is "agent"
+	// Namespace is the namespace to install node agent if node executor type is "proxy", "agent" or "debug"
 	Namespace string
-	// DaemonSet is the DaemonSet name if node executor type is "agent"
+	// DaemonSet is the DaemonSet name if node executor type is "proxy" or "agent"
 	DaemonSet string
-	// Image is the image that will be use to create node agent DaemonSet
+	// Image is the image that will be use to create node agent DaemonSet or debug pods
 	Image string
 	// AutoCreate indicate whether to create node agent DaemonSet if it is not exist
 	// if AutoCreate is true, agent will be deleted once cluster diagnostic done
 	AutoCreate bool
+	// LeftoverAge is the min age of agent DaemonSets left by earlier crashed runs
+	// they will be deleted before creating agent if AutoCreate is true, default is "1h"
+	LeftoverAge string
+	// Pod customize the agent DaemonSet if node executor type is "proxy" or "agent", or debug pods if type is "debug"
+	Pod *PodConfig
+	// SSH is the config of ssh if node executor type is "ssh"
+	SSH *SSHConfig
+	// Debug is the config of debug pods if node executor type is "debug"
+	Debug *DebugPodConfig
+	// Agent is the config of kube-jarvis-agent if node executor type is "agent"
+	Agent *AgentConfig
 }
 
 // NewConfig return a Config with default value
@@ -72,6 +141,13 @@ func (c *Config) Complete() {
 
 	if c.DaemonSet == "" {
 		c.DaemonSet = "kube-jarvis-agent"
+		if c.Type == "agent" {
+			c.DaemonSet = "kube-jarvis-node-agent"
+		}
+	}
+
+	if c.LeftoverAge == "" {
+		c.LeftoverAge = "1h"
 	}
 
 	if c.Image == "" {
@@ -82,9 +158,26 @@ func (c *Config) Complete() {
 // Executor return the appropriate node executor according to config value
 func (c *Config) Executor(logger logger.Logger,
 	cli kubernetes.Interface, config *restclient.Config) (Executor, error) {
+	if c.AutoCreate && (c.Type == "proxy" || c.Type == "agent") {
+		age, err := time.ParseDuration(c.LeftoverAge)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse leftover age failed")
+		}
+
+		if err := sweepLeftovers(logger, cli, c.Namespace, age); err != nil {
+			logger.Errorf("sweep leftover agents failed: %v", err)
+		}
+	}
+
 	switch c.Type {
 	case "proxy":
-		return NewDaemonSetProxy(logger, cli, config, c.Namespace, c.DaemonSet, c.Image, c.AutoCreate)
+		return NewDaemonSetProxy(logger, cli, config, c.Namespace, c.DaemonSet, c.Image, c.AutoCreate, c.Pod)
+	case "agent":
+		return NewAgentExecutor(logger, cli, c.Namespace, c.DaemonSet, c.Image, c.AutoCreate, c.Agent, c.Pod)
+	case "debug":
+		return NewDebugPodExecutor(logger, cli, config, c.Namespace, c.Image, c.Debug, c.Pod)
+	case "ssh":
+		return NewSSHExecutor(logger, cli, c.SSH)
 	case "none":
 		return nil, NoneExecutor
 	}
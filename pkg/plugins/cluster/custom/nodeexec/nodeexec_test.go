@@ -18,8 +18,11 @@
 package nodeexec
 
 import (
-	"k8s.io/client-go/kubernetes/fake"
+	"fmt"
+	"strings"
 	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
 	"tkestack.io/kube-jarvis/pkg/logger"
 )
 
@@ -37,9 +40,79 @@ func TestConfig_Executor(t *testing.T) {
 		t.Fatalf("should return an DaemonSetProxy Executor")
 	}
 
+	n.Type = "debug"
+	exe, err = n.Executor(logger.NewLogger(), fake.NewSimpleClientset(), nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, ok := exe.(*DebugPodExecutor); !ok {
+		t.Fatalf("should return an DebugPodExecutor Executor")
+	}
+
+	n.Type = "agent"
+	n.AutoCreate = true
+	n.DaemonSet = ""
+	n.Complete()
+	if n.DaemonSet != "kube-jarvis-node-agent" {
+		t.Fatalf("want default agent DaemonSet kube-jarvis-node-agent but get %s", n.DaemonSet)
+	}
+
+	exe, err = n.Executor(logger.NewLogger(), fake.NewSimpleClientset(), nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, ok := exe.(FactExecutor); !ok {
+		t.Fatalf("should return a FactExecutor")
+	}
+
+	n.AutoCreate = false
+	n.Type = "ssh"
+	_, err = n.Executor(logger.NewLogger(), fake.NewSimpleClientset(), nil)
+	if err == nil {
+		t.Fatalf("should return an error if no auth method of ssh")
+	}
+
+	n.SSH = &SSHConfig{
+		PrivateKeyFile: "/not/exist",
+	}
+	_, err = n.Executor(logger.NewLogger(), fake.NewSimpleClientset(), nil)
+	if err == nil || !strings.Contains(err.Error(), "read private key failed") {
+		t.Fatalf("should return an read private key error but get %v", err)
+	}
+
 	n.Type = "none"
 	_, err = n.Executor(logger.NewLogger(), fake.NewSimpleClientset(), nil)
 	if err != NoneExecutor {
 		t.Fatalf("should get a UnKnowTypeErr")
 	}
 }
+
+func TestConcurrency(t *testing.T) {
+	var cases = []struct {
+		exe  Executor
+		want int
+	}{
+		{
+			exe:  &SSHExecutor{},
+			want: 200,
+		},
+		{
+			exe:  &DebugPodExecutor{concurrency: 10},
+			want: 10,
+		},
+		{
+			exe:  &DebugPodExecutor{concurrency: 500},
+			want: 200,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%T", cs.exe), func(t *testing.T) {
+			if got := Concurrency(cs.exe, 200); got != cs.want {
+				t.Fatalf("want %d but get %d", cs.want, got)
+			}
+		})
+	}
+}
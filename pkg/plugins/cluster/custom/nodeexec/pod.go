@@ -0,0 +1,160 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Toleration is the toleration of agent pods
+type Toleration struct {
+	Key      string
+	Operator string
+	Value    string
+	Effect   string
+}
+
+// Resources is the compute resources of agent container, e.g. {"cpu": "100m", "memory": "128Mi"}
+type Resources struct {
+	Requests map[string]string
+	Limits   map[string]string
+}
+
+// HostMount is a host path that will be mounted into agent container
+type HostMount struct {
+	HostPath  string
+	MountPath string
+	ReadOnly  bool
+}
+
+// PodConfig customize the pods of agent DaemonSet and debug pods
+type PodConfig struct {
+	// Tolerations replace the default tolerations that tolerate all NoSchedule and NoExecute taints
+	Tolerations []Toleration
+	// NodeSelector select the nodes to run agent, it is ignored by debug pods
+	NodeSelector map[string]string
+	// Resources is the compute resources of agent container
+	Resources Resources
+	// PriorityClassName is the priority class of agent pods
+	PriorityClassName string
+	// ImagePullSecrets is the names of secrets to pull agent image
+	ImagePullSecrets []string
+	// ServiceAccount is the service account of agent pods
+	ServiceAccount string
+	// HostMounts is the extra host paths that will be mounted into agent container
+	HostMounts []HostMount
+}
+
+// apply merge PodConfig into pod spec
+func (p *PodConfig) apply(spec *v1.PodSpec) error {
+	if p == nil {
+		return nil
+	}
+
+	if len(p.Tolerations) != 0 {
+		spec.Tolerations = make([]v1.Toleration, 0, len(p.Tolerations))
+		for _, t := range p.Tolerations {
+			spec.Tolerations = append(spec.Tolerations, v1.Toleration{
+				Key:      t.Key,
+				Operator: v1.TolerationOperator(t.Operator),
+				Value:    t.Value,
+				Effect:   v1.TaintEffect(t.Effect),
+			})
+		}
+	}
+
+	if len(p.NodeSelector) != 0 {
+		spec.NodeSelector = p.NodeSelector
+	}
+
+	if p.PriorityClassName != "" {
+		spec.PriorityClassName = p.PriorityClassName
+	}
+
+	if p.ServiceAccount != "" {
+		spec.ServiceAccountName = p.ServiceAccount
+	}
+
+	for _, s := range p.ImagePullSecrets {
+		spec.ImagePullSecrets = append(spec.ImagePullSecrets, v1.LocalObjectReference{Name: s})
+	}
+
+	requests, err := resourceList(p.Resources.Requests)
+	if err != nil {
+		return errors.Wrapf(err, "parse resource requests failed")
+	}
+
+	limits, err := resourceList(p.Resources.Limits)
+	if err != nil {
+		return errors.Wrapf(err, "parse resource limits failed")
+	}
+
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if requests != nil {
+			c.Resources.Requests = requests
+		}
+
+		if limits != nil {
+			c.Resources.Limits = limits
+		}
+	}
+
+	for i, m := range p.HostMounts {
+		if m.HostPath == "" || m.MountPath == "" {
+			return fmt.Errorf("hostpath and mountpath of host mount can not be empty")
+		}
+
+		name := fmt.Sprintf("extra-host-mount-%d", i)
+		spec.Volumes = append(spec.Volumes, v1.Volume{
+			Name: name,
+			VolumeSource: v1.VolumeSource{
+				HostPath: &v1.HostPathVolumeSource{Path: m.HostPath},
+			},
+		})
+
+		for j := range spec.Containers {
+			spec.Containers[j].VolumeMounts = append(spec.Containers[j].VolumeMounts, v1.VolumeMount{
+				Name:      name,
+				MountPath: m.MountPath,
+				ReadOnly:  m.ReadOnly,
+			})
+		}
+	}
+	return nil
+}
+
+func resourceList(values map[string]string) (v1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	result := v1.ResourceList{}
+	for name, value := range values {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse %s=%s failed", name, value)
+		}
+		result[v1.ResourceName(name)] = q
+	}
+	return result, nil
+}
@@ -0,0 +1,110 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPodConfig_apply(t *testing.T) {
+	var cases = []struct {
+		name   string
+		conf   *PodConfig
+		check  func(spec *v1.PodSpec) bool
+		hasErr bool
+	}{
+		{
+			name: "nil",
+			check: func(spec *v1.PodSpec) bool {
+				return len(spec.Tolerations) == 2 && spec.NodeSelector == nil
+			},
+		},
+		{
+			name: "full",
+			conf: &PodConfig{
+				Tolerations: []Toleration{
+					{Key: "nvidia.com/gpu", Operator: "Exists", Effect: "NoSchedule"},
+				},
+				NodeSelector: map[string]string{"gpu": "true"},
+				Resources: Resources{
+					Requests: map[string]string{"cpu": "100m"},
+					Limits:   map[string]string{"memory": "128Mi"},
+				},
+				PriorityClassName: "system-node-critical",
+				ImagePullSecrets:  []string{"registry"},
+				ServiceAccount:    "kube-jarvis",
+				HostMounts: []HostMount{
+					{HostPath: "/etc/kubernetes", MountPath: "/etc/kubernetes", ReadOnly: true},
+				},
+			},
+			check: func(spec *v1.PodSpec) bool {
+				c := spec.Containers[0]
+				return len(spec.Tolerations) == 1 &&
+					spec.Tolerations[0].Key == "nvidia.com/gpu" &&
+					spec.NodeSelector["gpu"] == "true" &&
+					c.Resources.Requests.Cpu().MilliValue() == 100 &&
+					c.Resources.Limits.Memory().Value() == 128*1024*1024 &&
+					spec.PriorityClassName == "system-node-critical" &&
+					spec.ImagePullSecrets[0].Name == "registry" &&
+					spec.ServiceAccountName == "kube-jarvis" &&
+					spec.Volumes[len(spec.Volumes)-1].HostPath.Path == "/etc/kubernetes" &&
+					c.VolumeMounts[len(c.VolumeMounts)-1].MountPath == "/etc/kubernetes" &&
+					c.VolumeMounts[len(c.VolumeMounts)-1].ReadOnly
+			},
+		},
+		{
+			name: "bad quantity",
+			conf: &PodConfig{
+				Resources: Resources{
+					Requests: map[string]string{"cpu": "xx"},
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "empty host path",
+			conf: &PodConfig{
+				HostMounts: []HostMount{{MountPath: "/data"}},
+			},
+			hasErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			spec := &v1.PodSpec{
+				Tolerations: []v1.Toleration{
+					{Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+					{Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute},
+				},
+				Containers: []v1.Container{{Name: "proxy"}},
+			}
+
+			err := cs.conf.apply(spec)
+			if (err != nil) != cs.hasErr {
+				t.Fatalf("want hasErr %v but get %v", cs.hasErr, err)
+			}
+
+			if !cs.hasErr && !cs.check(spec) {
+				t.Fatalf("wrong pod spec %+v", spec)
+			}
+		})
+	}
+}
@@ -95,12 +95,13 @@ type DaemonSetProxy struct {
 	remoteExecutor remoteExecutor
 	image          string
 	autoCreate     bool
+	podConfig      *PodConfig
 }
 
 // NewDaemonSetProxy create and init a new DaemonSetProxy
 func NewDaemonSetProxy(logger logger.Logger, cli kubernetes.Interface,
 	config *restclient.Config, namespace string,
-	ds string, image string, autoCreate bool) (*DaemonSetProxy, error) {
+	ds string, image string, autoCreate bool, podConfig *PodConfig) (*DaemonSetProxy, error) {
 	d := &DaemonSetProxy{
 		cli:        cli,
 		namespace:  namespace,
@@ -109,6 +110,7 @@ func NewDaemonSetProxy(logger logger.Logger, cli kubernetes.Interface,
 		config:     config,
 		image:      image,
 		autoCreate: autoCreate,
+		podConfig:  podConfig,
 		remoteExecutor: &defaultExecutor{
 			newSPDYExecutor: remotecommand.NewSPDYExecutor,
 		},
@@ -143,6 +145,10 @@ func (d *DaemonSetProxy) tryCreateProxy() error {
 		return fmt.Errorf("covert to app/v1 DaemonSet failed")
 	}
 
+	if err := d.podConfig.apply(&ds.Spec.Template.Spec); err != nil {
+		return errors.Wrapf(err, "apply pod config failed")
+	}
+
 	if _, err := d.cli.AppsV1().DaemonSets(d.namespace).Create(ds); err != nil {
 		if !k8serr.IsAlreadyExists(err) {
 			return errors.Wrapf(err, "create namespace %s failed", d.namespace)
@@ -180,11 +186,18 @@ func (d *DaemonSetProxy) DoCmd(nodeName string,
 			return errors.Wrapf(err, "get agent pod failed")
 		}
 
-		if len(pods.Items) != 1 {
+		running := make([]v1.Pod, 0, len(pods.Items))
+		for _, p := range pods.Items {
+			if !terminating(&p) {
+				running = append(running, p)
+			}
+		}
+
+		if len(running) != 1 {
 			d.logger.Infof("target agent pod on node %s not found, it may be scheduled later", nodeName)
 			return util.RetryAbleErr
 		}
-		pod := pods.Items[0]
+		pod := running[0]
 
 		// check pod status ,it must be ready
 		isReady := false
@@ -208,6 +221,11 @@ func (d *DaemonSetProxy) DoCmd(nodeName string,
 	return retStdout, retStderr, err
 }
 
+// InContainer return true since commands are executed in the pods of DaemonSet
+func (d *DaemonSetProxy) InContainer() bool {
+	return true
+}
+
 // Finish do clean for ComponentExecutor
 func (d *DaemonSetProxy) Finish() error {
 	if d.autoCreate {
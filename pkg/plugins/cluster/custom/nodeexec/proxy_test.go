@@ -24,6 +24,7 @@ import (
 	"testing"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
@@ -33,6 +34,7 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/util"
 )
 
 type fakeStream struct {
@@ -109,7 +111,7 @@ func TestDoCmd(t *testing.T) {
 				}
 			}
 
-			p, err := NewDaemonSetProxy(logger.NewLogger(), cli, nil, "kube-system", "kube-jarvis-agent", "xxx", true)
+			p, err := NewDaemonSetProxy(logger.NewLogger(), cli, nil, "kube-system", "kube-jarvis-agent", "xxx", true, nil)
 			if err != nil {
 				t.Fatalf(err.Error())
 			}
@@ -236,3 +238,46 @@ func TestDefaultExecutor(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDaemonSetProxy_PodConfig(t *testing.T) {
+	conf := NewConfig()
+	if err := util.InitObjViaYaml(conf, map[string]interface{}{
+		"autocreate": true,
+		"pod": map[string]interface{}{
+			"tolerations": []interface{}{
+				map[string]interface{}{"key": "node-role.kubernetes.io/master", "operator": "Exists"},
+			},
+			"nodeselector":      map[string]interface{}{"gpu": "true"},
+			"priorityclassname": "system-node-critical",
+			"hostmounts": []interface{}{
+				map[string]interface{}{"hostpath": "/data", "mountpath": "/data"},
+			},
+		},
+	}); err != nil {
+		t.Fatalf(err.Error())
+	}
+	conf.Complete()
+
+	cli := fake.NewSimpleClientset()
+	if _, err := conf.Executor(logger.NewLogger(), cli, nil); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ds, err := cli.AppsV1().DaemonSets(conf.Namespace).Get(conf.DaemonSet, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	spec := ds.Spec.Template.Spec
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Key != "node-role.kubernetes.io/master" {
+		t.Fatalf("wrong tolerations %+v", spec.Tolerations)
+	}
+
+	if spec.NodeSelector["gpu"] != "true" || spec.PriorityClassName != "system-node-critical" {
+		t.Fatalf("wrong pod spec %+v", spec)
+	}
+
+	if spec.Volumes[len(spec.Volumes)-1].HostPath.Path != "/data" {
+		t.Fatalf("host mount should be added")
+	}
+}
@@ -0,0 +1,422 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+// SSHConfig is the config of node executor "ssh"
+type SSHConfig struct {
+	// User is the login user, default is "root"
+	User string
+	// Port is the ssh port of nodes, default is 22
+	Port int
+	// AddressTypes is the preferred types of node addresses
+	// default is ["InternalIP", "ExternalIP", "Hostname"]
+	AddressTypes []string
+	// Addresses specify the address of some nodes, the key is node name
+	// value can be "host" or "host:port"
+	Addresses map[string]string
+	// PrivateKeyFile is the path of private key file, e.g. "~/.ssh/id_rsa"
+	PrivateKeyFile string
+	// Passphrase is the passphrase of private key
+	Passphrase string
+	// UseAgent indicate whether to use ssh agent for authentication
+	UseAgent bool
+	// AgentSocket is the unix socket of ssh agent, default is $SSH_AUTH_SOCK
+	AgentSocket string
+	// KnownHostsFile is the known_hosts file used to check host keys, default is "~/.ssh/known_hosts"
+	KnownHostsFile string
+	// InsecureIgnoreHostKey indicate whether to skip host keys checking
+	InsecureIgnoreHostKey bool
+	// JumpHost is the address of jump host, e.g. "10.0.0.1:22"
+	// nodes will be connected through jump host if it is not empty
+	JumpHost string
+	// JumpUser is the login user of jump host, default is User
+	JumpUser string
+	// Sudo indicate whether to run commands with "sudo -n"
+	Sudo bool
+	// Timeout is the timeout of connecting, default is "10s"
+	Timeout string
+}
+
+// complete check and complete config fields
+func (c *SSHConfig) complete() error {
+	if c.User == "" {
+		c.User = "root"
+	}
+
+	if c.Port == 0 {
+		c.Port = 22
+	}
+
+	if len(c.AddressTypes) == 0 {
+		c.AddressTypes = []string{
+			string(v1.NodeInternalIP),
+			string(v1.NodeExternalIP),
+			string(v1.NodeHostName),
+		}
+	}
+
+	if c.AgentSocket == "" {
+		c.AgentSocket = os.Getenv("SSH_AUTH_SOCK")
+	}
+
+	if c.KnownHostsFile == "" {
+		c.KnownHostsFile = "~/.ssh/known_hosts"
+	}
+
+	if c.JumpUser == "" {
+		c.JumpUser = c.User
+	}
+
+	if c.Timeout == "" {
+		c.Timeout = "10s"
+	}
+
+	if c.PrivateKeyFile == "" && !c.UseAgent {
+		return fmt.Errorf("privatekeyfile or useagent must be set")
+	}
+
+	if c.UseAgent && c.AgentSocket == "" {
+		return fmt.Errorf("agent socket not found, $SSH_AUTH_SOCK is empty")
+	}
+	return nil
+}
+
+// sshConn is a reusable connection to a node
+type sshConn struct {
+	sync.Mutex
+	client *ssh.Client
+}
+
+// SSHExecutor do cmd on node via ssh
+// connections will be reused until Finish is called
+type SSHExecutor struct {
+	logger       logger.Logger
+	cli          kubernetes.Interface
+	config       *SSHConfig
+	clientConfig *ssh.ClientConfig
+	jumpConfig   *ssh.ClientConfig
+	agentConn    net.Conn
+
+	lock  sync.Mutex
+	jump  *ssh.Client
+	conns map[string]*sshConn
+}
+
+// NewSSHExecutor create and init a new SSHExecutor
+func NewSSHExecutor(logger logger.Logger, cli kubernetes.Interface, config *SSHConfig) (*SSHExecutor, error) {
+	if config == nil {
+		config = &SSHConfig{}
+	}
+
+	if err := config.complete(); err != nil {
+		return nil, err
+	}
+
+	timeout, err := time.ParseDuration(config.Timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse timeout failed")
+	}
+
+	s := &SSHExecutor{
+		logger: logger,
+		cli:    cli,
+		config: config,
+		conns:  map[string]*sshConn{},
+	}
+
+	auth, err := s.authMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !config.InsecureIgnoreHostKey {
+		hostKeyCallback, err = knownhosts.New(expandHome(config.KnownHostsFile))
+		if err != nil {
+			s.closeAgent()
+			return nil, errors.Wrapf(err, "load known hosts file failed")
+		}
+	}
+
+	s.clientConfig = &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	}
+	jumpConfig := *s.clientConfig
+	jumpConfig.User = config.JumpUser
+	s.jumpConfig = &jumpConfig
+	return s, nil
+}
+
+func (s *SSHExecutor) authMethods() ([]ssh.AuthMethod, error) {
+	auth := make([]ssh.AuthMethod, 0)
+	if s.config.PrivateKeyFile != "" {
+		data, err := ioutil.ReadFile(expandHome(s.config.PrivateKeyFile))
+		if err != nil {
+			return nil, errors.Wrapf(err, "read private key failed")
+		}
+
+		var signer ssh.Signer
+		if s.config.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(s.config.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(data)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse private key failed")
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	if s.config.UseAgent {
+		conn, err := net.Dial("unix", s.config.AgentSocket)
+		if err != nil {
+			return nil, errors.Wrapf(err, "connect to ssh agent failed")
+		}
+		s.agentConn = conn
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	return auth, nil
+}
+
+// DoCmd executes command on target node
+// a broken connection will be re-created once
+func (s *SSHExecutor) DoCmd(nodeName string, cmd []string) (string, string, error) {
+	conn := s.getConn(nodeName)
+	conn.Lock()
+	client, err := s.connect(conn, nodeName)
+	conn.Unlock()
+	if err != nil {
+		return "", "", err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		s.logger.Infof("create session on node %s failed, reconnect: %v", nodeName, err)
+		conn.Lock()
+		if conn.client == client {
+			_ = conn.client.Close()
+			conn.client = nil
+		}
+		client, err = s.connect(conn, nodeName)
+		conn.Unlock()
+		if err != nil {
+			return "", "", err
+		}
+
+		session, err = client.NewSession()
+		if err != nil {
+			return "", "", errors.Wrapf(err, "create session on node %s failed", nodeName)
+		}
+	}
+	defer session.Close()
+
+	stdout := bytes.NewBuffer([]byte{})
+	stderr := bytes.NewBuffer([]byte{})
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Run(s.command(cmd)); err != nil {
+		if exitErr, ok := err.(*ssh.ExitError); ok {
+			err = fmt.Errorf("command terminated with exit code %d", exitErr.ExitStatus())
+		}
+		return stdout.String(), stderr.String(), err
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
+
+// Finish close all connections
+func (s *SSHExecutor) Finish() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, conn := range s.conns {
+		conn.Lock()
+		if conn.client != nil {
+			_ = conn.client.Close()
+			conn.client = nil
+		}
+		conn.Unlock()
+	}
+	s.conns = map[string]*sshConn{}
+
+	if s.jump != nil {
+		_ = s.jump.Close()
+		s.jump = nil
+	}
+	s.closeAgent()
+	return nil
+}
+
+func (s *SSHExecutor) closeAgent() {
+	if s.agentConn != nil {
+		_ = s.agentConn.Close()
+		s.agentConn = nil
+	}
+}
+
+func (s *SSHExecutor) getConn(nodeName string) *sshConn {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	conn, exist := s.conns[nodeName]
+	if !exist {
+		conn = &sshConn{}
+		s.conns[nodeName] = conn
+	}
+	return conn
+}
+
+// connect return the client of conn, or create a new one if it is nil
+// conn must be locked by caller
+func (s *SSHExecutor) connect(conn *sshConn, nodeName string) (*ssh.Client, error) {
+	if conn.client != nil {
+		return conn.client, nil
+	}
+
+	addr, err := s.address(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.JumpHost == "" {
+		conn.client, err = ssh.Dial("tcp", addr, s.clientConfig)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ssh to node %s(%s) failed", nodeName, addr)
+		}
+		return conn.client, nil
+	}
+
+	jump, err := s.jumpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	netConn, err := jump.Dial("tcp", addr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial node %s(%s) via jump host failed", nodeName, addr)
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(netConn, addr, s.clientConfig)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, errors.Wrapf(err, "ssh to node %s(%s) via jump host failed", nodeName, addr)
+	}
+
+	conn.client = ssh.NewClient(c, chans, reqs)
+	return conn.client, nil
+}
+
+func (s *SSHExecutor) jumpClient() (*ssh.Client, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.jump != nil {
+		// check whether the connection is still alive
+		if _, _, err := s.jump.SendRequest("keepalive@kube-jarvis", true, nil); err == nil {
+			return s.jump, nil
+		}
+		_ = s.jump.Close()
+		s.jump = nil
+	}
+
+	var err error
+	s.jump, err = ssh.Dial("tcp", s.config.JumpHost, s.jumpConfig)
+	if err != nil {
+		return nil, errors.Wrapf(err, "ssh to jump host %s failed", s.config.JumpHost)
+	}
+	return s.jump, nil
+}
+
+// address return the ssh address of target node
+func (s *SSHExecutor) address(nodeName string) (string, error) {
+	if addr, exist := s.config.Addresses[nodeName]; exist {
+		return s.withPort(addr), nil
+	}
+
+	node, err := s.cli.CoreV1().Nodes().Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "get node %s failed", nodeName)
+	}
+
+	for _, typ := range s.config.AddressTypes {
+		for _, addr := range node.Status.Addresses {
+			if string(addr.Type) == typ && addr.Address != "" {
+				return s.withPort(addr.Address), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no address of types %v found for node %s", s.config.AddressTypes, nodeName)
+}
+
+func (s *SSHExecutor) withPort(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(addr, strconv.Itoa(s.config.Port))
+}
+
+// command join and quote cmd to a shell command line
+func (s *SSHExecutor) command(cmd []string) string {
+	args := make([]string, 0, len(cmd)+2)
+	if s.config.Sudo {
+		args = append(args, "sudo", "-n")
+	}
+
+	for _, arg := range cmd {
+		args = append(args, "'"+strings.Replace(arg, "'", `'\''`, -1)+"'")
+	}
+	return strings.Join(args, " ")
+}
+
+// expandHome replace the leading "~" of path with home dir
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
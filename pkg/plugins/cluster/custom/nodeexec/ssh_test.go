@@ -0,0 +1,453 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+// testSSHServer is a in-process ssh server that run "exec" requests with local shell
+// and forward "direct-tcpip" channels, so that it can be used as node or jump host
+type testSSHServer struct {
+	lis     net.Listener
+	hostKey ssh.Signer
+	config  *ssh.ServerConfig
+	conns   int32
+	wg      sync.WaitGroup
+}
+
+func newTestSSHServer(t *testing.T, authorized ssh.PublicKey) *testSSHServer {
+	hostKey, err := ssh.NewSignerFromKey(newTestKey(t))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	s := &testSSHServer{
+		hostKey: hostKey,
+		config: &ssh.ServerConfig{
+			PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+				if conn.User() == "root" && string(key.Marshal()) == string(authorized.Marshal()) {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("unauthorized")
+			},
+		},
+	}
+	s.config.AddHostKey(hostKey)
+
+	s.lis, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go func() {
+		for {
+			conn, err := s.lis.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testSSHServer) addr() string {
+	return s.lis.Addr().String()
+}
+
+func (s *testSSHServer) close() {
+	_ = s.lis.Close()
+}
+
+func (s *testSSHServer) serve(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	atomic.AddInt32(&s.conns, 1)
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		switch newCh.ChannelType() {
+		case "session":
+			ch, reqs, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			go s.session(ch, reqs)
+		case "direct-tcpip":
+			target := struct {
+				Host       string
+				Port       uint32
+				OriginHost string
+				OriginPort uint32
+			}{}
+			if err := ssh.Unmarshal(newCh.ExtraData(), &target); err != nil {
+				_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+
+			tcpConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+			if err != nil {
+				_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+
+			ch, reqs, err := newCh.Accept()
+			if err != nil {
+				_ = tcpConn.Close()
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			go func() {
+				_, _ = io.Copy(ch, tcpConn)
+				_ = ch.Close()
+			}()
+			go func() {
+				_, _ = io.Copy(tcpConn, ch)
+				_ = tcpConn.Close()
+			}()
+		default:
+			_ = newCh.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+}
+
+func (s *testSSHServer) session(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+
+		payload := struct{ Command string }{}
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+
+		cmd := exec.Command("/bin/sh", "-c", payload.Command)
+		cmd.Stdout = ch
+		cmd.Stderr = ch.Stderr()
+		status := uint32(0)
+		if err := cmd.Run(); err != nil {
+			status = 1
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				status = uint32(exitErr.ExitCode())
+			}
+		}
+
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return key
+}
+
+func writeTestKey(t *testing.T, dir string, key *ecdsa.PrivateKey) string {
+	data, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	path := filepath.Join(dir, "id_ecdsa")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{
+		Type:  "EC PRIVATE KEY",
+		Bytes: data,
+	}), 0600); err != nil {
+		t.Fatalf(err.Error())
+	}
+	return path
+}
+
+func writeKnownHosts(t *testing.T, dir string, servers ...*testSSHServer) string {
+	lines := make([]string, 0)
+	for _, s := range servers {
+		lines = append(lines, knownhosts.Line([]string{s.addr()}, s.hostKey.PublicKey()))
+	}
+
+	path := filepath.Join(dir, "known_hosts")
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf(err.Error())
+	}
+	return path
+}
+
+func startTestAgent(t *testing.T, dir string, key *ecdsa.PrivateKey) string {
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	sock := filepath.Join(dir, "agent.sock")
+	lis, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	return sock
+}
+
+func TestSSHExecutor_DoCmd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	key := newTestKey(t)
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	keyFile := writeTestKey(t, dir, key)
+	agentSock := startTestAgent(t, dir, key)
+	otherServer := newTestSSHServer(t, pub)
+	defer otherServer.close()
+
+	var cases = []struct {
+		name     string
+		config   func(node, jump *testSSHServer) *SSHConfig
+		jump     bool
+		override bool
+		wantErr  bool
+	}{
+		{
+			name: "private key",
+			config: func(node, jump *testSSHServer) *SSHConfig {
+				return &SSHConfig{
+					PrivateKeyFile: keyFile,
+					KnownHostsFile: writeKnownHosts(t, dir, node),
+				}
+			},
+		},
+		{
+			name: "agent",
+			config: func(node, jump *testSSHServer) *SSHConfig {
+				return &SSHConfig{
+					UseAgent:              true,
+					AgentSocket:           agentSock,
+					InsecureIgnoreHostKey: true,
+				}
+			},
+		},
+		{
+			name: "jump host",
+			jump: true,
+			config: func(node, jump *testSSHServer) *SSHConfig {
+				return &SSHConfig{
+					PrivateKeyFile: keyFile,
+					KnownHostsFile: writeKnownHosts(t, dir, node, jump),
+					JumpHost:       jump.addr(),
+				}
+			},
+		},
+		{
+			name:     "address override",
+			override: true,
+			config: func(node, jump *testSSHServer) *SSHConfig {
+				return &SSHConfig{
+					PrivateKeyFile:        keyFile,
+					InsecureIgnoreHostKey: true,
+					Addresses: map[string]string{
+						"node1": node.addr(),
+					},
+				}
+			},
+		},
+		{
+			name:    "unknown host key",
+			wantErr: true,
+			config: func(node, jump *testSSHServer) *SSHConfig {
+				return &SSHConfig{
+					PrivateKeyFile: keyFile,
+					KnownHostsFile: writeKnownHosts(t, dir, otherServer),
+				}
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			node := newTestSSHServer(t, pub)
+			defer node.close()
+			jump := newTestSSHServer(t, pub)
+			defer jump.close()
+
+			host, port, _ := net.SplitHostPort(node.addr())
+			n := &v1.Node{}
+			n.Name = "node1"
+			n.Status.Addresses = []v1.NodeAddress{
+				{
+					Type:    v1.NodeHostName,
+					Address: "node1",
+				},
+				{
+					Type:    v1.NodeInternalIP,
+					Address: host,
+				},
+			}
+			if cs.override {
+				n.Status.Addresses = nil
+			}
+
+			config := cs.config(node, jump)
+			_, _ = fmt.Sscanf(port, "%d", &config.Port)
+			e, err := NewSSHExecutor(logger.NewLogger(), fake.NewSimpleClientset(n), config)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			defer e.Finish()
+
+			out, errOut, err := e.DoCmd("node1", []string{"sh", "-c", "echo out; echo err >&2"})
+			if cs.wantErr {
+				if err == nil {
+					t.Fatalf("should return an error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if out != "out\n" || errOut != "err\n" {
+				t.Fatalf("want out and err but get %q %q", out, errOut)
+			}
+
+			out, _, err = e.DoCmd("node1", []string{"echo", "it's a test"})
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if out != "it's a test\n" {
+				t.Fatalf("want \"it's a test\" but get %q", out)
+			}
+
+			_, _, err = e.DoCmd("node1", []string{"sh", "-c", "exit 3"})
+			if err == nil || !strings.Contains(err.Error(), "terminated with exit code 3") {
+				t.Fatalf("want exit code 3 but get %v", err)
+			}
+
+			if n := atomic.LoadInt32(&node.conns); n != 1 {
+				t.Fatalf("connection should be reused, but get %d connections", n)
+			}
+
+			jumpConns := int32(0)
+			if cs.jump {
+				jumpConns = 1
+			}
+			if n := atomic.LoadInt32(&jump.conns); n != jumpConns {
+				t.Fatalf("want %d connections of jump host but get %d", jumpConns, n)
+			}
+
+			if _, _, err := e.DoCmd("node2", []string{"true"}); err == nil {
+				t.Fatalf("should return an error if node not found")
+			}
+		})
+	}
+}
+
+func TestSSHConfig_complete(t *testing.T) {
+	var cases = []struct {
+		config  SSHConfig
+		wantErr bool
+	}{
+		{
+			config:  SSHConfig{},
+			wantErr: true,
+		},
+		{
+			config: SSHConfig{
+				UseAgent: true,
+			},
+			wantErr: os.Getenv("SSH_AUTH_SOCK") == "",
+		},
+		{
+			config: SSHConfig{
+				PrivateKeyFile: "~/.ssh/id_rsa",
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs.config), func(t *testing.T) {
+			if err := cs.config.complete(); (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+
+			if cs.config.User != "root" || cs.config.Port != 22 || len(cs.config.AddressTypes) != 3 {
+				t.Fatalf("default values not set")
+			}
+		})
+	}
+}
+
+func TestSSHExecutor_command(t *testing.T) {
+	s := &SSHExecutor{config: &SSHConfig{Sudo: true}}
+	cmd := s.command([]string{"sh", "-c", "echo 'a'"})
+	if cmd != `sudo -n 'sh' '-c' 'echo '\''a'\'''` {
+		t.Fatalf("wrong command %s", cmd)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+const (
+	// OwnerLabel is the label key of objects that created by kube-jarvis automatically
+	OwnerLabel = "kube-jarvis/owner"
+	// OwnerValue is the value of OwnerLabel
+	OwnerValue = "kube-jarvis"
+)
+
+// sweepLeftovers delete agent DaemonSets and Secrets that left by earlier crashed runs
+// only objects with OwnerLabel and older than age are deleted, including the ones that have the same name as
+// the agent of current run, so that the agent is recreated with current image, pod config and a new token
+// objects younger than age may belong to another running kube-jarvis, so they are skipped
+func sweepLeftovers(logger logger.Logger, cli kubernetes.Interface,
+	namespace string, age time.Duration) error {
+	selector := labels.SelectorFromSet(map[string]string{
+		OwnerLabel: OwnerValue,
+	}).String()
+	background := metav1.DeletePropagationBackground
+
+	dss, err := cli.AppsV1().DaemonSets(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "list leftover DaemonSets failed")
+	}
+
+	for _, ds := range dss.Items {
+		if time.Since(ds.CreationTimestamp.Time) < age {
+			continue
+		}
+
+		logger.Infof("delete leftover DaemonSet %s/%s", ds.Namespace, ds.Name)
+		if err := cli.AppsV1().DaemonSets(namespace).Delete(ds.Name,
+			&metav1.DeleteOptions{PropagationPolicy: &background}); err != nil && !k8serr.IsNotFound(err) {
+			return errors.Wrapf(err, "delete DaemonSet %s failed", ds.Name)
+		}
+	}
+
+	secrets, err := cli.CoreV1().Secrets(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "list leftover Secrets failed")
+	}
+
+	for _, s := range secrets.Items {
+		if time.Since(s.CreationTimestamp.Time) < age {
+			continue
+		}
+
+		logger.Infof("delete leftover Secret %s/%s", s.Namespace, s.Name)
+		if err := cli.CoreV1().Secrets(namespace).Delete(s.Name,
+			&metav1.DeleteOptions{}); err != nil && !k8serr.IsNotFound(err) {
+			return errors.Wrapf(err, "delete Secret %s failed", s.Name)
+		}
+	}
+	return nil
+}
+
+// terminating return true if pod is being deleted, e.g. the pod of a swept leftover DaemonSet
+func terminating(pod *v1.Pod) bool {
+	return pod.DeletionTimestamp != nil
+}
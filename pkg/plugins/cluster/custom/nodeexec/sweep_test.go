@@ -0,0 +1,138 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package nodeexec
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
+
+func TestSweepLeftovers(t *testing.T) {
+	owned := map[string]string{OwnerLabel: OwnerValue}
+	old := metav1.NewTime(time.Now().Add(-time.Hour * 2))
+	fresh := metav1.NewTime(time.Now())
+
+	cli := fake.NewSimpleClientset()
+	for _, o := range []struct {
+		name    string
+		labels  map[string]string
+		created metav1.Time
+	}{
+		{name: "old", labels: owned, created: old},
+		{name: "fresh", labels: owned, created: fresh},
+		{name: "user", created: old},
+		{name: "kube-jarvis-node-agent", labels: owned, created: old},
+	} {
+		ds := &appv1.DaemonSet{}
+		ds.Name = o.name
+		ds.Namespace = "kube-jarvis"
+		ds.Labels = o.labels
+		ds.CreationTimestamp = o.created
+		if _, err := cli.AppsV1().DaemonSets(ds.Namespace).Create(ds); err != nil {
+			t.Fatalf(err.Error())
+		}
+
+		secret := &v1.Secret{}
+		secret.Name = o.name + "-token"
+		secret.Namespace = "kube-jarvis"
+		secret.Labels = o.labels
+		secret.CreationTimestamp = o.created
+		if _, err := cli.CoreV1().Secrets(secret.Namespace).Create(secret); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	if err := sweepLeftovers(logger.NewLogger(), cli, "kube-jarvis", time.Hour); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	dss, _ := cli.AppsV1().DaemonSets("kube-jarvis").List(metav1.ListOptions{})
+	names := make([]string, 0)
+	for _, ds := range dss.Items {
+		names = append(names, ds.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "fresh,user" {
+		t.Fatalf("want DaemonSets fresh,user but get %v", names)
+	}
+
+	secrets, _ := cli.CoreV1().Secrets("kube-jarvis").List(metav1.ListOptions{})
+	names = make([]string, 0)
+	for _, s := range secrets.Items {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "fresh-token,user-token" {
+		t.Fatalf("want Secrets fresh-token,user-token but get %v", names)
+	}
+}
+
+func TestConfig_ExecutorRecreateLeftover(t *testing.T) {
+	cli := fake.NewSimpleClientset()
+	ds := &appv1.DaemonSet{}
+	ds.Name = "kube-jarvis-node-agent"
+	ds.Namespace = "kube-jarvis"
+	ds.Labels = map[string]string{OwnerLabel: OwnerValue}
+	ds.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour * 2))
+	ds.Spec.Template.Spec.Containers = []v1.Container{{Name: "agent", Image: "old"}}
+	if _, err := cli.AppsV1().DaemonSets(ds.Namespace).Create(ds); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	secret := &v1.Secret{}
+	secret.Name = "kube-jarvis-node-agent-token"
+	secret.Namespace = "kube-jarvis"
+	secret.Labels = ds.Labels
+	secret.CreationTimestamp = ds.CreationTimestamp
+	secret.Data = map[string][]byte{"token": []byte("old")}
+	if _, err := cli.CoreV1().Secrets(secret.Namespace).Create(secret); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	c := NewConfig()
+	c.Type = "agent"
+	c.AutoCreate = true
+	c.Image = "new"
+	c.Complete()
+	exe, err := c.Executor(logger.NewLogger(), cli, nil)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ds, err = cli.AppsV1().DaemonSets("kube-jarvis").Get("kube-jarvis-node-agent", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if ds.Spec.Template.Spec.Containers[0].Image != "new" {
+		t.Fatalf("leftover agent should be recreated with image new but get %s",
+			ds.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	if exe.(*AgentExecutor).token == "old" {
+		t.Fatalf("token of leftover agent should not be reused")
+	}
+}
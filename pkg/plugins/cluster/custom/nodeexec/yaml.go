@@ -23,6 +23,7 @@ metadata:
   name: %s
   labels:
     k8s-app: kube-jarvis-agent
+    kube-jarvis/owner: kube-jarvis
   namespace: %s
 spec:
   selector:
@@ -105,3 +106,59 @@ spec:
         hostPath:
           path: /etc/sudoers.d
           type: Directory`
+
+var agentYaml = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: %[1]s
+  labels:
+    k8s-app: kube-jarvis-node-agent
+    kube-jarvis/owner: kube-jarvis
+  namespace: %[2]s
+spec:
+  selector:
+    matchLabels:
+      k8s-app: kube-jarvis-node-agent
+  template:
+    metadata:
+      labels:
+        k8s-app: kube-jarvis-node-agent
+    spec:
+      hostNetwork: true
+      hostPID: true
+      tolerations:
+      - effect: NoExecute
+        operator: Exists
+      - effect: NoSchedule
+        operator: Exists
+      containers:
+      - image: %[3]s
+        command: ["kube-jarvis-agent", "-address=$(POD_IP)", "-port=%[4]d", "-host-root=/host"]
+        imagePullPolicy: Always
+        name: agent
+        env:
+        - name: POD_IP
+          valueFrom:
+            fieldRef:
+              fieldPath: status.podIP
+        - name: KUBE_JARVIS_AGENT_TOKEN
+          valueFrom:
+            secretKeyRef:
+              name: %[5]s
+              key: token
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: %[4]d
+        securityContext:
+          runAsUser: 0
+          privileged: true
+        volumeMounts:
+        - name: host-root
+          mountPath: /host
+          readOnly: true
+      volumes:
+      - name: host-root
+        hostPath:
+          path: /
+          type: Directory`
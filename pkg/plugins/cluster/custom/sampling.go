@@ -0,0 +1,121 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package custom
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+)
+
+const (
+	// mastersGroup is the group name of master nodes if AllMasters is true
+	mastersGroup = "masters"
+)
+
+// masterLabels are the labels that master nodes may have
+// "node-role.kubernetes.io/control-plane" replaces "node-role.kubernetes.io/master" in newer versions
+var masterLabels = []string{"node-role.kubernetes.io/master", "node-role.kubernetes.io/control-plane"}
+
+// Sampling is the config of collecting machines of part of nodes
+type Sampling struct {
+	// Label is the node label that used to group nodes, e.g. "node.kubernetes.io/instance-type"
+	Label string
+	// Size is the number of nodes sampled per group, default is 3
+	Size int
+	// AllMasters indicate collecting machines of all master nodes, they are in group "masters"
+	AllMasters bool
+}
+
+// complete check and complete config items
+func (s *Sampling) complete() error {
+	if s.Label == "" {
+		return fmt.Errorf("label of sampling can not be empty")
+	}
+
+	if s.Size <= 0 {
+		s.Size = 3
+	}
+	return nil
+}
+
+// isMaster return true if node has any of masterLabels
+func isMaster(n v1.Node) bool {
+	for _, l := range masterLabels {
+		if _, exist := n.Labels[l]; exist {
+			return true
+		}
+	}
+	return false
+}
+
+// groups divide nodes into groups and sample nodes of each group
+// ready nodes are preferred
+func (s *Sampling) groups(nodes []v1.Node) []cluster.MachineGroup {
+	groupNodes := map[string][]v1.Node{}
+	for _, n := range nodes {
+		name := fmt.Sprintf("%s=%s", s.Label, n.Labels[s.Label])
+		if s.AllMasters && isMaster(n) {
+			name = mastersGroup
+		}
+		groupNodes[name] = append(groupNodes[name], n)
+	}
+
+	result := make([]cluster.MachineGroup, 0, len(groupNodes))
+	for name, ns := range groupNodes {
+		size := s.Size
+		if name == mastersGroup {
+			size = len(ns)
+		}
+
+		rand.Shuffle(len(ns), func(i, j int) {
+			ns[i], ns[j] = ns[j], ns[i]
+		})
+		sort.SliceStable(ns, func(i, j int) bool {
+			return isNodeReady(ns[i]) && !isNodeReady(ns[j])
+		})
+
+		g := cluster.MachineGroup{Name: name}
+		for i, n := range ns {
+			g.Nodes = append(g.Nodes, n.Name)
+			if i < size {
+				g.Sampled = append(g.Sampled, n.Name)
+			}
+		}
+		sort.Strings(g.Nodes)
+		sort.Strings(g.Sampled)
+		result = append(result, g)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func isNodeReady(n v1.Node) bool {
+	for _, c := range n.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package custom
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func newSamplingNode(name string, labels map[string]string, ready bool) v1.Node {
+	n := v1.Node{}
+	n.Name = name
+	n.Labels = labels
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	n.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status}}
+	return n
+}
+
+func TestSampling_groups(t *testing.T) {
+	const label = "node.kubernetes.io/instance-type"
+	nodes := []v1.Node{
+		newSamplingNode("master1", map[string]string{"node-role.kubernetes.io/master": "", label: "large"}, true),
+		newSamplingNode("master2", map[string]string{"node-role.kubernetes.io/control-plane": "", label: "large"}, true),
+		newSamplingNode("gpu1", map[string]string{label: "gpu"}, false),
+		newSamplingNode("gpu2", map[string]string{label: "gpu"}, true),
+		newSamplingNode("other", map[string]string{}, true),
+	}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, newSamplingNode(fmt.Sprintf("large%d", i), map[string]string{label: "large"}, true))
+	}
+
+	var cases = []struct {
+		allMasters bool
+		names      []string
+		total      []int
+		sampled    []int
+	}{
+		{
+			allMasters: true,
+			names:      []string{"masters", label + "=", label + "=gpu", label + "=large"},
+			total:      []int{2, 1, 2, 10},
+			sampled:    []int{2, 1, 1, 1},
+		},
+		{
+			allMasters: false,
+			names:      []string{label + "=", label + "=gpu", label + "=large"},
+			total:      []int{1, 2, 12},
+			sampled:    []int{1, 1, 1},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%v", cs.allMasters), func(t *testing.T) {
+			s := &Sampling{Label: label, Size: 1, AllMasters: cs.allMasters}
+			if err := s.complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			groups := s.groups(nodes)
+			names := make([]string, 0)
+			for i, g := range groups {
+				names = append(names, g.Name)
+				if len(g.Nodes) != cs.total[i] || len(g.Sampled) != cs.sampled[i] {
+					t.Fatalf("group %s want %d nodes and %d sampled but get %d and %d",
+						g.Name, cs.total[i], cs.sampled[i], len(g.Nodes), len(g.Sampled))
+				}
+
+				// ready node should be preferred
+				if g.Name == label+"=gpu" && g.Sampled[0] != "gpu2" {
+					t.Fatalf("ready node gpu2 should be sampled")
+				}
+			}
+
+			if !reflect.DeepEqual(names, cs.names) {
+				t.Fatalf("want groups %v but get %v", cs.names, names)
+			}
+		})
+	}
+
+	if err := (&Sampling{}).complete(); err == nil {
+		t.Fatalf("should return an error if label is empty")
+	}
+}
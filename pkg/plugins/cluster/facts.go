@@ -0,0 +1,179 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// the names of built-in machine facts
+const (
+	// FactKernel is a KernelFact
+	FactKernel = "kernel"
+	// FactUptime is an UptimeFact
+	FactUptime = "uptime"
+	// FactDisks is a []DiskFact
+	FactDisks = "disks"
+	// FactMemory is a MemoryFact
+	FactMemory = "memory"
+	// FactULimits is an ULimitsFact
+	FactULimits = "ulimits"
+	// FactConntrack is a ConntrackFact
+	FactConntrack = "conntrack"
+	// FactTimeSync is a TimeSyncFact
+	FactTimeSync = "timesync"
+	// FactCgroup is a CgroupFact
+	FactCgroup = "cgroup"
+	// FactIPVS is an IPVSFact
+	FactIPVS = "ipvs"
+)
+
+// KernelFact is the kernel information of a machine
+type KernelFact struct {
+	// Release is the kernel release, e.g. "4.14.105-1-tlinux3-0010"
+	Release string
+	// Version is the kernel version, e.g. "#1 SMP Thu Jan 9 15:27:10 CST 2020"
+	Version string
+	// Arch is the machine hardware name, e.g. "x86_64"
+	Arch string
+}
+
+// UptimeFact is the uptime of a machine
+type UptimeFact struct {
+	// Seconds is the seconds since machine booted
+	Seconds float64
+}
+
+// DiskFact is the usage of a mounted block device
+type DiskFact struct {
+	FileSystem string
+	Type       string
+	MountPoint string
+	// Total, Used and Available are in bytes
+	Total     int64
+	Used      int64
+	Available int64
+}
+
+// MemoryFact is the memory and swap usage of a machine, all values are in bytes
+type MemoryFact struct {
+	Total     int64
+	Available int64
+	SwapTotal int64
+	SwapFree  int64
+}
+
+// ULimit is the soft and hard value of a resource limit, "unlimited" means no limit
+type ULimit struct {
+	Soft string
+	Hard string
+	Unit string
+}
+
+// ULimitsFact is the resource limits of host init process
+// the key is the limit name in /proc/1/limits, e.g. "Max open files"
+type ULimitsFact map[string]ULimit
+
+// ConntrackFact is the usage of conntrack table
+type ConntrackFact struct {
+	Count int64
+	Max   int64
+}
+
+// TimeSyncFact is the time synchronization status of a machine
+type TimeSyncFact struct {
+	// Synchronized is true if system clock is synchronized
+	Synchronized bool
+	// NTPService is true if ntp service is active
+	NTPService bool
+}
+
+// CgroupFact is the cgroup information of a machine
+type CgroupFact struct {
+	// Version is the cgroup version, 1 or 2
+	Version int
+	// Runtime is the container runtime that Driver is read from
+	Runtime string
+	// Driver is the cgroup driver of container runtime, "cgroupfs" or "systemd"
+	Driver string
+}
+
+// IPVSRealServer is a real server of IPVS virtual server
+type IPVSRealServer struct {
+	// Address is "ip:port" of real server
+	Address string
+	// Forward is the forwarding method, e.g. "Masq", "Route"
+	Forward    string
+	Weight     int
+	ActiveConn int
+	InActConn  int
+}
+
+// IPVSService is a virtual server of IPVS
+type IPVSService struct {
+	// Protocol is "TCP", "UDP", "SCTP" or "FWM"
+	Protocol string
+	// Address is "ip:port" of virtual server
+	Address   string
+	Scheduler string
+	// RealServers is the backends of this virtual server
+	RealServers []IPVSRealServer
+}
+
+// IPVSFact is the IPVS virtual servers of a machine from "ipvsadm -Ln"
+type IPVSFact struct {
+	// Available is false if IPVS module is not loaded
+	// the fact is not collected and an error is recorded in Machine.FactErrors if ipvsadm is not installed
+	Available bool
+	Services  []IPVSService
+}
+
+// Fact read the fact with target name into obj, obj must be a pointer
+// false will be returned if the fact is not collected
+func (m Machine) Fact(name string, obj interface{}) (bool, error) {
+	fact, exist := m.Facts[name]
+	if !exist {
+		return false, nil
+	}
+
+	dst := reflect.ValueOf(obj)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() {
+		return false, fmt.Errorf("obj must be a non-nil pointer")
+	}
+
+	src := reflect.ValueOf(fact)
+	if src.IsValid() && src.Type().AssignableTo(dst.Elem().Type()) {
+		dst.Elem().Set(src)
+		return true, nil
+	}
+
+	// facts decoded from json are generic maps and slices
+	data, err := json.Marshal(fact)
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return false, errors.Wrapf(err, "decode fact %s failed", name)
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMachine_Fact(t *testing.T) {
+	m := Machine{
+		Facts: map[string]interface{}{
+			FactMemory: MemoryFact{Total: 1024},
+			FactDisks:  []DiskFact{{FileSystem: "/dev/vda1", Total: 2048}},
+		},
+	}
+
+	// facts decoded from json should also be readable
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	decoded := Machine{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	for _, m := range []Machine{m, decoded} {
+		mem := MemoryFact{}
+		if ok, err := m.Fact(FactMemory, &mem); !ok || err != nil || mem.Total != 1024 {
+			t.Fatalf("want memory total 1024 but get %v, %v, %v", ok, err, mem.Total)
+		}
+
+		var disks []DiskFact
+		if ok, err := m.Fact(FactDisks, &disks); !ok || err != nil || len(disks) != 1 || disks[0].Total != 2048 {
+			t.Fatalf("wrong disks %v, %v, %v", ok, err, disks)
+		}
+
+		if ok, _ := m.Fact(FactKernel, &KernelFact{}); ok {
+			t.Fatalf("kernel fact should not exist")
+		}
+
+		if _, err := m.Fact(FactMemory, mem); err == nil {
+			t.Fatalf("should return an error if obj is not a pointer")
+		}
+
+		if _, err := m.Fact(FactMemory, &disks); err == nil {
+			t.Fatalf("should return an error if fact type is wrong")
+		}
+	}
+}
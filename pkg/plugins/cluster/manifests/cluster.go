@@ -0,0 +1,378 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package manifests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	ar "k8s.io/api/admissionregistration/v1beta1"
+	appv1 "k8s.io/api/apps/v1"
+	asv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1beta12 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+)
+
+const (
+	// Type is the cluster type
+	Type = "manifests"
+)
+
+// Cluster is a cluster that read Resources from local manifest files instead of a real cluster
+// no node, component or machine information will be fetched
+type Cluster struct {
+	// Dir is the directory that contains manifest files, sub directories will be walked too
+	Dir string
+	// Namespace will be used for namespaced objects without a namespace
+	Namespace string
+
+	logger    logger.Logger
+	resources *cluster.Resources
+	progress  *plugins.Progress
+}
+
+// NewCluster return an new manifests Cluster
+func NewCluster(log logger.Logger, cli kubernetes.Interface, config *rest.Config) cluster.Cluster {
+	return &Cluster{
+		logger:    log,
+		resources: newResources(),
+	}
+}
+
+// Complete check and complete config items
+func (c *Cluster) Complete() error {
+	if c.Dir == "" {
+		return fmt.Errorf("manifests dir can not be empty")
+	}
+
+	if c.Namespace == "" {
+		c.Namespace = metav1.NamespaceDefault
+	}
+	return nil
+}
+
+// Init read all manifest files and convert them to Resources
+func (c *Cluster) Init(ctx context.Context, progress *plugins.Progress) error {
+	c.progress = progress
+	c.resources = newResources()
+
+	files, err := c.manifestFiles()
+	if err != nil {
+		return err
+	}
+
+	c.progress.CreateStep("init_manifests", "Loading manifests..", len(files)+1)
+	c.progress.SetCurStep("init_manifests")
+	for _, f := range files {
+		if err := c.loadFile(f); err != nil {
+			return err
+		}
+		c.progress.AddStepPercent("init_manifests", 1)
+	}
+
+	c.addTemplatePods()
+	c.progress.AddStepPercent("init_manifests", 1)
+	c.logger.Infof("Loading (%d) manifest files, (%d) Pods", len(files), len(c.resources.Pods.Items))
+	return nil
+}
+
+// manifestFiles return all yaml or json files under Dir
+func (c *Cluster) manifestFiles() ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(c.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "walk manifests dir %s failed", c.Dir)
+	}
+	return files, nil
+}
+
+func (c *Cluster) loadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "read manifest file %s failed", path)
+	}
+
+	reader := yaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	decode := scheme.Codecs.UniversalDeserializer().Decode
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "read manifest file %s failed", path)
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, gvk, err := decode(doc, nil, nil)
+		if err != nil {
+			c.logger.Infof("skip object in %s : %v", path, err)
+			continue
+		}
+
+		if err := c.addObject(obj); err != nil {
+			c.logger.Infof("skip %s in %s : %v", gvk.String(), path, err)
+		}
+	}
+}
+
+// addObject append obj to the right list of Resources
+// only the api versions used by Resources are supported
+func (c *Cluster) addObject(obj runtime.Object) error {
+	if list, ok := obj.(*corev1.List); ok {
+		decode := scheme.Codecs.UniversalDeserializer().Decode
+		for _, item := range list.Items {
+			o, _, err := decode(item.Raw, nil, nil)
+			if err != nil {
+				return err
+			}
+			if err := c.addObject(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if meta, ok := obj.(metav1.Object); ok {
+		c.completeMeta(obj, meta)
+	}
+
+	res := c.resources
+	switch o := obj.(type) {
+	case *appv1.Deployment:
+		res.Deployments.Items = append(res.Deployments.Items, *o)
+	case *appv1.DaemonSet:
+		res.DaemonSets.Items = append(res.DaemonSets.Items, *o)
+	case *appv1.StatefulSet:
+		res.StatefulSets.Items = append(res.StatefulSets.Items, *o)
+	case *appv1.ReplicaSet:
+		res.ReplicaSets.Items = append(res.ReplicaSets.Items, *o)
+	case *corev1.ReplicationController:
+		res.ReplicationControllers.Items = append(res.ReplicationControllers.Items, *o)
+	case *batchv1.Job:
+		res.Jobs.Items = append(res.Jobs.Items, *o)
+	case *v1beta12.CronJob:
+		res.CronJobs.Items = append(res.CronJobs.Items, *o)
+	case *corev1.Node:
+		res.Nodes.Items = append(res.Nodes.Items, *o)
+	case *corev1.PersistentVolume:
+		res.PersistentVolumes.Items = append(res.PersistentVolumes.Items, *o)
+	case *corev1.Pod:
+		res.Pods.Items = append(res.Pods.Items, *o)
+	case *corev1.PodTemplate:
+		res.PodTemplates.Items = append(res.PodTemplates.Items, *o)
+	case *corev1.PersistentVolumeClaim:
+		res.PersistentVolumeClaims.Items = append(res.PersistentVolumeClaims.Items, *o)
+	case *storagev1.StorageClass:
+		res.StorageClasses.Items = append(res.StorageClasses.Items, *o)
+	case *corev1.ConfigMap:
+		res.ConfigMaps.Items = append(res.ConfigMaps.Items, *o)
+	case *corev1.Service:
+		res.Services.Items = append(res.Services.Items, *o)
+	case *corev1.Endpoints:
+		res.Endpoints.Items = append(res.Endpoints.Items, *o)
+	case *discoveryv1beta1.EndpointSlice:
+		res.EndpointSlices.Items = append(res.EndpointSlices.Items, *o)
+	case *corev1.Secret:
+		res.Secrets.Items = append(res.Secrets.Items, *o)
+	case *corev1.ServiceAccount:
+		res.ServiceAccounts.Items = append(res.ServiceAccounts.Items, *o)
+	case *corev1.ResourceQuota:
+		res.ResourceQuotas.Items = append(res.ResourceQuotas.Items, *o)
+	case *corev1.LimitRange:
+		res.LimitRanges.Items = append(res.LimitRanges.Items, *o)
+	case *ar.MutatingWebhookConfiguration:
+		res.MutatingWebhookConfigurations.Items = append(res.MutatingWebhookConfigurations.Items, *o)
+	case *ar.ValidatingWebhookConfiguration:
+		res.ValidatingWebhookConfigurations.Items = append(res.ValidatingWebhookConfigurations.Items, *o)
+	case *corev1.Namespace:
+		res.Namespaces.Items = append(res.Namespaces.Items, *o)
+	case *asv1.HorizontalPodAutoscaler:
+		res.HPAs.Items = append(res.HPAs.Items, *o)
+	case *policyv1beta1.PodDisruptionBudget:
+		res.PodDisruptionBudgets.Items = append(res.PodDisruptionBudgets.Items, *o)
+	default:
+		return fmt.Errorf("unsupported object type %T", obj)
+	}
+	return nil
+}
+
+// completeMeta set default namespace and a stable UID,
+// objects from manifests have no UID, but diagnostics use UID to find owners
+func (c *Cluster) completeMeta(obj runtime.Object, meta metav1.Object) {
+	switch obj.(type) {
+	case *corev1.Node, *corev1.PersistentVolume, *corev1.Namespace, *storagev1.StorageClass,
+		*ar.MutatingWebhookConfiguration, *ar.ValidatingWebhookConfiguration:
+	default:
+		if meta.GetNamespace() == "" {
+			meta.SetNamespace(c.Namespace)
+		}
+	}
+
+	if meta.GetUID() == "" {
+		meta.SetUID(objUID(fmt.Sprintf("%T", obj), meta.GetNamespace(), meta.GetName()))
+	}
+}
+
+// addTemplatePods create one Pod for every workload from it's pod template
+// so that diagnostics base on Pods and their root owners can work without a cluster
+func (c *Cluster) addTemplatePods() {
+	res := c.resources
+	for _, d := range res.Deployments.Items {
+		c.addTemplatePod("Deployment", &d.ObjectMeta, &d.Spec.Template)
+	}
+
+	for _, ds := range res.DaemonSets.Items {
+		c.addTemplatePod("DaemonSet", &ds.ObjectMeta, &ds.Spec.Template)
+	}
+
+	for _, sts := range res.StatefulSets.Items {
+		c.addTemplatePod("StatefulSet", &sts.ObjectMeta, &sts.Spec.Template)
+	}
+
+	for _, rs := range res.ReplicaSets.Items {
+		c.addTemplatePod("ReplicaSet", &rs.ObjectMeta, &rs.Spec.Template)
+	}
+
+	for _, rc := range res.ReplicationControllers.Items {
+		if rc.Spec.Template != nil {
+			c.addTemplatePod("ReplicationController", &rc.ObjectMeta, rc.Spec.Template)
+		}
+	}
+
+	for _, job := range res.Jobs.Items {
+		c.addTemplatePod("Job", &job.ObjectMeta, &job.Spec.Template)
+	}
+
+	for _, cj := range res.CronJobs.Items {
+		c.addTemplatePod("CronJob", &cj.ObjectMeta, &cj.Spec.JobTemplate.Spec.Template)
+	}
+}
+
+func (c *Cluster) addTemplatePod(kind string, owner *metav1.ObjectMeta, tpl *corev1.PodTemplateSpec) {
+	isController := true
+	pod := corev1.Pod{}
+	pod.Name = fmt.Sprintf("%s-template", owner.Name)
+	pod.Namespace = owner.Namespace
+	pod.Labels = tpl.Labels
+	pod.Annotations = tpl.Annotations
+	pod.UID = objUID("Pod", pod.Namespace, pod.Name+"-"+kind)
+	pod.OwnerReferences = []metav1.OwnerReference{
+		{
+			Kind:       kind,
+			Name:       owner.Name,
+			UID:        owner.UID,
+			Controller: &isController,
+		},
+	}
+	pod.Spec = tpl.Spec
+	c.resources.Pods.Items = append(c.resources.Pods.Items, pod)
+}
+
+func objUID(kind, namespace, name string) types.UID {
+	return types.UID(fmt.Sprintf("%s/%s/%s", kind, namespace, name))
+}
+
+// Resources return fetched resources
+func (c *Cluster) Resources() *cluster.Resources {
+	return c.resources
+}
+
+// CloudType return the cloud type of Cluster
+func (c *Cluster) CloudType() string {
+	return Type
+}
+
+// Finish will be called once diagnostic done
+func (c *Cluster) Finish() error {
+	return nil
+}
+
+// newResources return Resources with all lists initialized,
+// diagnostics expect fetched lists are never nil
+func newResources() *cluster.Resources {
+	res := cluster.NewResources()
+	res.Deployments = &appv1.DeploymentList{}
+	res.DaemonSets = &appv1.DaemonSetList{}
+	res.StatefulSets = &appv1.StatefulSetList{}
+	res.ReplicaSets = &appv1.ReplicaSetList{}
+	res.ReplicationControllers = &corev1.ReplicationControllerList{}
+	res.Jobs = &batchv1.JobList{}
+	res.CronJobs = &v1beta12.CronJobList{}
+	res.Nodes = &corev1.NodeList{}
+	res.PersistentVolumes = &corev1.PersistentVolumeList{}
+	res.ComponentStatuses = &corev1.ComponentStatusList{}
+	res.Pods = &corev1.PodList{}
+	res.PodTemplates = &corev1.PodTemplateList{}
+	res.PersistentVolumeClaims = &corev1.PersistentVolumeClaimList{}
+	res.StorageClasses = &storagev1.StorageClassList{}
+	res.ConfigMaps = &corev1.ConfigMapList{}
+	res.Services = &corev1.ServiceList{}
+	res.Endpoints = &corev1.EndpointsList{}
+	res.EndpointSlices = &discoveryv1beta1.EndpointSliceList{}
+	res.Secrets = &corev1.SecretList{}
+	res.ServiceAccounts = &corev1.ServiceAccountList{}
+	res.ResourceQuotas = &corev1.ResourceQuotaList{}
+	res.LimitRanges = &corev1.LimitRangeList{}
+	res.MutatingWebhookConfigurations = &ar.MutatingWebhookConfigurationList{}
+	res.ValidatingWebhookConfigurations = &ar.ValidatingWebhookConfigurationList{}
+	res.Namespaces = &corev1.NamespaceList{}
+	res.HPAs = &asv1.HorizontalPodAutoscalerList{}
+	res.PodDisruptionBudgets = &policyv1beta1.PodDisruptionBudgetList{}
+	return res
+}
@@ -0,0 +1,120 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package manifests
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+)
+
+var testManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: test
+spec:
+  selector:
+    app: web
+---
+apiVersion: example.com/v1
+kind: Unknown
+metadata:
+  name: unknown
+`
+
+func TestCluster_Init(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifests")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "web.yaml"), []byte(testManifests), 0644); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# readme"), 0644); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	cls := NewCluster(logger.NewLogger(), nil, nil).(*Cluster)
+	if err := cls.Complete(); err == nil {
+		t.Fatalf("should return an error if Dir is empty")
+	}
+
+	cls.Dir = dir
+	if err := cls.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := cls.Init(context.Background(), plugins.NewProgress()); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	res := cls.Resources()
+	if len(res.Deployments.Items) != 1 {
+		t.Fatalf("want 1 Deployments but get %d", len(res.Deployments.Items))
+	}
+
+	deploy := res.Deployments.Items[0]
+	if deploy.Namespace != "default" || deploy.UID == "" {
+		t.Fatalf("namespace and uid of Deployment should be completed")
+	}
+
+	if len(res.Services.Items) != 1 || res.Services.Items[0].Namespace != "test" {
+		t.Fatalf("want 1 Services in namespace test")
+	}
+
+	if len(res.Pods.Items) != 1 {
+		t.Fatalf("want 1 template Pods but get %d", len(res.Pods.Items))
+	}
+
+	pod := res.Pods.Items[0]
+	if len(pod.OwnerReferences) != 1 || pod.OwnerReferences[0].UID != deploy.UID {
+		t.Fatalf("template Pod should be owned by Deployment")
+	}
+
+	if pod.Labels["app"] != "web" {
+		t.Fatalf("template Pod should have labels of pod template")
+	}
+}
@@ -18,7 +18,13 @@
 package cluster
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
 	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
 
 	ar "k8s.io/api/admissionregistration/v1beta1"
 	appv1 "k8s.io/api/apps/v1"
@@ -26,7 +32,9 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1beta12 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
 )
 
 type IPTablesChainPolicy string
@@ -51,10 +59,75 @@ type NATTable struct {
 	PostRoutingPolicy IPTablesChainPolicy
 }
 
+// IPTablesChain is a chain of iptables
+type IPTablesChain struct {
+	// Policy is the policy of built-in chain, e.g. "ACCEPT", it is "-" for user defined chains
+	Policy string
+	// Count is the number of rules in this chain
+	Count int
+	// Rules is the rules of built-in chain without "-A <chain>" prefix,
+	// rules of user defined chains are not kept to save memory
+	Rules []string
+}
+
+// IPTablesTable is a table of iptables
+type IPTablesTable struct {
+	// Chains is all chains of the table, the key is chain name
+	Chains map[string]IPTablesChain
+}
+
 // IPTablesInfo is the iptables information of a node
 type IPTablesInfo struct {
 	Filter FilterTable
 	NAT    NATTable
+	// Tables is all tables from "iptables-save", the key is table name, e.g. "filter", "nat"
+	Tables map[string]IPTablesTable
+}
+
+// Chain return the target chain, false will be returned if chain not found
+func (i *IPTablesInfo) Chain(table, chain string) (IPTablesChain, bool) {
+	c, exist := i.Tables[table].Chains[chain]
+	return c, exist
+}
+
+// ReadyEndpoints return the number of ready endpoints of Service
+// EndpointSlices are preferred because Endpoints may be truncated for huge Services
+func (r *Resources) ReadyEndpoints(svc *corev1.Service) int {
+	count := 0
+	sliceFound := false
+	if r.EndpointSlices != nil {
+		for _, s := range r.EndpointSlices.Items {
+			if s.Namespace != svc.Namespace || s.Labels[discoveryv1beta1.LabelServiceName] != svc.Name {
+				continue
+			}
+
+			sliceFound = true
+			for _, e := range s.Endpoints {
+				// nil means unknown state and should be interpreted as ready
+				if e.Conditions.Ready == nil || *e.Conditions.Ready || svc.Spec.PublishNotReadyAddresses {
+					count++
+				}
+			}
+		}
+	}
+
+	if sliceFound || r.Endpoints == nil {
+		return count
+	}
+
+	for _, e := range r.Endpoints.Items {
+		if e.Namespace != svc.Namespace || e.Name != svc.Name {
+			continue
+		}
+
+		for _, sub := range e.Subsets {
+			count += len(sub.Addresses)
+			if svc.Spec.PublishNotReadyAddresses {
+				count += len(sub.NotReadyAddresses)
+			}
+		}
+	}
+	return count
 }
 
 // Machine is the contains low level system information of a node
@@ -62,10 +135,25 @@ type Machine struct {
 	// SysCtl is the OS system param from command "sysctl -a"
 	SysCtl   map[string]string
 	IPTables IPTablesInfo
+	// Facts is the typed facts collected by machine collectors, the key is the name of collector
+	// use Fact to read a fact
+	Facts map[string]interface{}
+	// FactErrors is the error of collectors that failed, the key is the name of collector
+	FactErrors map[string]string
 	// Error is not nil if any error appear
 	Error error
 }
 
+// MachineGroup is a group of nodes whose machines are collected by sampling
+type MachineGroup struct {
+	// Name is the name of group, e.g. "node.kubernetes.io/instance-type=S5.LARGE8"
+	Name string
+	// Nodes is the names of all nodes in this group
+	Nodes []string
+	// Sampled is the names of nodes whose machine are collected, they are keys of Resources.Machines
+	Sampled []string
+}
+
 // ComponentName it the type of a component like
 type ComponentName string
 
@@ -97,6 +185,22 @@ type Component struct {
 	Error error
 	// Pod is not nil if this Component run as pod
 	Pod *corev1.Pod
+	// Certificates is the certificates in the files referenced by Args, they are read from Node
+	Certificates []Certificate
+}
+
+// Certificate is a certificate in a file used by a component
+type Certificate struct {
+	// Arg is the argument that references the file, e.g. "tls-cert-file"
+	Arg string
+	// Path is the file path on node
+	Path string
+	// Subject is the common name of certificate
+	Subject string
+	// NotAfter is the expiration time of certificate
+	NotAfter time.Time
+	// Error is not empty if the file can not be read or parsed
+	Error string
 }
 
 // Resources containers all cluster information from k8s , machine or process
@@ -114,8 +218,11 @@ type Resources struct {
 	Pods                            *corev1.PodList
 	PodTemplates                    *corev1.PodTemplateList
 	PersistentVolumeClaims          *corev1.PersistentVolumeClaimList
+	StorageClasses                  *storagev1.StorageClassList
 	ConfigMaps                      *corev1.ConfigMapList
 	Services                        *corev1.ServiceList
+	Endpoints                       *corev1.EndpointsList
+	EndpointSlices                  *discoveryv1beta1.EndpointSliceList
 	Secrets                         *corev1.SecretList
 	ServiceAccounts                 *corev1.ServiceAccountList
 	ResourceQuotas                  *corev1.ResourceQuotaList
@@ -128,6 +235,9 @@ type Resources struct {
 
 	CoreComponents map[string][]Component
 	Machines       map[string]Machine
+	// MachineGroups is not empty if machines are collected by sampling
+	// diagnostics should report machine results per group if it is not empty
+	MachineGroups []MachineGroup
 	// Extra is a CloudType special resources
 	Extra interface{}
 }
@@ -140,6 +250,106 @@ func NewResources() *Resources {
 	}
 }
 
+// CheckResourcesFields return an error if any name is not a field of Resources
+func CheckResourcesFields(names []string) error {
+	typ := reflect.TypeOf(Resources{})
+	for _, name := range names {
+		if _, exist := typ.FieldByName(name); !exist {
+			return fmt.Errorf("unknown resources %s", name)
+		}
+	}
+	return nil
+}
+
+// Select return a copy of Resources that only contains fields with target names
+// all fields will be copied if names is empty
+func (r *Resources) Select(names []string) *Resources {
+	if len(names) == 0 {
+		newRes := *r
+		return &newRes
+	}
+
+	newRes := &Resources{}
+	src := reflect.ValueOf(r).Elem()
+	dst := reflect.ValueOf(newRes).Elem()
+	for _, name := range names {
+		dst.FieldByName(name).Set(src.FieldByName(name))
+	}
+	return newRes
+}
+
+// MarshalJSON encode Error of Machine as a string
+func (m Machine) MarshalJSON() ([]byte, error) {
+	type machine Machine
+	return json.Marshal(&struct {
+		machine
+		Error string `json:",omitempty"`
+	}{
+		machine: machine(m),
+		Error:   errorString(m.Error),
+	})
+}
+
+// UnmarshalJSON decode Error of Machine from a string
+func (m *Machine) UnmarshalJSON(data []byte) error {
+	type machine Machine
+	obj := &struct {
+		*machine
+		Error string
+	}{
+		machine: (*machine)(m),
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return err
+	}
+	m.Error = stringError(obj.Error)
+	return nil
+}
+
+// MarshalJSON encode Error of Component as a string
+func (c Component) MarshalJSON() ([]byte, error) {
+	type component Component
+	return json.Marshal(&struct {
+		component
+		Error string `json:",omitempty"`
+	}{
+		component: component(c),
+		Error:     errorString(c.Error),
+	})
+}
+
+// UnmarshalJSON decode Error of Component from a string
+func (c *Component) UnmarshalJSON(data []byte) error {
+	type component Component
+	obj := &struct {
+		*component
+		Error string
+	}{
+		component: (*component)(c),
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return err
+	}
+	c.Error = stringError(obj.Error)
+	return nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func stringError(s string) error {
+	if s == "" {
+		return nil
+	}
+	return errors.New(s)
+}
+
 // ResourcesFilterItem shows what workloads will be filtered out
 type ResourcesFilterItem struct {
 	// Namespace,Kind,Name support regular expressions
@@ -19,8 +19,13 @@
 package cluster
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestResourcesFilter_Compile(t *testing.T) {
@@ -104,3 +109,116 @@ func TestResourcesFilter_Filtered(t *testing.T) {
 		})
 	}
 }
+
+func TestResources_JSON(t *testing.T) {
+	res := NewResources()
+	res.Machines["node1"] = Machine{
+		SysCtl: map[string]string{"a": "b"},
+		Error:  fmt.Errorf("sysctl failed"),
+	}
+	res.Machines["node2"] = Machine{
+		SysCtl: map[string]string{"c": "d"},
+	}
+	res.CoreComponents["etcd"] = []Component{
+		{
+			Name:  "etcd",
+			Error: fmt.Errorf("not found"),
+		},
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	newRes := NewResources()
+	if err := json.Unmarshal(data, newRes); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if fmt.Sprint(newRes.Machines["node1"].Error) != "sysctl failed" ||
+		newRes.Machines["node1"].SysCtl["a"] != "b" {
+		t.Fatalf("wrong machine node1 %+v", newRes.Machines["node1"])
+	}
+
+	if newRes.Machines["node2"].Error != nil {
+		t.Fatalf("error of node2 should be nil")
+	}
+
+	if fmt.Sprint(newRes.CoreComponents["etcd"][0].Error) != "not found" {
+		t.Fatalf("wrong component %+v", newRes.CoreComponents["etcd"][0])
+	}
+}
+
+func TestResources_Select(t *testing.T) {
+	res := NewResources()
+	if err := CheckResourcesFields([]string{"Pods", "Unknown"}); err == nil {
+		t.Fatalf("should return an error")
+	}
+
+	if err := CheckResourcesFields([]string{"Pods", "Machines"}); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	newRes := res.Select([]string{"Machines"})
+	if newRes.Machines == nil || newRes.CoreComponents != nil {
+		t.Fatalf("only Machines should be selected")
+	}
+
+	newRes = res.Select(nil)
+	if newRes.Machines == nil || newRes.CoreComponents == nil {
+		t.Fatalf("all fields should be selected")
+	}
+}
+
+func TestResources_ReadyEndpoints(t *testing.T) {
+	ready, notReady := true, false
+	r := NewResources()
+	r.EndpointSlices = &discoveryv1beta1.EndpointSliceList{Items: []discoveryv1beta1.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sliced-abc",
+				Labels: map[string]string{discoveryv1beta1.LabelServiceName: "sliced"}},
+			Endpoints: []discoveryv1beta1.Endpoint{
+				{Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready}},
+				{Conditions: discoveryv1beta1.EndpointConditions{Ready: &notReady}},
+				{},
+			},
+		},
+	}}
+	r.Endpoints = &corev1.EndpointsList{Items: []corev1.Endpoints{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sliced"},
+			Subsets: []corev1.EndpointSubset{
+				{Addresses: make([]corev1.EndpointAddress, 5)},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "plain"},
+			Subsets: []corev1.EndpointSubset{
+				{Addresses: make([]corev1.EndpointAddress, 1), NotReadyAddresses: make([]corev1.EndpointAddress, 1)},
+			},
+		},
+	}}
+
+	var cases = []struct {
+		name    string
+		publish bool
+		want    int
+	}{
+		{name: "sliced", want: 2},
+		{name: "sliced", publish: true, want: 3},
+		{name: "plain", want: 1},
+		{name: "plain", publish: true, want: 2},
+		{name: "missing", want: 0},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%s-%v", cs.name, cs.publish), func(t *testing.T) {
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: cs.name}}
+			svc.Spec.PublishNotReadyAddresses = cs.publish
+			if got := r.ReadyEndpoints(svc); got != cs.want {
+				t.Fatalf("want %d ready endpoints but get %d", cs.want, got)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/coordinate"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
 )
 
@@ -38,6 +39,7 @@ type Coordinator struct {
 	cls         cluster.Cluster
 	logger      logger.Logger
 	diagnostics []diagnose.Diagnostic
+	evaluators  []evaluate.Evaluator
 	exporters   []export.Exporter
 	progress    *plugins.Progress
 	store       store.Store
@@ -63,13 +65,19 @@ func (c *Coordinator) AddDiagnostic(dia diagnose.Diagnostic) {
 	c.diagnostics = append(c.diagnostics, dia)
 }
 
+// AddEvaluator add a Evaluator to Coordinator
+func (c *Coordinator) AddEvaluator(evaluator evaluate.Evaluator) {
+	c.evaluators = append(c.evaluators, evaluator)
+}
+
 // AddExporter add a Exporter to Coordinator
 func (c *Coordinator) AddExporter(exporter export.Exporter) {
 	c.exporters = append(c.exporters, exporter)
 }
 
 // Run will do all diagnostics, evaluations, then export it by exporters
-func (c *Coordinator) Run(ctx context.Context) error {
+// Cluster.Finish is always called even if Run is failed or ctx is canceled
+func (c *Coordinator) Run(ctx context.Context) (err error) {
 	c.progress = plugins.NewProgress()
 	c.progress.AddProgressUpdatedWatcher(func(p *plugins.Progress) {
 		c.progress = p.Clone()
@@ -77,16 +85,20 @@ func (c *Coordinator) Run(ctx context.Context) error {
 
 	c.progress.CreateStep("diagnostic", "Diagnosing...", len(c.diagnostics))
 
+	defer func() {
+		if fErr := c.cls.Finish(); fErr != nil && err == nil {
+			err = errors.Wrapf(fErr, "finish cluster failed")
+		}
+	}()
+
 	if err := c.cls.Init(ctx, c.progress); err != nil {
 		return errors.Wrap(err, "init cluster failed")
 	}
 
 	c.logger.Infof("Start Diagnosing......")
 	c.progress.SetCurStep("diagnostic")
-	c.diagnostic(ctx)
-
-	if err := c.cls.Finish(); err != nil {
-		return errors.Wrapf(err, "finish cluster failed")
+	if err := c.diagnostic(ctx); err != nil {
+		return errors.Wrap(err, "diagnostic canceled")
 	}
 
 	c.progress.Done()
@@ -100,9 +112,13 @@ func (c *Coordinator) Progress() *plugins.Progress {
 	return c.progress
 }
 
-func (c *Coordinator) diagnostic(ctx context.Context) {
+func (c *Coordinator) diagnostic(ctx context.Context) error {
 	result := export.NewAllResult()
 	for _, dia := range c.diagnostics {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
 		resultChan, err := dia.StartDiagnose(ctx, diagnose.StartDiagnoseParam{
 			CloudType: c.cls.CloudType(),
 			Resources: c.cls.Resources(),
@@ -111,7 +127,7 @@ func (c *Coordinator) diagnostic(ctx context.Context) {
 		if err != nil {
 			c.logger.Errorf("start diagnostic type[%s] name[%s] failed : %v",
 				dia.Meta().Type, dia.Meta().Name, err)
-			return
+			return nil
 		}
 
 		resultItem := export.NewDiagnosticResultItem(dia)
@@ -128,8 +144,28 @@ func (c *Coordinator) diagnostic(ctx context.Context) {
 		c.progress.AddStepPercent("diagnostic", 1)
 	}
 
+	c.evaluate(ctx, result)
 	result.EndTime = time.Now()
 	c.export(ctx, result)
+	return nil
+}
+
+func (c *Coordinator) evaluate(ctx context.Context, r *export.AllResult) {
+	if len(c.evaluators) == 0 {
+		return
+	}
+
+	for _, e := range c.evaluators {
+		if err := e.Evaluate(ctx, evaluate.EvaluateParam{
+			CloudType: c.cls.CloudType(),
+			Resources: c.cls.Resources(),
+			Result:    r,
+		}); err != nil {
+			c.logger.Errorf("evaluator type[%s] name[%s] failed : %v",
+				e.Meta().Type, e.Meta().Name, err)
+		}
+	}
+	r.RefreshStatistics()
 }
 
 func (c *Coordinator) export(ctx context.Context, r *export.AllResult) {
@@ -19,6 +19,7 @@ package basic
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	logger2 "tkestack.io/kube-jarvis/pkg/logger"
@@ -26,6 +27,7 @@ import (
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster/fake"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/other/example"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
 	"tkestack.io/kube-jarvis/pkg/plugins/export/stdout"
 	"tkestack.io/kube-jarvis/pkg/store"
@@ -46,3 +48,135 @@ func TestNewDefault(t *testing.T) {
 	d.AddExporter(stdout.NewExporter(&export.MetaData{}))
 	_ = d.Run(ctx)
 }
+
+type fakeEvaluator struct {
+	*evaluate.MetaData
+}
+
+func (f *fakeEvaluator) Complete() error {
+	return nil
+}
+
+func (f *fakeEvaluator) Evaluate(ctx context.Context, param evaluate.EvaluateParam) error {
+	for _, d := range param.Result.Diagnostics {
+		for _, r := range d.Results {
+			r.Level = diagnose.HealthyLevelSerious
+		}
+	}
+	return nil
+}
+
+type fakeExporter struct {
+	*export.MetaData
+	result *export.AllResult
+}
+
+func (f *fakeExporter) Complete() error {
+	return nil
+}
+
+func (f *fakeExporter) Export(ctx context.Context, result *export.AllResult) error {
+	f.result = result
+	return nil
+}
+
+func TestCoordinator_AddEvaluator(t *testing.T) {
+	logger := logger2.NewLogger()
+	ctx := context.Background()
+	d := NewCoordinator(logger, fake.NewCluster(), store.GetStore("mem", ""))
+	_ = d.Complete()
+
+	d.AddDiagnostic(example.NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+		},
+	}))
+	d.AddEvaluator(&fakeEvaluator{MetaData: &evaluate.MetaData{}})
+	e := &fakeExporter{MetaData: &export.MetaData{}}
+	d.AddExporter(e)
+	if err := d.Run(ctx); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if e.result == nil {
+		t.Fatalf("exporter should receive result")
+	}
+
+	total := 0
+	for _, num := range e.result.Statistics {
+		total += num
+	}
+
+	if total == 0 || e.result.Statistics[diagnose.HealthyLevelSerious] != total {
+		t.Fatalf("statistics should be refreshed after evaluation: %+v", e.result.Statistics)
+	}
+}
+
+type finishCluster struct {
+	*fake.Cluster
+	initErr  error
+	finished bool
+}
+
+func (f *finishCluster) Init(ctx context.Context, progress *plugins.Progress) error {
+	return f.initErr
+}
+
+func (f *finishCluster) Finish() error {
+	f.finished = true
+	return nil
+}
+
+func TestCoordinator_RunAlwaysFinish(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var cases = []struct {
+		name     string
+		ctx      context.Context
+		initErr  error
+		exported bool
+	}{
+		{
+			name:     "success",
+			ctx:      context.Background(),
+			exported: true,
+		},
+		{
+			name:    "init failed",
+			ctx:     context.Background(),
+			initErr: fmt.Errorf("init failed"),
+		},
+		{
+			name: "canceled",
+			ctx:  canceled,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			cls := &finishCluster{Cluster: fake.NewCluster(), initErr: cs.initErr}
+			d := NewCoordinator(logger2.NewLogger(), cls, store.GetStore("mem", ""))
+			d.AddDiagnostic(example.NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+				},
+			}))
+			e := &fakeExporter{MetaData: &export.MetaData{}}
+			d.AddExporter(e)
+
+			err := d.Run(cs.ctx)
+			if (err == nil) != cs.exported {
+				t.Fatalf("want exported %v but get error %v", cs.exported, err)
+			}
+
+			if (e.result != nil) != cs.exported {
+				t.Fatalf("want exported %v", cs.exported)
+			}
+
+			if !cls.finished {
+				t.Fatalf("cluster should be finished")
+			}
+		})
+	}
+}
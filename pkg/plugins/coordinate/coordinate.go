@@ -26,6 +26,7 @@ import (
 	"tkestack.io/kube-jarvis/pkg/store"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
 )
 
@@ -35,6 +36,8 @@ type Coordinator interface {
 	Complete() error
 	// AddDiagnostic add a diagnostic to Coordinator
 	AddDiagnostic(dia diagnose.Diagnostic)
+	// AddEvaluator add a Evaluator to Coordinator
+	AddEvaluator(evaluator evaluate.Evaluator)
 	// AddExporter add a Exporter to Coordinator
 	AddExporter(exporter export.Exporter)
 	// Run will do all diagnostics, evaluations, then export it by exporters
@@ -4,6 +4,7 @@ import (
 	"context"
 	"tkestack.io/kube-jarvis/pkg/plugins"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
 )
 
@@ -21,6 +22,11 @@ func (f *FakeCoordinator) AddDiagnostic(dia diagnose.Diagnostic) {
 
 }
 
+// AddEvaluator add a Evaluator to Coordinator
+func (f *FakeCoordinator) AddEvaluator(evaluator evaluate.Evaluator) {
+
+}
+
 // AddExporter add a Exporter to Coordinator
 func (f *FakeCoordinator) AddExporter(exporter export.Exporter) {
 
@@ -22,15 +22,25 @@ import (
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/apiserver"
 	controller_manager "tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/controller-manager"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/etcd"
+	argsrule "tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/rule"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/scheduler"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/capacity"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/certs"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/cis"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/components"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/ha"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/iptables"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/status"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/sys"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/other/example"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/other/external"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/other/remote"
 	hpaip "tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/hpa/ip"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/quota"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/rule"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/service"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/storage"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/webhook"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/affinity"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/batch"
 	workloadha "tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/ha"
@@ -59,6 +69,16 @@ func addMasterDiagnostics() {
 		Creator:   components.NewDiagnostic,
 		Catalogue: diagnose.CatalogueMaster,
 	})
+
+	diagnose.Add(cis.DiagnosticType, diagnose.Factory{
+		Creator:   cis.NewDiagnostic,
+		Catalogue: diagnose.CatalogueMaster,
+	})
+
+	diagnose.Add(certs.DiagnosticType, diagnose.Factory{
+		Creator:   certs.NewDiagnostic,
+		Catalogue: diagnose.CatalogueMaster,
+	})
 }
 
 func addResourceDiagnostics() {
@@ -101,6 +121,31 @@ func addResourceDiagnostics() {
 		Creator:   workloadStatus.NewDiagnostic,
 		Catalogue: diagnose.CatalogueResource,
 	})
+
+	diagnose.Add(rule.DiagnosticType, diagnose.Factory{
+		Creator:   rule.NewDiagnostic,
+		Catalogue: diagnose.CatalogueResource,
+	})
+
+	diagnose.Add(webhook.DiagnosticType, diagnose.Factory{
+		Creator:   webhook.NewDiagnostic,
+		Catalogue: diagnose.CatalogueResource,
+	})
+
+	diagnose.Add(quota.DiagnosticType, diagnose.Factory{
+		Creator:   quota.NewDiagnostic,
+		Catalogue: diagnose.CatalogueResource,
+	})
+
+	diagnose.Add(service.DiagnosticType, diagnose.Factory{
+		Creator:   service.NewDiagnostic,
+		Catalogue: diagnose.CatalogueResource,
+	})
+
+	diagnose.Add(storage.DiagnosticType, diagnose.Factory{
+		Creator:   storage.NewDiagnostic,
+		Catalogue: diagnose.CatalogueResource,
+	})
 }
 
 func addOtherDiagnostics() {
@@ -108,6 +153,18 @@ func addOtherDiagnostics() {
 		Creator:   example.NewDiagnostic,
 		Catalogue: diagnose.CatalogueOther,
 	})
+
+	diagnose.Add(external.DiagnosticType, diagnose.Factory{
+		Creator:    external.NewDiagnostic,
+		Catalogue:  diagnose.CatalogueOther,
+		NeedConfig: true,
+	})
+
+	diagnose.Add(remote.DiagnosticType, diagnose.Factory{
+		Creator:    remote.NewDiagnostic,
+		Catalogue:  diagnose.CatalogueOther,
+		NeedConfig: true,
+	})
 }
 
 func addNodeDiagnostics() {
@@ -149,4 +206,9 @@ func addMasterArgDiagnostics() {
 		Creator:   etcd.NewDiagnostic,
 		Catalogue: diagnose.CatalogueMaster,
 	})
+	diagnose.Add(argsrule.DiagnosticType, diagnose.Factory{
+		Creator:    argsrule.NewDiagnostic,
+		Catalogue:  diagnose.CatalogueMaster,
+		NeedConfig: true,
+	})
 }
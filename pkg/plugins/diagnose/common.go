@@ -22,6 +22,7 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/translate"
 )
 
@@ -31,8 +32,8 @@ func CommonDeafer(c chan *Result) {
 		c <- &Result{
 			Level:   HealthyLevelFailed,
 			ObjName: "*",
-			Title:   "Failed",
-			Desc:    translate.Message(fmt.Sprintf("%v", err)),
+			Title:   translate.Literal("Failed"),
+			Desc:    translate.Literal(fmt.Sprintf("%v", err)),
 		}
 	}
 }
@@ -55,3 +56,62 @@ func GetRootOwner(obj MetaObject, uid2obj map[types.UID]MetaObject) MetaObject {
 	}
 	return obj
 }
+
+// kindGroups is the API group of kinds that built-in diagnostics care about
+var kindGroups = map[string]string{
+	"Deployment":              "apps",
+	"DaemonSet":               "apps",
+	"StatefulSet":             "apps",
+	"ReplicaSet":              "apps",
+	"Job":                     "batch",
+	"CronJob":                 "batch",
+	"HorizontalPodAutoscaler": "autoscaling",
+	"PodDisruptionBudget":     "policy",
+}
+
+// NewObjectRef return a ObjectRef of a k8s object
+// Kind of obj must be set, Group will be guessed from Kind if it is empty
+func NewObjectRef(obj MetaObject) *ObjectRef {
+	gvk := obj.GroupVersionKind()
+	group := gvk.Group
+	if group == "" {
+		group = kindGroups[gvk.Kind]
+	}
+
+	return &ObjectRef{
+		Kind:      gvk.Kind,
+		Group:     group,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       obj.GetUID(),
+	}
+}
+
+// NewNodeRef return a ObjectRef of a Node
+func NewNodeRef(name string) *ObjectRef {
+	return &ObjectRef{
+		Kind: "Node",
+		Name: name,
+		Node: name,
+	}
+}
+
+// NewComponentRef return a ObjectRef of a cluster Component
+// the ObjectRef refer to the Pod of Component if it run as pod
+func NewComponentRef(comp *cluster.Component) *ObjectRef {
+	if comp.Pod != nil {
+		return &ObjectRef{
+			Kind:      "Pod",
+			Namespace: comp.Pod.Namespace,
+			Name:      comp.Pod.Name,
+			UID:       comp.Pod.UID,
+			Node:      comp.Node,
+		}
+	}
+
+	return &ObjectRef{
+		Kind: "Component",
+		Name: comp.Name,
+		Node: comp.Node,
+	}
+}
@@ -0,0 +1,99 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package diagnose
+
+import (
+	"fmt"
+	"testing"
+
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+)
+
+func TestNewObjectRef(t *testing.T) {
+	deploy := &appv1.Deployment{}
+	deploy.Kind = "Deployment"
+	deploy.Namespace = "default"
+	deploy.Name = "deploy1"
+	deploy.UID = "uid1"
+
+	pod := &v1.Pod{}
+	pod.Kind = "Pod"
+	pod.Namespace = "kube-system"
+	pod.Name = "pod1"
+
+	var cases = []struct {
+		obj    MetaObject
+		expect ObjectRef
+	}{
+		{
+			obj: deploy,
+			expect: ObjectRef{
+				Kind:      "Deployment",
+				Group:     "apps",
+				Namespace: "default",
+				Name:      "deploy1",
+				UID:       "uid1",
+			},
+		},
+		{
+			obj: pod,
+			expect: ObjectRef{
+				Kind:      "Pod",
+				Namespace: "kube-system",
+				Name:      "pod1",
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs.expect), func(t *testing.T) {
+			ref := NewObjectRef(cs.obj)
+			if *ref != cs.expect {
+				t.Fatalf("want %+v but get %+v", cs.expect, *ref)
+			}
+		})
+	}
+}
+
+func TestNewComponentRef(t *testing.T) {
+	comp := &cluster.Component{
+		Name: "kube-apiserver-node1",
+		Node: "node1",
+	}
+
+	ref := NewComponentRef(comp)
+	if ref.Kind != "Component" || ref.Name != comp.Name || ref.Node != "node1" {
+		t.Fatalf("wrong ref %+v", *ref)
+	}
+
+	comp.Pod = &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kube-system",
+			Name:      "kube-apiserver-node1",
+			UID:       "uid1",
+		},
+	}
+
+	ref = NewComponentRef(comp)
+	if ref.Kind != "Pod" || ref.Namespace != "kube-system" || ref.UID != "uid1" || ref.Node != "node1" {
+		t.Fatalf("wrong ref %+v", *ref)
+	}
+}
@@ -19,6 +19,8 @@ package diagnose
 
 import (
 	"context"
+
+	"k8s.io/apimachinery/pkg/types"
 	"tkestack.io/kube-jarvis/pkg/plugins"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/translate"
@@ -91,12 +93,32 @@ func (m *MetaData) Meta() MetaData {
 	return *m
 }
 
+// ObjectRef is a typed reference to the diagnosed object
+type ObjectRef struct {
+	// Kind is the kind of object, e.g. "Deployment", "Node"
+	Kind string
+	// Group is the API group of object, it is empty for core group
+	Group string
+	// Namespace is the namespace of object, it is empty for cluster scoped objects
+	Namespace string
+	// Name is the name of object
+	Name string
+	// UID is the uid of object, it may be empty if object is not a k8s resource
+	UID types.UID
+	// Node is the node that object located at, if any
+	Node string
+}
+
 // Result is a diagnostic result item
 type Result struct {
 	// Level is the healthy status
 	Level HealthyLevel
 	// ObjName is the name of diagnosed object
+	// it is a human readable name, use Obj to match objects
 	ObjName string
+	// Obj is the typed reference to diagnosed object
+	// it is nil if the Result is not about a certain object
+	Obj *ObjectRef
 	// ObjInfo is the core information of Obj
 	ObjInfo map[string]interface{}
 	// Title is the short description of Result,that is, the title of Result
@@ -105,6 +127,8 @@ type Result struct {
 	Desc translate.Message
 	// Proposal is the full description that show how solve the healthy problem
 	Proposal translate.Message
+	// Annotations are extra descriptions added by evaluators
+	Annotations []translate.Message
 }
 
 // StartDiagnoseParam contains all items that StartDiagnose need
@@ -135,6 +159,9 @@ type Factory struct {
 	SupportedClouds []string
 	// Catalogue is the catalogue type of the Diagnostic
 	Catalogue Catalogue
+	// NeedConfig is true if the Diagnostic can not run without config
+	// it will not be used if no diagnostics are specified in config file
+	NeedConfig bool
 }
 
 // Factories store all registered Diagnostic Creator
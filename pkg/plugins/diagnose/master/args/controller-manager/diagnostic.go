@@ -18,19 +18,9 @@
 package controller_manager
 
 import (
-	"context"
-	"fmt"
-	"strconv"
-
-	"tkestack.io/kube-jarvis/pkg/translate"
-
-	"k8s.io/apimachinery/pkg/api/resource"
-
-	v1 "k8s.io/api/core/v1"
-
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
-
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	argsrule "tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/rule"
 )
 
 const (
@@ -38,123 +28,48 @@ const (
 	DiagnosticType = "kube-controller-manager-args"
 )
 
-// Diagnostic is a example diagnostic shows how to write a diagnostic
-type Diagnostic struct {
-	*diagnose.MetaData
-	result chan *diagnose.Result
-}
-
-// NewDiagnostic return a example diagnostic
+// NewDiagnostic return a kube-controller-manager-args diagnostic
+// the built-in rules can be replaced by config "rules", see component-args diagnostic
 func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
-	return &Diagnostic{
-		result:   make(chan *diagnose.Result, 1000),
-		MetaData: meta,
-	}
-}
-
-// Complete check and complete config items
-func (d *Diagnostic) Complete() error {
-	return nil
-}
-
-// StartDiagnose return a result chan that will output results
-func (d *Diagnostic) StartDiagnose(ctx context.Context,
-	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
-	d.result = make(chan *diagnose.Result, 1000)
-	go func() {
-		defer diagnose.CommonDeafer(d.result)
-		for _, comp := range param.Resources.CoreComponents[cluster.ComponentControllerManager] {
-			d.checkOne(param.Resources, comp)
-		}
-	}()
-	return d.result, nil
-}
-
-func (d *Diagnostic) checkOne(resources *cluster.Resources, info cluster.Component) {
-	if info.Error != nil {
-		d.Logger.Errorf("check kube-controller-manager on node %s get error : %v", info.Node, info.Error)
-		return
-	}
-
-	if !info.IsRunning {
-		d.Logger.Errorf("kube-controller-manager on node %s not running ", info.Node)
-		return
-	}
-
-	if info.Node == "" {
-		d.Logger.Errorf("can not get node info for %s", info.Name)
-		return
-	}
-
-	node := findNode(resources.Nodes, info.Node)
-	mem := node.Status.Capacity.Memory()
-	cpu := node.Status.Capacity.Cpu()
-	qpsTarget := float64(50)
-	burstTarget := float64(100)
-
-	if mem.Cmp(resource.MustParse("14Gi")) > 1 && cpu.Cmp(resource.MustParse("6000m")) > 1 {
-		qpsTarget = 100
-		burstTarget = 200
-	}
-
-	if mem.Cmp(resource.MustParse("30Gi")) > 1 && cpu.Cmp(resource.MustParse("14000m")) > 1 {
-		qpsTarget = 200
-		burstTarget = 300
-	}
-
-	if mem.Cmp(resource.MustParse("60Gi")) > 1 && cpu.Cmp(resource.MustParse("30000m")) > 1 {
-		qpsTarget = 300
-		burstTarget = 400
-	}
-
-	d.checkArgs(resources, info, "kube-api-qps", float64(50), qpsTarget)
-	d.checkArgs(resources, info, "kube-api-burst", float64(100), burstTarget)
-}
-
-func (d *Diagnostic) checkArgs(resources *cluster.Resources, info cluster.Component,
-	arg string, defVal, targetVal float64) {
-	nodeTotal := len(resources.Nodes.Items)
-	obj := map[string]interface{}{
-		"Name":      info.Name,
-		"Node":      info.Node,
-		"NodeTotal": nodeTotal,
-		"Arg":       arg,
-	}
-
-	curVal := defVal
-	curValStr := info.Args[arg]
-	if curValStr != "" {
-		curVal, _ = strconv.ParseFloat(curValStr, 64)
-	}
-
-	obj["CurVal"] = curVal
-	obj["TargetVal"] = targetVal
-
-	level := diagnose.HealthyLevelGood
-	desc := d.Translator.Message("good-desc", obj)
-	proposal := translate.Message("")
-
-	if curVal < targetVal {
-		level = diagnose.HealthyLevelWarn
-		desc = d.Translator.Message(fmt.Sprintf("%s-desc", arg), obj)
-		proposal = d.Translator.Message(fmt.Sprintf("%s-proposal", arg), obj)
-	}
-
-	d.result <- &diagnose.Result{
-		Level:    level,
-		ObjName:  info.Name,
-		ObjInfo:  obj,
-		Title:    d.Translator.Message(fmt.Sprintf("%s-title", arg), nil),
-		Desc:     desc,
-		Proposal: proposal,
-	}
+	return argsrule.NewDiagnosticWithRules(meta, rules())
 }
 
-func findNode(node *v1.NodeList, nodeName string) *v1.Node {
-	for _, n := range node.Items {
-		if n.Name == nodeName {
-			return &n
-		}
+// rules return the recommended arguments of kube-controller-manager
+func rules() []*argsrule.Rule {
+	return []*argsrule.Rule{
+		{
+			Component: cluster.ComponentControllerManager,
+			Arg:       "kube-api-qps",
+			Op:        argsrule.OpMin,
+			Default:   "50",
+			Value:     "50",
+			Scale: &argsrule.Scale{
+				By: argsrule.ScaleByNodeCapacity,
+				Steps: []*argsrule.Step{
+					{CPU: "6", Memory: "14Gi", Value: "100"},
+					{CPU: "14", Memory: "30Gi", Value: "200"},
+					{CPU: "30", Memory: "60Gi", Value: "300"},
+				},
+			},
+			Level: diagnose.HealthyLevelWarn,
+			Key:   "kube-api-qps",
+		},
+		{
+			Component: cluster.ComponentControllerManager,
+			Arg:       "kube-api-burst",
+			Op:        argsrule.OpMin,
+			Default:   "100",
+			Value:     "100",
+			Scale: &argsrule.Scale{
+				By: argsrule.ScaleByNodeCapacity,
+				Steps: []*argsrule.Step{
+					{CPU: "6", Memory: "14Gi", Value: "200"},
+					{CPU: "14", Memory: "30Gi", Value: "300"},
+					{CPU: "30", Memory: "60Gi", Value: "400"},
+				},
+			},
+			Level: diagnose.HealthyLevelWarn,
+			Key:   "kube-api-burst",
+		},
 	}
-	return nil
 }
@@ -18,15 +18,9 @@
 package etcd
 
 import (
-	"context"
-	"fmt"
-	"strconv"
-
-	"tkestack.io/kube-jarvis/pkg/translate"
-
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
-
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	argsrule "tkestack.io/kube-jarvis/pkg/plugins/diagnose/master/args/rule"
 )
 
 const (
@@ -34,71 +28,25 @@ const (
 	DiagnosticType = "etcd-args"
 )
 
-// Diagnostic is a example diagnostic shows how to write a diagnostic
-type Diagnostic struct {
-	*diagnose.MetaData
-	result chan *diagnose.Result
-}
-
-// NewDiagnostic return a example diagnostic
+// NewDiagnostic return a etcd-args diagnostic
+// the built-in rules can be replaced by config "rules", see component-args diagnostic
 func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
-	return &Diagnostic{
-		result:   make(chan *diagnose.Result, 1000),
-		MetaData: meta,
-	}
-}
-
-// Complete check and complete config items
-func (d *Diagnostic) Complete() error {
-	return nil
+	return argsrule.NewDiagnosticWithRules(meta, rules())
 }
 
-// StartDiagnose return a result chan that will output results
-func (d *Diagnostic) StartDiagnose(ctx context.Context,
-	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
-	d.result = make(chan *diagnose.Result, 1000)
-	go func() {
-		defer diagnose.CommonDeafer(d.result)
-		for _, comp := range param.Resources.CoreComponents[cluster.ComponentETCD] {
-			d.checkQuota(param.Resources, comp)
-		}
-	}()
-	return d.result, nil
-}
-
-func (d *Diagnostic) checkQuota(resources *cluster.Resources, info cluster.Component) {
-	arg := "quota-backend-bytes"
-	curVal := 2 * 1024 * 1024 * 1024
-	curValStr := info.Args[arg]
-	if curValStr != "" {
-		curVal, _ = strconv.Atoi(curValStr)
-	}
-	targetVal := 6 * 1024 * 1024 * 1024
-
-	obj := map[string]interface{}{
-		"Name":      info.Name,
-		"Node":      info.Node,
-		"Arg":       arg,
-		"CurVal":    curVal,
-		"TargetVal": targetVal,
-	}
-
-	level := diagnose.HealthyLevelGood
-	desc := d.Translator.Message("good-desc", obj)
-	proposal := translate.Message("")
-
-	if curVal < targetVal {
-		level = diagnose.HealthyLevelWarn
-		desc = d.Translator.Message(fmt.Sprintf("%s-desc", arg), obj)
-		proposal = d.Translator.Message(fmt.Sprintf("%s-proposal", arg), obj)
-	}
-
-	d.result <- &diagnose.Result{
-		Level:    level,
-		ObjName:  info.Name,
-		ObjInfo:  obj,
-		Title:    d.Translator.Message(fmt.Sprintf("%s-title", arg), nil),
-		Desc:     desc,
-		Proposal: proposal,
+// rules return the recommended arguments of etcd
+func rules() []*argsrule.Rule {
+	return []*argsrule.Rule{
+		{
+			Component: cluster.ComponentETCD,
+			Arg:       "quota-backend-bytes",
+			Op:        argsrule.OpMin,
+			// 2Gi
+			Default: "2147483648",
+			// 6Gi
+			Value: "6442450944",
+			Level: diagnose.HealthyLevelWarn,
+			Key:   "quota-backend-bytes",
+		},
 	}
 }
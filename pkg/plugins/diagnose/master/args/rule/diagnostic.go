@@ -0,0 +1,177 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package rule
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "component-args"
+)
+
+// Diagnostic check arguments of components with rules
+type Diagnostic struct {
+	*diagnose.MetaData
+	Rules  []*Rule
+	result chan *diagnose.Result
+}
+
+// NewDiagnostic return a component-args diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return NewDiagnosticWithRules(meta, nil)
+}
+
+// NewDiagnosticWithRules return a component-args diagnostic with default rules
+// the rules can be replaced by config
+func NewDiagnosticWithRules(meta *diagnose.MetaData, rules []*Rule) *Diagnostic {
+	return &Diagnostic{
+		MetaData: meta,
+		Rules:    rules,
+		result:   make(chan *diagnose.Result, 1000),
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	for _, r := range d.Rules {
+		if err := r.complete(); err != nil {
+			return errors.Wrapf(err, "complete rule %s failed", r.Name)
+		}
+	}
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+// op messages belong to component-args, they are returned if rules are not built-in
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"good-desc"}
+	if len(d.Rules) == 0 {
+		for _, op := range []string{OpPresent, OpAbsent, OpEqual, OpMin, OpMax} {
+			ids = append(ids, op+"-desc", op+"-proposal")
+		}
+	}
+
+	for _, r := range d.Rules {
+		if r.Key != "" {
+			ids = append(ids, r.Key+"-title", r.Key+"-desc", r.Key+"-proposal")
+		}
+	}
+	return ids
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		for _, r := range d.Rules {
+			for _, comp := range param.Resources.CoreComponents[r.Component] {
+				d.checkOne(param.Resources, r, comp)
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) checkOne(resources *cluster.Resources, r *Rule, info cluster.Component) {
+	if info.Error != nil {
+		d.Logger.Errorf("check %s on node %s get error : %v", r.Component, info.Node, info.Error)
+		return
+	}
+
+	if !info.IsRunning {
+		d.Logger.Errorf("%s on node %s not running ", r.Component, info.Node)
+		return
+	}
+
+	nodeTotal := 0
+	if resources.Nodes != nil {
+		nodeTotal = len(resources.Nodes.Items)
+	}
+
+	targetVal := r.target(nodeTotal, findNode(resources.Nodes, info.Node))
+	curVal, pass := r.pass(info.Args, targetVal)
+	obj := map[string]interface{}{
+		"Rule":      r.Name,
+		"Component": r.Component,
+		"Name":      info.Name,
+		"Node":      info.Node,
+		"NodeTotal": nodeTotal,
+		"Arg":       r.Arg,
+		"CurVal":    curVal,
+		"TargetVal": targetVal,
+	}
+
+	level := diagnose.HealthyLevelGood
+	desc := d.Translator.Message("good-desc", obj)
+	proposal := translate.Message{}
+	if !pass {
+		level = r.Level
+		desc = d.message(r, 1, obj)
+		proposal = d.message(r, 2, obj)
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    level,
+		ObjName:  info.Name,
+		Obj:      diagnose.NewComponentRef(&info),
+		ObjInfo:  obj,
+		Title:    d.message(r, 0, obj),
+		Desc:     desc,
+		Proposal: proposal,
+	}
+}
+
+// message return the title, desc or proposal of rule
+// translation keys are used if Key is set, the default translations of op in module "diagnostics.component-args"
+// are used if template is empty
+func (d *Diagnostic) message(r *Rule, idx int, obj map[string]interface{}) translate.Message {
+	kind := []string{"title", "desc", "proposal"}[idx]
+	if r.Key != "" {
+		return d.Translator.Message(r.Key+"-"+kind, obj)
+	}
+
+	if r.templates[idx] != nil {
+		return translate.Literal(render(r.templates[idx], obj))
+	}
+
+	// op messages are shared by all diagnostics built on component-args
+	return d.Translator.WithModule("diagnostics."+DiagnosticType).Message(r.Op+"-"+kind, obj)
+}
+
+func findNode(nodes *v1.NodeList, nodeName string) *v1.Node {
+	if nodes == nil {
+		return nil
+	}
+
+	for i := range nodes.Items {
+		if nodes.Items[i].Name == nodeName {
+			return &nodes.Items[i]
+		}
+	}
+	return nil
+}
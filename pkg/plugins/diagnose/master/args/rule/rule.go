@@ -0,0 +1,252 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package rule
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"text/template"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// OpPresent means the argument must be set
+	OpPresent = "present"
+	// OpAbsent means the argument must not be set
+	OpAbsent = "absent"
+	// OpEqual means the argument must equal to target
+	OpEqual = "equal"
+	// OpMin means the argument must be a number not less than target
+	OpMin = "min"
+	// OpMax means the argument must be a number not greater than target
+	OpMax = "max"
+
+	// ScaleByNodeCount scale target with the number of nodes in cluster
+	ScaleByNodeCount = "node-count"
+	// ScaleByNodeCapacity scale target with the capacity of the node that component runs on
+	ScaleByNodeCapacity = "node-capacity"
+)
+
+// Rule is a check on one argument of a component
+type Rule struct {
+	// Name is the name of rule, default is Arg
+	Name string
+	// Component is the target component, e.g. "kube-apiserver"
+	Component string
+	// Arg is the argument name without "--"
+	Arg string
+	// Op is one of "present", "absent", "equal", "min" and "max"
+	Op string
+	// Value is the target of "equal", "min" and "max"
+	Value string
+	// Default is the value used by component if Arg is not set
+	Default string
+	// Scale changes the target with node count or node capacity
+	Scale *Scale
+	// Level is the HealthyLevel of result if the check failed, default is "warn"
+	Level diagnose.HealthyLevel
+	// Key is the prefix of translation keys, "<Key>-title", "<Key>-desc" and "<Key>-proposal" are used if it is set
+	Key string
+	// Title, Desc and Proposal are golang templates of result, they are used if Key is not set
+	// {{.Rule}},{{.Component}},{{.Name}},{{.Node}},{{.NodeTotal}},{{.Arg}},{{.CurVal}} and {{.TargetVal}} can be used
+	Title    string
+	Desc     string
+	Proposal string
+
+	templates [3]*template.Template
+}
+
+// Scale is a list of steps, the target is the value of the last matched step
+type Scale struct {
+	// By is "node-count" or "node-capacity"
+	By string
+	// Steps should be sorted from small to large
+	Steps []*Step
+}
+
+// Step is a target value for clusters or nodes that are big enough
+type Step struct {
+	// Nodes is the min number of nodes in cluster, used by "node-count"
+	Nodes int
+	// CPU and Memory are used by "node-capacity", the step is matched if the capacity of node is greater than both
+	CPU    string
+	Memory string
+	// Value is the target if this step is matched
+	Value string
+
+	cpu    resource.Quantity
+	memory resource.Quantity
+}
+
+func (r *Rule) complete() error {
+	if r.Component == "" || r.Arg == "" {
+		return fmt.Errorf("component and arg can not be empty")
+	}
+
+	if r.Name == "" {
+		r.Name = r.Arg
+	}
+
+	if r.Level == "" {
+		r.Level = diagnose.HealthyLevelWarn
+	}
+
+	if !r.Level.Verify() {
+		return fmt.Errorf("level %s is illegal", r.Level)
+	}
+
+	values := []string{r.Value}
+	if r.Scale != nil {
+		if err := r.Scale.complete(); err != nil {
+			return errors.Wrap(err, "complete scale failed")
+		}
+
+		for _, s := range r.Scale.Steps {
+			values = append(values, s.Value)
+		}
+	}
+
+	switch r.Op {
+	case OpPresent, OpAbsent, OpEqual:
+	case OpMin, OpMax:
+		if r.Default != "" {
+			values = append(values, r.Default)
+		}
+
+		for _, v := range values {
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return errors.Wrapf(err, "value of %s must be a number", r.Op)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown op %s", r.Op)
+	}
+
+	if r.Key != "" {
+		return nil
+	}
+
+	if r.Title == "" {
+		r.Title = r.Name
+	}
+
+	for i, text := range []string{r.Title, r.Desc, r.Proposal} {
+		if text == "" {
+			continue
+		}
+
+		tpl, err := template.New(r.Name).Parse(text)
+		if err != nil {
+			return errors.Wrapf(err, "parse template %s failed", text)
+		}
+		r.templates[i] = tpl
+	}
+	return nil
+}
+
+func (s *Scale) complete() error {
+	if s.By != ScaleByNodeCount && s.By != ScaleByNodeCapacity {
+		return fmt.Errorf("unknown scale type %s", s.By)
+	}
+
+	for _, step := range s.Steps {
+		if s.By == ScaleByNodeCount {
+			continue
+		}
+
+		cpu, err := resource.ParseQuantity(step.CPU)
+		if err != nil {
+			return errors.Wrapf(err, "parse cpu %s failed", step.CPU)
+		}
+
+		memory, err := resource.ParseQuantity(step.Memory)
+		if err != nil {
+			return errors.Wrapf(err, "parse memory %s failed", step.Memory)
+		}
+		step.cpu, step.memory = cpu, memory
+	}
+	return nil
+}
+
+// target return the expected value for the component runs on node
+func (r *Rule) target(nodeTotal int, node *v1.Node) string {
+	target := r.Value
+	if r.Scale == nil {
+		return target
+	}
+
+	for _, s := range r.Scale.Steps {
+		switch r.Scale.By {
+		case ScaleByNodeCount:
+			if nodeTotal >= s.Nodes {
+				target = s.Value
+			}
+		case ScaleByNodeCapacity:
+			if node != nil && node.Status.Capacity.Cpu().Cmp(s.cpu) > 0 &&
+				node.Status.Capacity.Memory().Cmp(s.memory) > 0 {
+				target = s.Value
+			}
+		}
+	}
+	return target
+}
+
+// pass return true if the argument meets target, curVal is the effective value of argument
+func (r *Rule) pass(args map[string]string, target string) (curVal string, pass bool) {
+	curVal, exist := args[r.Arg]
+	switch r.Op {
+	case OpPresent:
+		return curVal, exist
+	case OpAbsent:
+		return curVal, !exist
+	}
+
+	if !exist {
+		curVal = r.Default
+	}
+
+	switch r.Op {
+	case OpEqual:
+		return curVal, curVal == target
+	case OpMin, OpMax:
+		cur, err := strconv.ParseFloat(curVal, 64)
+		if err != nil {
+			return curVal, false
+		}
+
+		t, _ := strconv.ParseFloat(target, 64)
+		if r.Op == OpMin {
+			return curVal, cur >= t
+		}
+		return curVal, cur <= t
+	}
+	return curVal, false
+}
+
+func render(tpl *template.Template, data interface{}) string {
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, data); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
@@ -0,0 +1,280 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package rule
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestRule_Complete(t *testing.T) {
+	var cases = []struct {
+		name string
+		rule Rule
+		pass bool
+	}{
+		{
+			name: "normal",
+			rule: Rule{Component: "etcd", Arg: "quota-backend-bytes", Op: OpMin, Value: "6442450944"},
+			pass: true,
+		},
+		{
+			name: "no arg",
+			rule: Rule{Component: "etcd", Op: OpPresent},
+		},
+		{
+			name: "unknown op",
+			rule: Rule{Component: "etcd", Arg: "a", Op: "less"},
+		},
+		{
+			name: "min with string",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpMin, Value: "a"},
+		},
+		{
+			name: "illegal level",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpPresent, Level: "bad"},
+		},
+		{
+			name: "unknown scale",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpMin, Value: "1", Scale: &Scale{By: "pods"}},
+		},
+		{
+			name: "illegal step value",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpMin, Value: "1", Scale: &Scale{
+				By:    ScaleByNodeCount,
+				Steps: []*Step{{Nodes: 100, Value: "a"}},
+			}},
+		},
+		{
+			name: "illegal step cpu",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpMin, Value: "1", Scale: &Scale{
+				By:    ScaleByNodeCapacity,
+				Steps: []*Step{{CPU: "a", Memory: "1Gi", Value: "2"}},
+			}},
+		},
+		{
+			name: "illegal template",
+			rule: Rule{Component: "etcd", Arg: "a", Op: OpPresent, Desc: "{{.Arg"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			err := cs.rule.complete()
+			if (err == nil) != cs.pass {
+				t.Fatalf("want pass %v but get err %v", cs.pass, err)
+			}
+		})
+	}
+}
+
+func TestRule_Target(t *testing.T) {
+	node := &v1.Node{}
+	node.Status.Capacity = v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("16"),
+		v1.ResourceMemory: resource.MustParse("32Gi"),
+	}
+
+	var cases = []struct {
+		name      string
+		scale     *Scale
+		nodeTotal int
+		node      *v1.Node
+		want      string
+	}{
+		{
+			name: "no scale",
+			want: "1",
+		},
+		{
+			name: "node count",
+			scale: &Scale{By: ScaleByNodeCount, Steps: []*Step{
+				{Nodes: 100, Value: "2"},
+				{Nodes: 500, Value: "3"},
+			}},
+			nodeTotal: 200,
+			want:      "2",
+		},
+		{
+			name: "node capacity",
+			scale: &Scale{By: ScaleByNodeCapacity, Steps: []*Step{
+				{CPU: "6", Memory: "14Gi", Value: "2"},
+				{CPU: "14", Memory: "30Gi", Value: "3"},
+				{CPU: "30", Memory: "60Gi", Value: "4"},
+			}},
+			node: node,
+			want: "3",
+		},
+		{
+			name: "node capacity boundary",
+			scale: &Scale{By: ScaleByNodeCapacity, Steps: []*Step{
+				{CPU: "6", Memory: "14Gi", Value: "2"},
+				{CPU: "16", Memory: "32Gi", Value: "3"},
+			}},
+			node: node,
+			want: "2",
+		},
+		{
+			name: "node not found",
+			scale: &Scale{By: ScaleByNodeCapacity, Steps: []*Step{
+				{CPU: "6", Memory: "14Gi", Value: "2"},
+			}},
+			want: "1",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			r := &Rule{Component: "etcd", Arg: "a", Op: OpMin, Value: "1", Scale: cs.scale}
+			if err := r.complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if got := r.target(cs.nodeTotal, cs.node); got != cs.want {
+				t.Fatalf("want %s but get %s", cs.want, got)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	res := cluster.NewResources()
+	res.Nodes = &v1.NodeList{Items: []v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "master1"}}}}
+	res.CoreComponents[cluster.ComponentApiserver] = []cluster.Component{
+		{
+			Name:      "kube-apiserver-master1",
+			Node:      "master1",
+			IsRunning: true,
+			Args: map[string]string{
+				"max-requests-inflight": "1000",
+				"profiling":             "true",
+			},
+		},
+		{
+			Name:  "kube-apiserver-master2",
+			Node:  "master2",
+			Error: context.Canceled,
+		},
+	}
+
+	d := NewDiagnosticWithRules(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}, []*Rule{
+		{
+			Component: cluster.ComponentApiserver,
+			Arg:       "max-requests-inflight",
+			Op:        OpMin,
+			Default:   "400",
+			Value:     "800",
+		},
+		{
+			Component: cluster.ComponentApiserver,
+			Arg:       "max-mutating-requests-inflight",
+			Op:        OpMin,
+			Default:   "200",
+			Value:     "200",
+			Scale:     &Scale{By: ScaleByNodeCount, Steps: []*Step{{Nodes: 1, Value: "500"}}},
+			Level:     diagnose.HealthyLevelRisk,
+			Key:       "max-mutating-requests-inflight",
+		},
+		{
+			Name:      "no-profiling",
+			Component: cluster.ComponentApiserver,
+			Arg:       "profiling",
+			Op:        OpEqual,
+			Value:     "false",
+			Desc:      "{{.Name}} profiling is {{.CurVal}}",
+		},
+	})
+
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]*diagnose.Result{}
+	for r := range results {
+		got[r.ObjInfo["Rule"].(string)] = r
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("want 3 results but get %d", len(got))
+	}
+
+	if r := got["max-requests-inflight"]; r.Level != diagnose.HealthyLevelGood {
+		t.Fatalf("want good but get %s", r.Level)
+	}
+
+	r := got["max-mutating-requests-inflight"]
+	if r.Level != diagnose.HealthyLevelRisk || r.ObjInfo["CurVal"] != "200" || r.ObjInfo["TargetVal"] != "500" {
+		t.Fatalf("unexpected result %+v", r.ObjInfo)
+	}
+
+	r = got["no-profiling"]
+	if r.Level != diagnose.HealthyLevelWarn || r.Desc.Text != "kube-apiserver-master1 profiling is true" {
+		t.Fatalf("unexpected result %s %+v", r.Level, r.Desc)
+	}
+}
+
+func TestDiagnostic_OpMessage(t *testing.T) {
+	tr, err := translate.NewDefault("", "en", "en")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	d := NewDiagnosticWithRules(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: tr.WithModule("diagnostics.kube-apiserver-args"),
+			Logger:     logger.NewLogger(),
+			Type:       "kube-apiserver-args",
+			Name:       "kube-apiserver-args",
+		},
+	}, nil)
+
+	r := &Rule{Component: cluster.ComponentApiserver, Arg: "a", Op: OpPresent}
+	if err := r.complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	msg := d.message(r, 1, map[string]interface{}{"Name": "kube-apiserver", "Node": "node1", "Arg": "a"})
+	if msg.ID != "diagnostics.component-args.present-desc" {
+		t.Fatalf("op message should belong to component-args but get %s", msg.ID)
+	}
+
+	if text := tr.Translate(msg); text != "kube-apiserver on node node1 argument --a is not set" {
+		t.Fatalf("unexpected text %s", text)
+	}
+}
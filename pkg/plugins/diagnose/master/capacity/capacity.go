@@ -69,6 +69,11 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"title", "desc", "proposal", "good-desc"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context,
 	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
@@ -100,8 +105,8 @@ l1:
 		d.result <- &diagnose.Result{
 			Level:   diagnose.HealthyLevelFailed,
 			ObjName: "*",
-			Title:   "Failed",
-			Desc:    translate.Message(err.Error()),
+			Title:   translate.Literal("Failed"),
+			Desc:    translate.Literal(err.Error()),
 		}
 		return
 	}
@@ -139,6 +144,7 @@ func (d *Diagnostic) sendCapacityWarnResult(name string, resource string,
 
 	d.result <- &diagnose.Result{
 		ObjName: name,
+		Obj:     diagnose.NewNodeRef(name),
 		Level:   diagnose.HealthyLevelWarn,
 		ObjInfo: objInfo,
 		Title: d.Translator.Message("title", map[string]interface{}{
@@ -161,6 +167,7 @@ func (d *Diagnostic) sendCapacityGoodResult(name string, resource string,
 
 	d.result <- &diagnose.Result{
 		ObjName: name,
+		Obj:     diagnose.NewNodeRef(name),
 		Level:   diagnose.HealthyLevelGood,
 		ObjInfo: objInfo,
 		Title: d.Translator.Message("title", map[string]interface{}{
@@ -0,0 +1,262 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package certs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "cert-expiry"
+
+	// SourceSecret means the certificate is in a kubernetes.io/tls Secret
+	SourceSecret = "secret"
+	// SourceWebhook means the certificate is the caBundle of an admission webhook
+	SourceWebhook = "webhook"
+	// SourceComponent means the certificate is in a file used by a core component
+	SourceComponent = "component"
+
+	// DefaultWarnDays is the default value of WarnDays
+	DefaultWarnDays = 30
+	// DefaultRiskDays is the default value of RiskDays
+	DefaultRiskDays = 7
+
+	admissionGroup = "admissionregistration.k8s.io"
+)
+
+// Diagnostic check the expiration time of certificates in tls Secrets,
+// caBundles of admission webhooks and files used by core components
+type Diagnostic struct {
+	*diagnose.MetaData
+	// WarnDays is the days remaining below which a certificate is reported as warn
+	WarnDays int
+	// RiskDays is the days remaining below which a certificate is reported as risk
+	RiskDays int
+	now      func() time.Time
+	result   chan *diagnose.Result
+}
+
+// NewDiagnostic return a cert-expiry diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+		now:      time.Now,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.WarnDays == 0 {
+		d.WarnDays = DefaultWarnDays
+	}
+
+	if d.RiskDays == 0 {
+		d.RiskDays = DefaultRiskDays
+	}
+
+	if d.RiskDays < 0 || d.WarnDays < d.RiskDays {
+		return fmt.Errorf("riskdays must be positive and not greater than warndays")
+	}
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"cert-title", "cert-good-desc", "cert-expired-desc", "cert-expiring-desc",
+		"cert-failed-desc", "cert-proposal"}
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		res := param.Resources
+		d.diagnoseSecrets(res)
+		d.diagnoseWebhooks(res)
+		for _, comps := range res.CoreComponents {
+			for i := range comps {
+				d.diagnoseComponent(&comps[i])
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) diagnoseSecrets(res *cluster.Resources) {
+	if res.Secrets == nil {
+		return
+	}
+
+	for _, s := range res.Secrets.Items {
+		if s.Type != corev1.SecretTypeTLS {
+			continue
+		}
+
+		ref := &diagnose.ObjectRef{
+			Kind:      "Secret",
+			Namespace: s.Namespace,
+			Name:      s.Name,
+			UID:       s.UID,
+		}
+		obj := map[string]interface{}{
+			"Source": SourceSecret,
+			"Name":   fmt.Sprintf("%s:%s", s.Namespace, s.Name),
+		}
+		d.diagnosePEM(ref, obj, s.Data[corev1.TLSCertKey])
+	}
+}
+
+func (d *Diagnostic) diagnoseWebhooks(res *cluster.Resources) {
+	if res.MutatingWebhookConfigurations != nil {
+		for _, c := range res.MutatingWebhookConfigurations.Items {
+			for _, w := range c.Webhooks {
+				d.diagnoseWebhook("MutatingWebhookConfiguration", c.ObjectMeta, w.Name, w.ClientConfig.CABundle)
+			}
+		}
+	}
+
+	if res.ValidatingWebhookConfigurations != nil {
+		for _, c := range res.ValidatingWebhookConfigurations.Items {
+			for _, w := range c.Webhooks {
+				d.diagnoseWebhook("ValidatingWebhookConfiguration", c.ObjectMeta, w.Name, w.ClientConfig.CABundle)
+			}
+		}
+	}
+}
+
+func (d *Diagnostic) diagnoseWebhook(kind string, meta metav1.ObjectMeta, webhook string, caBundle []byte) {
+	// webhooks without caBundle use the system trust roots
+	if len(caBundle) == 0 {
+		return
+	}
+
+	ref := &diagnose.ObjectRef{
+		Kind:  kind,
+		Group: admissionGroup,
+		Name:  meta.Name,
+		UID:   meta.UID,
+	}
+	obj := map[string]interface{}{
+		"Source": SourceWebhook,
+		"Name":   fmt.Sprintf("%s/%s", meta.Name, webhook),
+	}
+	d.diagnosePEM(ref, obj, caBundle)
+}
+
+func (d *Diagnostic) diagnoseComponent(comp *cluster.Component) {
+	for _, c := range comp.Certificates {
+		obj := map[string]interface{}{
+			"Source": SourceComponent,
+			"Name":   comp.Name,
+			"Node":   comp.Node,
+			"Arg":    c.Arg,
+			"Path":   c.Path,
+		}
+
+		if c.Error != "" {
+			d.sendFailed(diagnose.NewComponentRef(comp), obj, c.Error)
+			continue
+		}
+		d.sendCert(diagnose.NewComponentRef(comp), obj, c.Subject, c.NotAfter)
+	}
+}
+
+// diagnosePEM report every certificate in PEM encoded data
+func (d *Diagnostic) diagnosePEM(ref *diagnose.ObjectRef, obj map[string]interface{}, data []byte) {
+	certs, err := util.ParseCertificates(data)
+	if err != nil {
+		d.sendFailed(ref, obj, err.Error())
+		return
+	}
+
+	for _, c := range certs {
+		info := map[string]interface{}{}
+		for k, v := range obj {
+			info[k] = v
+		}
+		d.sendCert(ref, info, c.Subject.CommonName, c.NotAfter)
+	}
+}
+
+// level return the HealthyLevel of a certificate with target days remaining
+func (d *Diagnostic) level(notAfter time.Time, days int) diagnose.HealthyLevel {
+	switch {
+	case !d.now().Before(notAfter):
+		return diagnose.HealthyLevelSerious
+	case days < d.RiskDays:
+		return diagnose.HealthyLevelRisk
+	case days < d.WarnDays:
+		return diagnose.HealthyLevelWarn
+	}
+	return diagnose.HealthyLevelGood
+}
+
+func (d *Diagnostic) sendCert(ref *diagnose.ObjectRef, obj map[string]interface{}, subject string, notAfter time.Time) {
+	days := int(notAfter.Sub(d.now()).Hours() / 24)
+	obj["Subject"] = subject
+	obj["NotAfter"] = notAfter.Format(time.RFC3339)
+	obj["Days"] = days
+	obj["WarnDays"] = d.WarnDays
+
+	level := d.level(notAfter, days)
+	desc := d.Translator.Message("cert-good-desc", obj)
+	proposal := translate.Message{}
+	switch level {
+	case diagnose.HealthyLevelSerious:
+		desc = d.Translator.Message("cert-expired-desc", obj)
+		proposal = d.Translator.Message("cert-proposal", obj)
+	case diagnose.HealthyLevelRisk, diagnose.HealthyLevelWarn:
+		desc = d.Translator.Message("cert-expiring-desc", obj)
+		proposal = d.Translator.Message("cert-proposal", obj)
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    level,
+		ObjName:  obj["Name"].(string),
+		Obj:      ref,
+		ObjInfo:  obj,
+		Title:    d.Translator.Message("cert-title", obj),
+		Desc:     desc,
+		Proposal: proposal,
+	}
+}
+
+func (d *Diagnostic) sendFailed(ref *diagnose.ObjectRef, obj map[string]interface{}, err string) {
+	obj["Error"] = err
+	d.result <- &diagnose.Result{
+		Level:   diagnose.HealthyLevelFailed,
+		ObjName: obj["Name"].(string),
+		Obj:     ref,
+		ObjInfo: obj,
+		Title:   d.Translator.Message("cert-title", obj),
+		Desc:    d.Translator.Message("cert-failed-desc", obj),
+	}
+}
@@ -0,0 +1,204 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	ar "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestCert(t *testing.T, cn string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    notAfter.Add(-time.Hour * 24 * 365),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func days(n int) time.Time {
+	return now.Add(time.Hour * 24 * time.Duration(n))
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		name     string
+		warnDays int
+		riskDays int
+		pass     bool
+	}{
+		{
+			name: "default",
+			pass: true,
+		},
+		{
+			name:     "custom",
+			warnDays: 60,
+			riskDays: 14,
+			pass:     true,
+		},
+		{
+			name:     "risk greater than warn",
+			warnDays: 10,
+			riskDays: 14,
+		},
+		{
+			name:     "negative",
+			riskDays: -1,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+			d.WarnDays = cs.warnDays
+			d.RiskDays = cs.riskDays
+			err := d.Complete()
+			if (err == nil) != cs.pass {
+				t.Fatalf("want pass %v but get err %v", cs.pass, err)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	res := cluster.NewResources()
+	res.Secrets = &corev1.SecretList{}
+	for name, data := range map[string][]byte{
+		"expired":  newTestCert(t, "expired", days(-1)),
+		"good":     newTestCert(t, "good", days(100)),
+		"bad-data": []byte("bad"),
+	} {
+		s := corev1.Secret{Type: corev1.SecretTypeTLS}
+		s.Namespace = "default"
+		s.Name = name
+		s.Data = map[string][]byte{corev1.TLSCertKey: data}
+		res.Secrets.Items = append(res.Secrets.Items, s)
+	}
+
+	opaque := corev1.Secret{Type: corev1.SecretTypeOpaque}
+	opaque.Name = "opaque"
+	opaque.Data = map[string][]byte{corev1.TLSCertKey: newTestCert(t, "opaque", days(-1))}
+	res.Secrets.Items = append(res.Secrets.Items, opaque)
+
+	mwc := ar.MutatingWebhookConfiguration{}
+	mwc.Name = "mutating"
+	mwc.Webhooks = []ar.MutatingWebhook{
+		{Name: "risk", ClientConfig: ar.WebhookClientConfig{CABundle: newTestCert(t, "risk", days(3))}},
+		{Name: "no-ca"},
+	}
+	res.MutatingWebhookConfigurations = &ar.MutatingWebhookConfigurationList{
+		Items: []ar.MutatingWebhookConfiguration{mwc},
+	}
+
+	vwc := ar.ValidatingWebhookConfiguration{}
+	vwc.Name = "validating"
+	vwc.Webhooks = []ar.ValidatingWebhook{
+		{Name: "warn", ClientConfig: ar.WebhookClientConfig{CABundle: newTestCert(t, "warn", days(20))}},
+	}
+	res.ValidatingWebhookConfigurations = &ar.ValidatingWebhookConfigurationList{
+		Items: []ar.ValidatingWebhookConfiguration{vwc},
+	}
+
+	res.CoreComponents[cluster.ComponentApiserver] = []cluster.Component{
+		{
+			Name:      "kube-apiserver-master1",
+			Node:      "master1",
+			IsRunning: true,
+			Certificates: []cluster.Certificate{
+				{Arg: "tls-cert-file", Path: "/pki/apiserver.crt", Subject: "apiserver", NotAfter: days(0)},
+				{Arg: "client-ca-file", Path: "/pki/ca.crt", Error: "no such file"},
+			},
+		},
+	}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	d.now = func() time.Time { return now }
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	for r := range results {
+		key := r.ObjName
+		if p, exist := r.ObjInfo["Path"]; exist {
+			key += p.(string)
+		}
+		got[key] = r.Level
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"default:expired":  diagnose.HealthyLevelSerious,
+		"default:good":     diagnose.HealthyLevelGood,
+		"default:bad-data": diagnose.HealthyLevelFailed,
+		"mutating/risk":    diagnose.HealthyLevelRisk,
+		"validating/warn":  diagnose.HealthyLevelWarn,
+		"kube-apiserver-master1/pki/apiserver.crt": diagnose.HealthyLevelSerious,
+		"kube-apiserver-master1/pki/ca.crt":        diagnose.HealthyLevelFailed,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d results, got %d: %v", len(want), len(got), got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+}
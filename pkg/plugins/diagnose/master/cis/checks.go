@@ -0,0 +1,131 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package cis
+
+import (
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+// strongCiphers is the cipher suites recommended by CIS Kubernetes Benchmark
+var strongCiphers = []string{
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305",
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+	"TLS_RSA_WITH_AES_256_GCM_SHA384",
+	"TLS_RSA_WITH_AES_128_GCM_SHA256",
+}
+
+const (
+	apiserver  = cluster.ComponentApiserver
+	controller = cluster.ComponentControllerManager
+	scheduler  = cluster.ComponentScheduler
+	etcd       = cluster.ComponentETCD
+	kubelet    = cluster.ComponentKubelet
+
+	warn    = diagnose.HealthyLevelWarn
+	risk    = diagnose.HealthyLevelRisk
+	serious = diagnose.HealthyLevelSerious
+)
+
+// Checks is the built-in recommendations of CIS Kubernetes Benchmark v1.5.1 that can be checked via arguments
+// a recommendation may have more than one check, e.g. a cert file and a key file
+var Checks = []Check{
+	// 1.2 API Server
+	{ID: "1.2.1", Component: apiserver, Arg: "anonymous-auth", Op: OpEqual, Value: "false", Default: "true", Level: warn},
+	{ID: "1.2.2", Component: apiserver, Arg: "basic-auth-file", Op: OpNotSet, Level: risk},
+	{ID: "1.2.3", Component: apiserver, Arg: "token-auth-file", Op: OpNotSet, Level: risk},
+	{ID: "1.2.4", Component: apiserver, Arg: "kubelet-https", Op: OpEqual, Value: "true", Default: "true", Level: risk},
+	{ID: "1.2.5", Component: apiserver, Arg: "kubelet-client-certificate", Op: OpSet, Level: warn},
+	{ID: "1.2.5", Component: apiserver, Arg: "kubelet-client-key", Op: OpSet, Level: warn},
+	{ID: "1.2.6", Component: apiserver, Arg: "kubelet-certificate-authority", Op: OpSet, Level: warn},
+	{ID: "1.2.7", Component: apiserver, Arg: "authorization-mode", Op: OpNotContains, Value: "AlwaysAllow",
+		Default: "AlwaysAllow", Level: serious},
+	{ID: "1.2.8", Component: apiserver, Arg: "authorization-mode", Op: OpContains, Value: "Node",
+		Default: "AlwaysAllow", Level: warn},
+	{ID: "1.2.9", Component: apiserver, Arg: "authorization-mode", Op: OpContains, Value: "RBAC",
+		Default: "AlwaysAllow", Level: risk},
+	{ID: "1.2.11", Component: apiserver, Arg: "enable-admission-plugins", Op: OpNotContains, Value: "AlwaysAdmit",
+		Level: risk},
+	{ID: "1.2.16", Component: apiserver, Arg: "disable-admission-plugins", Op: OpNotContains, Value: "NamespaceLifecycle",
+		Level: warn},
+	{ID: "1.2.17", Component: apiserver, Arg: "enable-admission-plugins", Op: OpContains, Value: "NodeRestriction",
+		Level: warn},
+	{ID: "1.2.18", Component: apiserver, Arg: "insecure-bind-address", Op: OpNotSet, Level: risk},
+	{ID: "1.2.19", Component: apiserver, Arg: "insecure-port", Op: OpEqual, Value: "0", Default: "8080", Level: serious},
+	{ID: "1.2.20", Component: apiserver, Arg: "secure-port", Op: OpNotEqual, Value: "0", Default: "6443", Level: risk},
+	{ID: "1.2.21", Component: apiserver, Arg: "profiling", Op: OpEqual, Value: "false", Default: "true", Level: warn},
+	{ID: "1.2.22", Component: apiserver, Arg: "audit-log-path", Op: OpSet, Level: warn},
+	{ID: "1.2.23", Component: apiserver, Arg: "audit-log-maxage", Op: OpMin, Value: "30", Default: "0", Level: warn},
+	{ID: "1.2.24", Component: apiserver, Arg: "audit-log-maxbackup", Op: OpMin, Value: "10", Default: "0", Level: warn},
+	{ID: "1.2.25", Component: apiserver, Arg: "audit-log-maxsize", Op: OpMin, Value: "100", Default: "0", Level: warn},
+	{ID: "1.2.27", Component: apiserver, Arg: "service-account-lookup", Op: OpEqual, Value: "true", Default: "true",
+		Level: warn},
+	{ID: "1.2.28", Component: apiserver, Arg: "service-account-key-file", Op: OpSet, Level: warn},
+	{ID: "1.2.29", Component: apiserver, Arg: "etcd-certfile", Op: OpSet, Level: risk},
+	{ID: "1.2.29", Component: apiserver, Arg: "etcd-keyfile", Op: OpSet, Level: risk},
+	{ID: "1.2.30", Component: apiserver, Arg: "tls-cert-file", Op: OpSet, Level: risk},
+	{ID: "1.2.30", Component: apiserver, Arg: "tls-private-key-file", Op: OpSet, Level: risk},
+	{ID: "1.2.31", Component: apiserver, Arg: "client-ca-file", Op: OpSet, Level: risk},
+	{ID: "1.2.32", Component: apiserver, Arg: "etcd-cafile", Op: OpSet, Level: risk},
+	{ID: "1.2.33", Component: apiserver, Arg: "encryption-provider-config", Op: OpSet, Level: warn},
+	{ID: "1.2.35", Component: apiserver, Arg: "tls-cipher-suites", Op: OpSubset, Values: strongCiphers, Level: warn},
+
+	// 1.3 Controller Manager
+	{ID: "1.3.1", Component: controller, Arg: "terminated-pod-gc-threshold", Op: OpSet, Level: warn},
+	{ID: "1.3.2", Component: controller, Arg: "profiling", Op: OpEqual, Value: "false", Default: "true", Level: warn},
+	{ID: "1.3.3", Component: controller, Arg: "use-service-account-credentials", Op: OpEqual, Value: "true",
+		Default: "false", Level: warn},
+	{ID: "1.3.4", Component: controller, Arg: "service-account-private-key-file", Op: OpSet, Level: warn},
+	{ID: "1.3.5", Component: controller, Arg: "root-ca-file", Op: OpSet, Level: warn},
+	{ID: "1.3.7", Component: controller, Arg: "bind-address", Op: OpEqual, Value: "127.0.0.1", Default: "0.0.0.0",
+		Level: warn},
+
+	// 1.4 Scheduler
+	{ID: "1.4.1", Component: scheduler, Arg: "profiling", Op: OpEqual, Value: "false", Default: "true", Level: warn},
+	{ID: "1.4.2", Component: scheduler, Arg: "bind-address", Op: OpEqual, Value: "127.0.0.1", Default: "0.0.0.0",
+		Level: warn},
+
+	// 2 Etcd
+	{ID: "2.1", Component: etcd, Arg: "cert-file", Op: OpSet, Level: risk},
+	{ID: "2.1", Component: etcd, Arg: "key-file", Op: OpSet, Level: risk},
+	{ID: "2.2", Component: etcd, Arg: "client-cert-auth", Op: OpEqual, Value: "true", Default: "false", Level: risk},
+	{ID: "2.3", Component: etcd, Arg: "auto-tls", Op: OpNotEqual, Value: "true", Default: "false", Level: risk},
+	{ID: "2.4", Component: etcd, Arg: "peer-cert-file", Op: OpSet, Level: risk},
+	{ID: "2.4", Component: etcd, Arg: "peer-key-file", Op: OpSet, Level: risk},
+	{ID: "2.5", Component: etcd, Arg: "peer-client-cert-auth", Op: OpEqual, Value: "true", Default: "false",
+		Level: risk},
+	{ID: "2.6", Component: etcd, Arg: "peer-auto-tls", Op: OpNotEqual, Value: "true", Default: "false", Level: risk},
+
+	// 4.2 Kubelet
+	{ID: "4.2.1", Component: kubelet, Arg: "anonymous-auth", Op: OpEqual, Value: "false", Default: "true", Level: risk},
+	{ID: "4.2.2", Component: kubelet, Arg: "authorization-mode", Op: OpNotEqual, Value: "AlwaysAllow",
+		Default: "AlwaysAllow", Level: risk},
+	{ID: "4.2.3", Component: kubelet, Arg: "client-ca-file", Op: OpSet, Level: risk},
+	{ID: "4.2.4", Component: kubelet, Arg: "read-only-port", Op: OpEqual, Value: "0", Default: "10255", Level: warn},
+	{ID: "4.2.5", Component: kubelet, Arg: "streaming-connection-idle-timeout", Op: OpNotEqual, Value: "0",
+		Default: "4h0m0s", Level: warn},
+	{ID: "4.2.6", Component: kubelet, Arg: "protect-kernel-defaults", Op: OpEqual, Value: "true", Default: "false",
+		Level: warn},
+	{ID: "4.2.7", Component: kubelet, Arg: "make-iptables-util-chains", Op: OpEqual, Value: "true", Default: "true",
+		Level: warn},
+	{ID: "4.2.13", Component: kubelet, Arg: "tls-cipher-suites", Op: OpSubset, Values: strongCiphers, Level: warn},
+}
@@ -0,0 +1,253 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package cis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "cis-benchmark"
+
+	// OpEqual means the argument must equal to Value
+	OpEqual = "equal"
+	// OpNotEqual means the argument must not equal to Value
+	OpNotEqual = "not-equal"
+	// OpSet means the argument must be set
+	OpSet = "set"
+	// OpNotSet means the argument must not be set
+	OpNotSet = "not-set"
+	// OpContains means the comma separated argument must contain Value
+	OpContains = "contains"
+	// OpNotContains means the comma separated argument must not contain Value
+	OpNotContains = "not-contains"
+	// OpMin means the argument must be a number not less than Value
+	OpMin = "min"
+	// OpSubset means the comma separated argument must be set and only contain items in Values
+	OpSubset = "subset"
+)
+
+// Check is a CIS Kubernetes Benchmark recommendation on one argument of a component
+type Check struct {
+	// ID is the recommendation ID in CIS Kubernetes Benchmark, e.g. "1.2.1"
+	ID string
+	// Component is the name of target component, e.g. "kube-apiserver"
+	Component string
+	// Arg is the argument name without "--"
+	Arg string
+	// Op is the comparison operator, see Op* constants
+	Op string
+	// Value is the expected value of all operators except "set", "not-set" and "subset"
+	Value string
+	// Values is the allowed items of "subset"
+	Values []string
+	// Default is the value used by component if Arg is not set
+	Default string
+	// Level is the default HealthyLevel if the check failed
+	Level diagnose.HealthyLevel
+}
+
+// Pass return true if args of component meet the check
+func (c *Check) Pass(args map[string]string) bool {
+	val, exist := args[c.Arg]
+	switch c.Op {
+	case OpSet:
+		return exist && val != ""
+	case OpNotSet:
+		return !exist || val == ""
+	}
+
+	if !exist {
+		val = c.Default
+	}
+
+	switch c.Op {
+	case OpEqual:
+		return val == c.Value
+	case OpNotEqual:
+		return val != c.Value
+	case OpContains:
+		return contains(val, c.Value)
+	case OpNotContains:
+		return !contains(val, c.Value)
+	case OpMin:
+		cur, err := strconv.ParseInt(val, 10, 64)
+		target, _ := strconv.ParseInt(c.Value, 10, 64)
+		return err == nil && cur >= target
+	case OpSubset:
+		if val == "" {
+			return false
+		}
+		for _, item := range strings.Split(val, ",") {
+			if !contains(strings.Join(c.Values, ","), item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// contains return true if target is an item of comma separated list
+func contains(list string, target string) bool {
+	for _, item := range strings.Split(list, ",") {
+		if strings.TrimSpace(item) == strings.TrimSpace(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnostic check arguments of core components with CIS Kubernetes Benchmark
+type Diagnostic struct {
+	*diagnose.MetaData
+	// Levels overwrite the level of checks, the key is recommendation ID
+	Levels map[string]diagnose.HealthyLevel
+	// Skip is the recommendation IDs that will not be checked
+	Skip   []string
+	checks []Check
+	result chan *diagnose.Result
+}
+
+// NewDiagnostic return a cis-benchmark diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	ids := map[string]bool{}
+	for _, c := range Checks {
+		ids[c.ID] = true
+	}
+
+	skip := map[string]bool{}
+	for _, id := range d.Skip {
+		if !ids[id] {
+			return fmt.Errorf("unknown recommendation %s in skip", id)
+		}
+		skip[id] = true
+	}
+
+	for id, level := range d.Levels {
+		if !ids[id] {
+			return fmt.Errorf("unknown recommendation %s in levels", id)
+		}
+
+		if !level.Verify() {
+			return fmt.Errorf("level %s of recommendation %s is illegal", level, id)
+		}
+	}
+
+	d.checks = make([]Check, 0, len(Checks))
+	for _, c := range Checks {
+		if skip[c.ID] {
+			continue
+		}
+
+		if level, exist := d.Levels[c.ID]; exist {
+			c.Level = level
+		}
+		d.checks = append(d.checks, c)
+	}
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"cis-title", "cis-good-desc"}
+	for _, op := range []string{OpEqual, OpNotEqual, OpSet, OpNotSet, OpContains, OpNotContains, OpMin, OpSubset} {
+		ids = append(ids, fmt.Sprintf("cis-%s-desc", op), fmt.Sprintf("cis-%s-proposal", op))
+	}
+	return ids
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		for _, c := range d.checks {
+			for _, comp := range param.Resources.CoreComponents[c.Component] {
+				d.checkOne(c, comp)
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) checkOne(c Check, comp cluster.Component) {
+	if comp.Error != nil || !comp.IsRunning {
+		return
+	}
+
+	val, exist := comp.Args[c.Arg]
+	// kubelet may be configured via config file, which is not explored
+	if !exist && c.Component == cluster.ComponentKubelet && comp.Args["config"] != "" {
+		return
+	}
+
+	if !exist {
+		val = c.Default
+	}
+
+	obj := map[string]interface{}{
+		"ID":        c.ID,
+		"Component": c.Component,
+		"Name":      comp.Name,
+		"Node":      comp.Node,
+		"Arg":       c.Arg,
+		"CurVal":    val,
+		"Value":     c.Value,
+	}
+
+	if c.Op == OpSubset {
+		obj["Value"] = strings.Join(c.Values, ",")
+	}
+
+	level := diagnose.HealthyLevelGood
+	desc := d.Translator.Message("cis-good-desc", obj)
+	proposal := translate.Message{}
+	if !c.Pass(comp.Args) {
+		level = c.Level
+		desc = d.Translator.Message(fmt.Sprintf("cis-%s-desc", c.Op), obj)
+		proposal = d.Translator.Message(fmt.Sprintf("cis-%s-proposal", c.Op), obj)
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    level,
+		ObjName:  comp.Name,
+		Obj:      diagnose.NewComponentRef(&comp),
+		ObjInfo:  obj,
+		Title:    d.Translator.Message("cis-title", obj),
+		Desc:     desc,
+		Proposal: proposal,
+	}
+}
@@ -0,0 +1,231 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package cis
+
+import (
+	"context"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestCheck_Pass(t *testing.T) {
+	var cases = []struct {
+		name  string
+		check Check
+		args  map[string]string
+		pass  bool
+	}{
+		{
+			name:  "equal with default",
+			check: Check{Arg: "profiling", Op: OpEqual, Value: "false", Default: "true"},
+			pass:  false,
+		},
+		{
+			name:  "equal",
+			check: Check{Arg: "profiling", Op: OpEqual, Value: "false", Default: "true"},
+			args:  map[string]string{"profiling": "false"},
+			pass:  true,
+		},
+		{
+			name:  "not-equal",
+			check: Check{Arg: "auto-tls", Op: OpNotEqual, Value: "true", Default: "false"},
+			pass:  true,
+		},
+		{
+			name:  "set",
+			check: Check{Arg: "audit-log-path", Op: OpSet},
+			args:  map[string]string{"audit-log-path": ""},
+			pass:  false,
+		},
+		{
+			name:  "not-set",
+			check: Check{Arg: "token-auth-file", Op: OpNotSet},
+			args:  map[string]string{"token-auth-file": "/etc/token.csv"},
+			pass:  false,
+		},
+		{
+			name:  "contains",
+			check: Check{Arg: "authorization-mode", Op: OpContains, Value: "RBAC", Default: "AlwaysAllow"},
+			args:  map[string]string{"authorization-mode": "Node, RBAC"},
+			pass:  true,
+		},
+		{
+			name:  "not-contains with default",
+			check: Check{Arg: "authorization-mode", Op: OpNotContains, Value: "AlwaysAllow", Default: "AlwaysAllow"},
+			pass:  false,
+		},
+		{
+			name:  "min",
+			check: Check{Arg: "audit-log-maxage", Op: OpMin, Value: "30", Default: "0"},
+			args:  map[string]string{"audit-log-maxage": "7"},
+			pass:  false,
+		},
+		{
+			name:  "subset",
+			check: Check{Arg: "tls-cipher-suites", Op: OpSubset, Values: strongCiphers},
+			args:  map[string]string{"tls-cipher-suites": "TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_128_GCM_SHA256"},
+			pass:  true,
+		},
+		{
+			name:  "subset with weak item",
+			check: Check{Arg: "tls-cipher-suites", Op: OpSubset, Values: strongCiphers},
+			args:  map[string]string{"tls-cipher-suites": "TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_RC4_128_SHA"},
+			pass:  false,
+		},
+		{
+			name:  "subset not set",
+			check: Check{Arg: "tls-cipher-suites", Op: OpSubset, Values: strongCiphers},
+			pass:  false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			if cs.check.Pass(cs.args) != cs.pass {
+				t.Fatalf("want pass %v but not", cs.pass)
+			}
+		})
+	}
+}
+
+func TestChecks(t *testing.T) {
+	ops := map[string]bool{OpEqual: true, OpNotEqual: true, OpSet: true, OpNotSet: true,
+		OpContains: true, OpNotContains: true, OpMin: true, OpSubset: true}
+	for _, c := range Checks {
+		if !ops[c.Op] || !c.Level.Verify() || c.ID == "" || c.Arg == "" {
+			t.Fatalf("illegal check %+v", c)
+		}
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		name   string
+		levels map[string]diagnose.HealthyLevel
+		skip   []string
+		pass   bool
+	}{
+		{
+			name:   "normal",
+			levels: map[string]diagnose.HealthyLevel{"1.2.1": diagnose.HealthyLevelSerious},
+			skip:   []string{"1.2.33"},
+			pass:   true,
+		},
+		{
+			name:   "unknown level id",
+			levels: map[string]diagnose.HealthyLevel{"9.9": diagnose.HealthyLevelWarn},
+		},
+		{
+			name:   "illegal level",
+			levels: map[string]diagnose.HealthyLevel{"1.2.1": "bad"},
+		},
+		{
+			name: "unknown skip id",
+			skip: []string{"9.9"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+			d.Levels = cs.levels
+			d.Skip = cs.skip
+			err := d.Complete()
+			if (err == nil) != cs.pass {
+				t.Fatalf("want pass %v but get err %v", cs.pass, err)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	res := cluster.NewResources()
+	res.CoreComponents[cluster.ComponentApiserver] = []cluster.Component{
+		{
+			Name:      "kube-apiserver-master1",
+			Node:      "master1",
+			IsRunning: true,
+			Args: map[string]string{
+				"anonymous-auth":     "false",
+				"authorization-mode": "Node,RBAC",
+			},
+		},
+	}
+	res.CoreComponents[cluster.ComponentKubelet] = []cluster.Component{
+		{
+			Name:      "kubelet",
+			Node:      "node1",
+			IsRunning: true,
+			Args: map[string]string{
+				"config":         "/var/lib/kubelet/config.yaml",
+				"anonymous-auth": "true",
+			},
+		},
+	}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	d.Levels = map[string]diagnose.HealthyLevel{"1.2.21": diagnose.HealthyLevelSerious}
+	d.Skip = []string{"1.2.9"}
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	for r := range results {
+		got[r.ObjInfo["ID"].(string)+"/"+r.ObjInfo["Arg"].(string)] = r.Level
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"1.2.1/anonymous-auth":     diagnose.HealthyLevelGood,
+		"1.2.7/authorization-mode": diagnose.HealthyLevelGood,
+		"1.2.21/profiling":         diagnose.HealthyLevelSerious,
+		"1.2.19/insecure-port":     diagnose.HealthyLevelSerious,
+		"4.2.1/anonymous-auth":     diagnose.HealthyLevelRisk,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+
+	if _, exist := got["1.2.9/authorization-mode"]; exist {
+		t.Fatalf("1.2.9 should be skipped")
+	}
+
+	// kubelet uses config file, unset arguments are not checked
+	if _, exist := got["4.2.4/read-only-port"]; exist {
+		t.Fatalf("4.2.4 should not be checked")
+	}
+}
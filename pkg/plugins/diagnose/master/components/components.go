@@ -25,6 +25,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
 )
 
 const (
@@ -78,6 +79,15 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := make([]string, 0)
+	for _, prefix := range []string{"err", "not-run", "restart", "good"} {
+		ids = append(ids, prefix+"-title", prefix+"-desc", prefix+"-proposal")
+	}
+	return ids
+}
+
 func isMasterCoreComp(comp string) bool {
 	return comp == cluster.ComponentApiserver ||
 		comp == cluster.ComponentScheduler ||
@@ -158,8 +168,8 @@ func (d *Diagnostic) sendCompNotExist(comp string) {
 	d.result <- &diagnose.Result{
 		Level:   diagnose.HealthyLevelFailed,
 		ObjName: comp,
-		Title:   "Failed",
-		Desc:    "can not found target component info",
+		Title:   translate.Literal("Failed"),
+		Desc:    translate.Literal("can not found target component info"),
 	}
 }
 
@@ -178,6 +188,7 @@ func (d *Diagnostic) sendNormalResult(comp string, inf *cluster.Component,
 	d.result <- &diagnose.Result{
 		Level:    level,
 		ObjName:  inf.Name,
+		Obj:      diagnose.NewComponentRef(inf),
 		ObjInfo:  obj,
 		Title:    d.Translator.Message(preFix+"-title", obj),
 		Desc:     d.Translator.Message(preFix+"-desc", obj),
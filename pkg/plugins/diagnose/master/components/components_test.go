@@ -120,7 +120,7 @@ func TestDiagnostic_StartDiagnose(t *testing.T) {
 						t.Fatalf("should return an risk result")
 					}
 
-					if r.Desc != "not-run-desc" {
+					if r.Desc.ID != "not-run-desc" {
 						t.Fatalf("should get not-run-desc")
 					}
 
@@ -133,7 +133,7 @@ func TestDiagnostic_StartDiagnose(t *testing.T) {
 							t.Fatalf("should return an result result")
 						}
 
-						if r.Desc != "restart-desc" {
+						if r.Desc.ID != "restart-desc" {
 							t.Fatalf("should get restart-desc")
 						}
 					} else {
@@ -141,7 +141,7 @@ func TestDiagnostic_StartDiagnose(t *testing.T) {
 							t.Fatalf("should return an good result")
 						}
 
-						if r.Desc != "good-desc" {
+						if r.Desc.ID != "good-desc" {
 							t.Fatalf("should get good-desc")
 						}
 					}
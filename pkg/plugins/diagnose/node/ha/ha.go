@@ -51,6 +51,15 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := make([]string, 0)
+	for _, objName := range []string{"node-num", "node-zone"} {
+		ids = append(ids, objName+"-title", objName+"-good-desc", objName+"-bad-desc", objName+"-proposal")
+	}
+	return ids
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
 	d.result = make(chan *diagnose.Result, 1000)
@@ -157,7 +166,7 @@ func (d *Diagnostic) sendFailedResult(objName string, err error) {
 			"ResourceName":    "none",
 			"CurTotalZoneNum": 0,
 		},
-		Title: "Failed",
-		Desc:  translate.Message(err.Error()),
+		Title: translate.Literal("Failed"),
+		Desc:  translate.Literal(err.Error()),
 	}
 }
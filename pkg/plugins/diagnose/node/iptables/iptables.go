@@ -19,6 +19,7 @@ package iptables
 
 import (
 	"context"
+	"strings"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
@@ -53,70 +54,174 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"iptables-count-title", "iptables-count-desc", "iptables-count-group-desc",
+		"iptables-count-proposal", "iptables-forward-policy-title", "iptables-forward-policy-desc",
+		"iptables-forward-policy-group-desc", "iptables-forward-policy-good-desc",
+		"iptables-forward-policy-group-good-desc", "iptables-forward-policy-proposal"}
+	for _, name := range []string{"iptables-kube-chains", "iptables-sync", "iptables-services", "iptables-shadow"} {
+		ids = append(ids, name+"-title", name+"-desc", name+"-group-desc", name+"-good-desc",
+			name+"-group-good-desc", name+"-proposal")
+	}
+	return ids
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
 	d.result = make(chan *diagnose.Result, 1000)
 	d.param = &param
 	go func() {
 		defer diagnose.CommonDeafer(d.result)
-		for node, m := range d.param.Resources.Machines {
-			var cntLevel diagnose.HealthyLevel
-			totalCount := m.IPTables.Filter.Count + m.IPTables.NAT.Count
-			if totalCount < GoodIPTablesCount {
-				cntLevel = diagnose.HealthyLevelGood
-			} else if totalCount < WarnIPTablesCount {
-				cntLevel = diagnose.HealthyLevelWarn
-			} else if totalCount < RiskIPTablesCount {
-				cntLevel = diagnose.HealthyLevelRisk
-			} else {
-				cntLevel = diagnose.HealthyLevelSerious
-			}
-			if cntLevel != diagnose.HealthyLevelGood {
-				obj := map[string]interface{}{
-					"Node":           node,
-					"Name":           "iptables-count",
-					"SuggestedCount": GoodIPTablesCount,
-					"CurCount":       totalCount,
-				}
-
-				d.result <- &diagnose.Result{
-					Level:    cntLevel,
-					Title:    d.Translator.Message("iptables-count-title", nil),
-					ObjName:  node,
-					ObjInfo:  obj,
-					Desc:     d.Translator.Message("iptables-count-desc", obj),
-					Proposal: d.Translator.Message("iptables-count-proposal", obj),
+		checks := d.kubeChecks()
+		// machines are collected by sampling, report results per group
+		if len(d.param.Resources.MachineGroups) != 0 {
+			for _, g := range d.param.Resources.MachineGroups {
+				d.diagnoseGroup(g)
+				for _, c := range checks {
+					d.diagnoseKubeGroup(c, g)
 				}
 			}
+			return
+		}
 
-			obj := map[string]interface{}{
-				"Node":            node,
-				"Name":            "iptables-forward-policy",
-				"CurPolicy":       m.IPTables.Filter.ForwardPolicy,
-				"SuggestedPolicy": cluster.AcceptPolicy,
-			}
-
-			forwardPolicyLevel := diagnose.HealthyLevelGood
-			if m.IPTables.Filter.ForwardPolicy != cluster.AcceptPolicy {
-				forwardPolicyLevel = diagnose.HealthyLevelWarn
-				d.result <- &diagnose.Result{
-					Level:    forwardPolicyLevel,
-					Title:    d.Translator.Message("iptables-forward-policy-title", nil),
-					ObjName:  node,
-					ObjInfo:  obj,
-					Desc:     d.Translator.Message("iptables-forward-policy-desc", obj),
-					Proposal: d.Translator.Message("iptables-forward-policy-proposal", obj),
-				}
-			} else {
-				d.result <- &diagnose.Result{
-					Level:   forwardPolicyLevel,
-					Title:   d.Translator.Message("iptables-forward-policy-title", nil),
-					ObjName: node,
-					ObjInfo: obj,
-					Desc:    d.Translator.Message("iptables-forward-policy-good-desc", obj),
-				}
+		for node, m := range d.param.Resources.Machines {
+			d.diagnoseNode(node, m)
+			for _, c := range checks {
+				d.diagnoseKubeNode(c, node, m)
 			}
 		}
 	}()
 	return d.result, nil
 }
+
+// countLevel return the healthy level of iptables count
+func countLevel(count int) diagnose.HealthyLevel {
+	if count < GoodIPTablesCount {
+		return diagnose.HealthyLevelGood
+	} else if count < WarnIPTablesCount {
+		return diagnose.HealthyLevelWarn
+	} else if count < RiskIPTablesCount {
+		return diagnose.HealthyLevelRisk
+	}
+	return diagnose.HealthyLevelSerious
+}
+
+func (d *Diagnostic) diagnoseNode(node string, m cluster.Machine) {
+	totalCount := m.IPTables.Filter.Count + m.IPTables.NAT.Count
+	cntLevel := countLevel(totalCount)
+	if cntLevel != diagnose.HealthyLevelGood {
+		obj := map[string]interface{}{
+			"Node":           node,
+			"Name":           "iptables-count",
+			"SuggestedCount": GoodIPTablesCount,
+			"CurCount":       totalCount,
+		}
+
+		d.result <- &diagnose.Result{
+			Level:    cntLevel,
+			Title:    d.Translator.Message("iptables-count-title", nil),
+			ObjName:  node,
+			Obj:      diagnose.NewNodeRef(node),
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message("iptables-count-desc", obj),
+			Proposal: d.Translator.Message("iptables-count-proposal", obj),
+		}
+	}
+
+	obj := map[string]interface{}{
+		"Node":            node,
+		"Name":            "iptables-forward-policy",
+		"CurPolicy":       m.IPTables.Filter.ForwardPolicy,
+		"SuggestedPolicy": cluster.AcceptPolicy,
+	}
+
+	if m.IPTables.Filter.ForwardPolicy != cluster.AcceptPolicy {
+		d.result <- &diagnose.Result{
+			Level:    diagnose.HealthyLevelWarn,
+			Title:    d.Translator.Message("iptables-forward-policy-title", nil),
+			ObjName:  node,
+			Obj:      diagnose.NewNodeRef(node),
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message("iptables-forward-policy-desc", obj),
+			Proposal: d.Translator.Message("iptables-forward-policy-proposal", obj),
+		}
+	} else {
+		d.result <- &diagnose.Result{
+			Level:   diagnose.HealthyLevelGood,
+			Title:   d.Translator.Message("iptables-forward-policy-title", nil),
+			ObjName: node,
+			Obj:     diagnose.NewNodeRef(node),
+			ObjInfo: obj,
+			Desc:    d.Translator.Message("iptables-forward-policy-good-desc", obj),
+		}
+	}
+}
+
+// diagnoseGroup report the max iptables count and the nodes with bad forward policy of a group
+func (d *Diagnostic) diagnoseGroup(g cluster.MachineGroup) {
+	maxNode, maxCount := "", -1
+	badNodes := make([]string, 0)
+	var curPolicy cluster.IPTablesChainPolicy
+	for _, node := range g.Sampled {
+		m := d.param.Resources.Machines[node]
+		if count := m.IPTables.Filter.Count + m.IPTables.NAT.Count; count > maxCount {
+			maxNode, maxCount = node, count
+		}
+
+		if m.IPTables.Filter.ForwardPolicy != cluster.AcceptPolicy {
+			badNodes = append(badNodes, node)
+			curPolicy = m.IPTables.Filter.ForwardPolicy
+		}
+	}
+
+	if cntLevel := countLevel(maxCount); maxNode != "" && cntLevel != diagnose.HealthyLevelGood {
+		obj := map[string]interface{}{
+			"Group":          g.Name,
+			"Sampled":        len(g.Sampled),
+			"Total":          len(g.Nodes),
+			"Node":           maxNode,
+			"Name":           "iptables-count",
+			"SuggestedCount": GoodIPTablesCount,
+			"CurCount":       maxCount,
+		}
+
+		d.result <- &diagnose.Result{
+			Level:    cntLevel,
+			Title:    d.Translator.Message("iptables-count-title", nil),
+			ObjName:  g.Name,
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message("iptables-count-group-desc", obj),
+			Proposal: d.Translator.Message("iptables-count-proposal", obj),
+		}
+	}
+
+	obj := map[string]interface{}{
+		"Group":           g.Name,
+		"Sampled":         len(g.Sampled),
+		"Total":           len(g.Nodes),
+		"Nodes":           strings.Join(badNodes, ","),
+		"Name":            "iptables-forward-policy",
+		"CurPolicy":       curPolicy,
+		"SuggestedPolicy": cluster.AcceptPolicy,
+	}
+
+	if len(badNodes) != 0 {
+		d.result <- &diagnose.Result{
+			Level:    diagnose.HealthyLevelWarn,
+			Title:    d.Translator.Message("iptables-forward-policy-title", nil),
+			ObjName:  g.Name,
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message("iptables-forward-policy-group-desc", obj),
+			Proposal: d.Translator.Message("iptables-forward-policy-proposal", obj),
+		}
+	} else {
+		d.result <- &diagnose.Result{
+			Level:   diagnose.HealthyLevelGood,
+			Title:   d.Translator.Message("iptables-forward-policy-title", nil),
+			ObjName: g.Name,
+			ObjInfo: obj,
+			Desc:    d.Translator.Message("iptables-forward-policy-group-good-desc", obj),
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package iptables
+
+import (
+	"context"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	machines := map[string]cluster.Machine{
+		"node1": {IPTables: cluster.IPTablesInfo{
+			Filter: cluster.FilterTable{Count: 10, ForwardPolicy: cluster.AcceptPolicy},
+		}},
+		"node2": {IPTables: cluster.IPTablesInfo{
+			Filter: cluster.FilterTable{Count: 10, ForwardPolicy: cluster.DropPolicy},
+			NAT:    cluster.NATTable{Count: 7000},
+		}},
+	}
+
+	var cases = []struct {
+		name   string
+		groups []cluster.MachineGroup
+		want   map[string]diagnose.HealthyLevel
+	}{
+		{
+			name: "per node",
+			want: map[string]diagnose.HealthyLevel{
+				"node1/iptables-forward-policy": diagnose.HealthyLevelGood,
+				"node2/iptables-forward-policy": diagnose.HealthyLevelWarn,
+				"node2/iptables-count":          diagnose.HealthyLevelRisk,
+			},
+		},
+		{
+			name: "per group",
+			groups: []cluster.MachineGroup{
+				{Name: "pool=a", Nodes: []string{"node1"}, Sampled: []string{"node1"}},
+				{Name: "pool=b", Nodes: []string{"node1", "node2", "node3"}, Sampled: []string{"node1", "node2"}},
+			},
+			want: map[string]diagnose.HealthyLevel{
+				"pool=a/iptables-forward-policy": diagnose.HealthyLevelGood,
+				"pool=b/iptables-forward-policy": diagnose.HealthyLevelWarn,
+				"pool=b/iptables-count":          diagnose.HealthyLevelRisk,
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			res := cluster.NewResources()
+			res.Machines = machines
+			res.MachineGroups = cs.groups
+
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+			})
+
+			results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				CloudType: "fake",
+				Resources: res,
+			})
+
+			got := map[string]diagnose.HealthyLevel{}
+			for r := range results {
+				got[r.ObjName+"/"+r.ObjInfo["Name"].(string)] = r.Level
+			}
+
+			if len(got) != len(cs.want) {
+				t.Fatalf("want %v but get %v", cs.want, got)
+			}
+
+			for k, v := range cs.want {
+				if got[k] != v {
+					t.Fatalf("want %s level %s but get %s", k, v, got[k])
+				}
+			}
+		})
+	}
+}
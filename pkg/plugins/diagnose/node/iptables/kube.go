@@ -0,0 +1,342 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package iptables
+
+import (
+	"fmt"
+	"strings"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// WarnServiceRules is the KUBE-SERVICES rule count that kube-proxy of iptables mode starts to sync slowly
+	WarnServiceRules = 2000
+	// RiskServiceRules is the KUBE-SERVICES rule count that kube-proxy of iptables mode can hardly sync in time
+	RiskServiceRules = 5000
+	// maxShadowRules is the max number of shadowing rules shown in result
+	maxShadowRules = 3
+)
+
+// kubeJumps is the jump rules that kube-proxy prepends into built-in chains
+var kubeJumps = []struct {
+	table  string
+	chain  string
+	target string
+}{
+	{table: "nat", chain: "PREROUTING", target: "KUBE-SERVICES"},
+	{table: "nat", chain: "OUTPUT", target: "KUBE-SERVICES"},
+	{table: "nat", chain: "POSTROUTING", target: "KUBE-POSTROUTING"},
+	{table: "filter", chain: "FORWARD", target: "KUBE-FORWARD"},
+}
+
+// kubeChains is the chains that kube-proxy creates in both iptables and ipvs mode
+var kubeChains = []string{"KUBE-SERVICES", "KUBE-POSTROUTING", "KUBE-MARK-MASQ"}
+
+// terminalTargets is the targets that stop traversing chain
+var terminalTargets = []string{"ACCEPT", "DROP", "REJECT", "RETURN", "DNAT", "REDIRECT"}
+
+// kubeCheck is a check on the iptables of one machine
+// skip is true if the check is not applicable for the machine
+type kubeCheck struct {
+	name string
+	do   func(m cluster.Machine) (level diagnose.HealthyLevel, info map[string]interface{}, skip bool)
+}
+
+// kubeChecks return the kube-proxy related checks, nil is returned if kube-proxy is not found on any machine
+func (d *Diagnostic) kubeChecks() []kubeCheck {
+	counts := map[int]int{}
+	found := false
+	for _, m := range d.param.Resources.Machines {
+		if _, exist := m.IPTables.Chain("nat", "KUBE-SERVICES"); exist {
+			found = true
+			if !ipvsUnknown(m) {
+				counts[proxyServices(m)]++
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	// most nodes should be synced, so the common value is the expected one
+	expected, max := 0, -1
+	for count, nodes := range counts {
+		if nodes > max || (nodes == max && count > expected) {
+			expected, max = count, nodes
+		}
+	}
+
+	return []kubeCheck{
+		{name: "iptables-kube-chains", do: checkKubeChains},
+		{name: "iptables-sync", do: func(m cluster.Machine) (diagnose.HealthyLevel, map[string]interface{}, bool) {
+			return checkSync(m, expected)
+		}},
+		{name: "iptables-services", do: checkServiceRules},
+		{name: "iptables-shadow", do: checkShadow},
+	}
+}
+
+// ipvsMode return true if kube-proxy of the machine runs in ipvs mode
+func ipvsMode(m cluster.Machine) bool {
+	ipvs := cluster.IPVSFact{}
+	if ok, err := m.Fact(cluster.FactIPVS, &ipvs); !ok || err != nil {
+		return false
+	}
+	return len(ipvs.Services) != 0
+}
+
+// ipvsUnknown return true if the ipvs fact of the machine failed to collect, e.g. ipvsadm is not installed
+// the proxy mode of machine is unknown in this case
+func ipvsUnknown(m cluster.Machine) bool {
+	_, failed := m.FactErrors[cluster.FactIPVS]
+	return failed
+}
+
+// proxyServices return the number of services ports that kube-proxy synced to the machine
+func proxyServices(m cluster.Machine) int {
+	if ipvsMode(m) {
+		ipvs := cluster.IPVSFact{}
+		_, _ = m.Fact(cluster.FactIPVS, &ipvs)
+		return len(ipvs.Services)
+	}
+
+	count := 0
+	for name := range m.IPTables.Tables["nat"].Chains {
+		if strings.HasPrefix(name, "KUBE-SVC-") {
+			count++
+		}
+	}
+	return count
+}
+
+// jumpIndex return the index of the first rule that jump to target, -1 is returned if not found
+func jumpIndex(rules []string, target string) int {
+	for i, r := range rules {
+		if ruleTarget(r) == target {
+			return i
+		}
+	}
+	return -1
+}
+
+// ruleTarget return the target of "-j"
+func ruleTarget(rule string) string {
+	fields := strings.Fields(rule)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "-j" || fields[i] == "--jump" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func checkKubeChains(m cluster.Machine) (diagnose.HealthyLevel, map[string]interface{}, bool) {
+	if m.IPTables.Tables == nil {
+		return "", nil, true
+	}
+
+	missing := make([]string, 0)
+	for _, c := range kubeChains {
+		if _, exist := m.IPTables.Chain("nat", c); !exist {
+			missing = append(missing, "nat/"+c)
+		}
+	}
+
+	for _, j := range kubeJumps {
+		// KUBE-FORWARD is not created by old versions
+		if _, exist := m.IPTables.Chain(j.table, j.target); !exist && j.target == "KUBE-FORWARD" {
+			continue
+		}
+
+		chain, _ := m.IPTables.Chain(j.table, j.chain)
+		if jumpIndex(chain.Rules, j.target) == -1 {
+			missing = append(missing, fmt.Sprintf("%s/%s -j %s", j.table, j.chain, j.target))
+		}
+	}
+
+	level := diagnose.HealthyLevelGood
+	if len(missing) != 0 {
+		level = diagnose.HealthyLevelRisk
+	}
+
+	return level, map[string]interface{}{
+		"Missing": strings.Join(missing, ", "),
+	}, false
+}
+
+func checkSync(m cluster.Machine, expected int) (diagnose.HealthyLevel, map[string]interface{}, bool) {
+	if _, exist := m.IPTables.Chain("nat", "KUBE-SERVICES"); !exist || ipvsUnknown(m) {
+		return "", nil, true
+	}
+
+	mode := "iptables"
+	if ipvsMode(m) {
+		mode = "ipvs"
+	}
+
+	cur := proxyServices(m)
+	level := diagnose.HealthyLevelGood
+	if cur != expected {
+		level = diagnose.HealthyLevelWarn
+	}
+
+	return level, map[string]interface{}{
+		"Mode":          mode,
+		"CurServices":   cur,
+		"ExpectedCount": expected,
+	}, false
+}
+
+func checkServiceRules(m cluster.Machine) (diagnose.HealthyLevel, map[string]interface{}, bool) {
+	svc, exist := m.IPTables.Chain("nat", "KUBE-SERVICES")
+	if !exist || ipvsMode(m) || ipvsUnknown(m) {
+		return "", nil, true
+	}
+
+	level := diagnose.HealthyLevelGood
+	if svc.Count >= RiskServiceRules {
+		level = diagnose.HealthyLevelRisk
+	} else if svc.Count >= WarnServiceRules {
+		level = diagnose.HealthyLevelWarn
+	}
+
+	return level, map[string]interface{}{
+		"CurCount":       svc.Count,
+		"SuggestedCount": WarnServiceRules,
+		"SvcChains":      proxyServices(m),
+	}, false
+}
+
+func checkShadow(m cluster.Machine) (diagnose.HealthyLevel, map[string]interface{}, bool) {
+	if m.IPTables.Tables == nil {
+		return "", nil, true
+	}
+
+	shadows := make([]string, 0)
+	for _, j := range kubeJumps {
+		chain, _ := m.IPTables.Chain(j.table, j.chain)
+		idx := jumpIndex(chain.Rules, j.target)
+		for i := 0; i < idx; i++ {
+			target := ruleTarget(chain.Rules[i])
+			for _, t := range terminalTargets {
+				if target == t {
+					shadows = append(shadows, fmt.Sprintf("%s/%s: %s", j.table, j.chain, chain.Rules[i]))
+					break
+				}
+			}
+		}
+	}
+
+	level := diagnose.HealthyLevelGood
+	total := len(shadows)
+	if total != 0 {
+		level = diagnose.HealthyLevelWarn
+	}
+
+	if total > maxShadowRules {
+		shadows = shadows[:maxShadowRules]
+	}
+
+	return level, map[string]interface{}{
+		"Count": total,
+		"Rules": strings.Join(shadows, "; "),
+	}, false
+}
+
+// diagnoseKubeNode run check on one machine
+func (d *Diagnostic) diagnoseKubeNode(c kubeCheck, node string, m cluster.Machine) {
+	level, obj, skip := c.do(m)
+	if skip {
+		return
+	}
+
+	obj["Node"] = node
+	obj["Name"] = c.name
+	if level != diagnose.HealthyLevelGood {
+		d.result <- &diagnose.Result{
+			Level:    level,
+			Title:    d.Translator.Message(c.name+"-title", nil),
+			ObjName:  node,
+			Obj:      diagnose.NewNodeRef(node),
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message(c.name+"-desc", obj),
+			Proposal: d.Translator.Message(c.name+"-proposal", obj),
+		}
+	} else {
+		d.result <- &diagnose.Result{
+			Level:   level,
+			Title:   d.Translator.Message(c.name+"-title", nil),
+			ObjName: node,
+			Obj:     diagnose.NewNodeRef(node),
+			ObjInfo: obj,
+			Desc:    d.Translator.Message(c.name+"-good-desc", obj),
+		}
+	}
+}
+
+// diagnoseKubeGroup run check on sampled nodes of group, the information of the worst node is reported
+func (d *Diagnostic) diagnoseKubeGroup(c kubeCheck, g cluster.MachineGroup) {
+	var obj map[string]interface{}
+	level := diagnose.HealthyLevelGood
+	badNodes := make([]string, 0)
+	for _, node := range g.Sampled {
+		l, info, skip := c.do(d.param.Resources.Machines[node])
+		if skip {
+			continue
+		}
+
+		if l != diagnose.HealthyLevelGood {
+			badNodes = append(badNodes, node)
+		}
+
+		if obj == nil || l.Compare(level) < 0 {
+			level, obj = l, info
+		}
+	}
+
+	if obj == nil {
+		return
+	}
+
+	obj["Group"] = g.Name
+	obj["Sampled"] = len(g.Sampled)
+	obj["Total"] = len(g.Nodes)
+	obj["Nodes"] = strings.Join(badNodes, ",")
+	obj["Name"] = c.name
+	if level != diagnose.HealthyLevelGood {
+		d.result <- &diagnose.Result{
+			Level:    level,
+			Title:    d.Translator.Message(c.name+"-title", nil),
+			ObjName:  g.Name,
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message(c.name+"-group-desc", obj),
+			Proposal: d.Translator.Message(c.name+"-proposal", obj),
+		}
+	} else {
+		d.result <- &diagnose.Result{
+			Level:   level,
+			Title:   d.Translator.Message(c.name+"-title", nil),
+			ObjName: g.Name,
+			ObjInfo: obj,
+			Desc:    d.Translator.Message(c.name+"-group-good-desc", obj),
+		}
+	}
+}
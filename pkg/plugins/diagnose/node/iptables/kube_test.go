@@ -0,0 +1,203 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package iptables
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+// kubeMachine return a machine with chains of kube-proxy, modify can change the tables before return
+func kubeMachine(modify func(nat, filter map[string]cluster.IPTablesChain)) cluster.Machine {
+	nat := map[string]cluster.IPTablesChain{
+		"PREROUTING":       {Policy: "ACCEPT", Count: 1, Rules: []string{"-j KUBE-SERVICES"}},
+		"OUTPUT":           {Policy: "ACCEPT", Count: 1, Rules: []string{"-j KUBE-SERVICES"}},
+		"POSTROUTING":      {Policy: "ACCEPT", Count: 1, Rules: []string{"-j KUBE-POSTROUTING"}},
+		"KUBE-SERVICES":    {Policy: "-", Count: 10},
+		"KUBE-POSTROUTING": {Policy: "-", Count: 1},
+		"KUBE-MARK-MASQ":   {Policy: "-", Count: 1},
+		"KUBE-SVC-A":       {Policy: "-", Count: 1},
+		"KUBE-SVC-B":       {Policy: "-", Count: 1},
+	}
+	filter := map[string]cluster.IPTablesChain{
+		"FORWARD": {Policy: "ACCEPT", Count: 2, Rules: []string{
+			"-m comment --comment \"kubernetes forwarding rules\" -j KUBE-FORWARD",
+			"-o docker0 -j DOCKER",
+		}},
+		"KUBE-FORWARD": {Policy: "-", Count: 1},
+	}
+
+	if modify != nil {
+		modify(nat, filter)
+	}
+
+	return cluster.Machine{IPTables: cluster.IPTablesInfo{
+		Filter: cluster.FilterTable{Count: 10, ForwardPolicy: cluster.AcceptPolicy},
+		Tables: map[string]cluster.IPTablesTable{
+			"nat":    {Chains: nat},
+			"filter": {Chains: filter},
+		},
+	}}
+}
+
+func TestDiagnostic_KubeChecks(t *testing.T) {
+	bad := kubeMachine(func(nat, filter map[string]cluster.IPTablesChain) {
+		delete(nat, "KUBE-SVC-B")
+		delete(nat, "KUBE-MARK-MASQ")
+		nat["KUBE-SERVICES"] = cluster.IPTablesChain{Policy: "-", Count: 6000}
+		nat["PREROUTING"] = cluster.IPTablesChain{Policy: "ACCEPT", Count: 2, Rules: []string{
+			"-s 10.0.0.0/8 -j ACCEPT",
+			"-j KUBE-SERVICES",
+		}}
+	})
+
+	ipvs := kubeMachine(func(nat, filter map[string]cluster.IPTablesChain) {
+		delete(nat, "KUBE-SVC-A")
+		delete(nat, "KUBE-SVC-B")
+		nat["KUBE-SERVICES"] = cluster.IPTablesChain{Policy: "-", Count: 10000}
+	})
+	ipvs.Facts = map[string]interface{}{
+		cluster.FactIPVS: cluster.IPVSFact{Available: true, Services: []cluster.IPVSService{
+			{Protocol: "TCP", Address: "10.96.0.1:443"},
+			{Protocol: "UDP", Address: "10.96.0.10:53"},
+		}},
+	}
+
+	// ipvsadm is not installed, proxy mode of node5 is unknown
+	unknown := kubeMachine(func(nat, filter map[string]cluster.IPTablesChain) {
+		delete(nat, "KUBE-SVC-A")
+		delete(nat, "KUBE-SVC-B")
+		nat["KUBE-SERVICES"] = cluster.IPTablesChain{Policy: "-", Count: 10000}
+	})
+	unknown.FactErrors = map[string]string{
+		cluster.FactIPVS: "command terminated with exit code 127",
+	}
+
+	machines := map[string]cluster.Machine{
+		"node1": kubeMachine(nil),
+		"node2": kubeMachine(nil),
+		"node3": bad,
+		"node4": ipvs,
+		"node5": unknown,
+	}
+
+	var cases = []struct {
+		name   string
+		groups []cluster.MachineGroup
+		want   map[string]diagnose.HealthyLevel
+	}{
+		{
+			name: "per node",
+			want: map[string]diagnose.HealthyLevel{
+				"node1/iptables-kube-chains": diagnose.HealthyLevelGood,
+				"node1/iptables-sync":        diagnose.HealthyLevelGood,
+				"node1/iptables-services":    diagnose.HealthyLevelGood,
+				"node1/iptables-shadow":      diagnose.HealthyLevelGood,
+				"node3/iptables-kube-chains": diagnose.HealthyLevelRisk,
+				"node3/iptables-sync":        diagnose.HealthyLevelWarn,
+				"node3/iptables-services":    diagnose.HealthyLevelRisk,
+				"node3/iptables-shadow":      diagnose.HealthyLevelWarn,
+				"node4/iptables-kube-chains": diagnose.HealthyLevelGood,
+				"node4/iptables-sync":        diagnose.HealthyLevelGood,
+				"node4/iptables-shadow":      diagnose.HealthyLevelGood,
+				"node5/iptables-kube-chains": diagnose.HealthyLevelGood,
+				"node5/iptables-shadow":      diagnose.HealthyLevelGood,
+			},
+		},
+		{
+			name: "per group",
+			groups: []cluster.MachineGroup{
+				{Name: "pool=a", Nodes: []string{"node1", "node3"}, Sampled: []string{"node1", "node3"}},
+			},
+			want: map[string]diagnose.HealthyLevel{
+				"pool=a/iptables-kube-chains": diagnose.HealthyLevelRisk,
+				"pool=a/iptables-sync":        diagnose.HealthyLevelWarn,
+				"pool=a/iptables-services":    diagnose.HealthyLevelRisk,
+				"pool=a/iptables-shadow":      diagnose.HealthyLevelWarn,
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			res := cluster.NewResources()
+			res.Machines = machines
+			res.MachineGroups = cs.groups
+
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+			})
+
+			results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				CloudType: "fake",
+				Resources: res,
+			})
+
+			for r := range results {
+				key := fmt.Sprintf("%s/%s", r.ObjName, r.ObjInfo["Name"])
+				want, exist := cs.want[key]
+				// node2 is same as node1
+				if r.ObjName == "node2" || r.ObjInfo["Name"] == "iptables-forward-policy" {
+					continue
+				}
+
+				if !exist {
+					t.Fatalf("unexpected result %s", key)
+				}
+
+				if r.Level != want {
+					t.Fatalf("want %s level %s but get %s", key, want, r.Level)
+				}
+				delete(cs.want, key)
+			}
+
+			if len(cs.want) != 0 {
+				t.Fatalf("results %v not found", cs.want)
+			}
+		})
+	}
+}
+
+func TestRuleTarget(t *testing.T) {
+	var cases = []struct {
+		rule   string
+		target string
+	}{
+		{rule: "-j KUBE-SERVICES", target: "KUBE-SERVICES"},
+		{rule: "-s 10.0.0.0/8 --jump ACCEPT", target: "ACCEPT"},
+		{rule: "-s 10.0.0.0/8", target: ""},
+	}
+
+	for _, cs := range cases {
+		if got := ruleTarget(cs.rule); got != cs.target {
+			t.Fatalf("rule %s want target %s but get %s", cs.rule, cs.target, got)
+		}
+	}
+}
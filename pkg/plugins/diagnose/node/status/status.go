@@ -38,6 +38,16 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := make([]string, 0)
+	for _, prefix := range []string{"master", "node"} {
+		ids = append(ids, prefix+"-status-title", prefix+"-status-desc", prefix+"-status-proposal",
+			prefix+"-status-good-desc", prefix+"-status-good-proposal")
+	}
+	return ids
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
 	d.param = &param
@@ -58,25 +68,26 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiag
 			}
 			for _, cond := range node.Status.Conditions {
 				if cond.Status == v1.ConditionUnknown {
-					d.uploadResult(isMaster, node.Name, v1.NodeReady, cond.Status, levelBad)
+					d.uploadResult(isMaster, &node, v1.NodeReady, cond.Status, levelBad)
 					isHealth = false
 					break
 				}
 				if (cond.Type == v1.NodeReady && cond.Status != v1.ConditionTrue) || (cond.Type != v1.NodeReady && cond.Status == v1.ConditionTrue) {
-					d.uploadResult(isMaster, node.Name, cond.Type, cond.Status, levelBad)
+					d.uploadResult(isMaster, &node, cond.Type, cond.Status, levelBad)
 					isHealth = false
 					break
 				}
 			}
 			if isHealth {
-				d.uploadResult(isMaster, node.Name, v1.NodeReady, v1.ConditionTrue, levelGood)
+				d.uploadResult(isMaster, &node, v1.NodeReady, v1.ConditionTrue, levelGood)
 			}
 		}
 	}()
 	return d.result, nil
 }
 
-func (d *Diagnostic) uploadResult(isMaster bool, name string, typ v1.NodeConditionType, status v1.ConditionStatus, level diagnose.HealthyLevel) {
+func (d *Diagnostic) uploadResult(isMaster bool, node *v1.Node, typ v1.NodeConditionType, status v1.ConditionStatus, level diagnose.HealthyLevel) {
+	name := node.Name
 	resource := typ
 	prefix := "node"
 	goodFlag := ""
@@ -103,10 +114,13 @@ func (d *Diagnostic) uploadResult(isMaster bool, name string, typ v1.NodeConditi
 	desc := d.Translator.Message(prefix+"-status-"+goodFlag+"desc", obj)
 	proposal := d.Translator.Message(prefix+"-status-"+goodFlag+"proposal", obj)
 
+	ref := diagnose.NewNodeRef(name)
+	ref.UID = node.UID
 	d.result <- &diagnose.Result{
 		Level:    level,
 		Title:    title,
 		ObjName:  name,
+		Obj:      ref,
 		ObjInfo:  obj,
 		Desc:     desc,
 		Proposal: proposal,
@@ -207,7 +207,7 @@ func TestDiagnostic_StartDiagnose_Issue1(t *testing.T) {
 
 			got := want{}
 			for res := range ch {
-				if strings.Contains(string(res.Title), "master") {
+				if strings.Contains(res.Title.ID, "master") {
 					got.numMaster++
 					continue
 				}
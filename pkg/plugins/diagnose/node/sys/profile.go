@@ -0,0 +1,217 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package sys
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// OpEqual means the parameter must equal to Value
+	OpEqual = "equal"
+	// OpMin means the parameter must be a number not less than Value
+	OpMin = "min"
+	// OpMax means the parameter must be a number not greater than Value
+	OpMax = "max"
+	// OpOneOf means the parameter must be one of Values
+	OpOneOf = "one-of"
+
+	// DefaultProfile is the profile used for nodes that match no selector
+	DefaultProfile = "general"
+)
+
+// builtinProfiles is the baselines shipped with kube-jarvis
+// "general" is suitable for all nodes, "ingress" is for nodes that handle a large number of connections
+var builtinProfiles = `
+general:
+  - key: "net.ipv4.tcp_tw_reuse"
+    value: "1"
+  - key: "net.ipv4.ip_forward"
+    value: "1"
+  - key: "net.bridge.bridge-nf-call-iptables"
+    value: "1"
+ingress:
+  - key: "net.ipv4.tcp_tw_reuse"
+    value: "1"
+  - key: "net.ipv4.ip_forward"
+    value: "1"
+  - key: "net.bridge.bridge-nf-call-iptables"
+    value: "1"
+  - key: "net.core.somaxconn"
+    op: "min"
+    value: "32768"
+  - key: "net.ipv4.tcp_max_syn_backlog"
+    op: "min"
+    value: "8192"
+  - key: "net.core.netdev_max_backlog"
+    op: "min"
+    value: "16384"
+  - key: "net.ipv4.tcp_fin_timeout"
+    op: "max"
+    value: "30"
+  - key: "fs.file-max"
+    op: "min"
+    value: "1048576"
+  - key: "net.netfilter.nf_conntrack_max"
+    op: "min"
+    value: "1048576"
+    level: "risk"
+  - key: "net.ipv4.tcp_congestion_control"
+    op: "one-of"
+    values: ["bbr", "cubic"]
+`
+
+// Rule is an expected value of one kernel parameter
+type Rule struct {
+	// Key is the name of kernel parameter, e.g. "net.ipv4.ip_forward"
+	Key string
+	// Op is one of "equal", "min", "max" and "one-of", default is "equal"
+	Op string
+	// Value is the expected value of "equal", "min" and "max"
+	Value string
+	// Values is the expected values of "one-of"
+	Values []string
+	// Level is the HealthyLevel of result if the parameter is not expected, default is "warn"
+	Level diagnose.HealthyLevel
+
+	number int64
+}
+
+// Selector choose a profile for nodes with all target labels
+type Selector struct {
+	// Profile is the name of target profile
+	Profile string
+	// Labels is the labels that node must have
+	Labels map[string]string
+}
+
+func (r *Rule) complete() error {
+	if r.Key == "" {
+		return fmt.Errorf("key can not be empty")
+	}
+
+	if r.Op == "" {
+		r.Op = OpEqual
+	}
+
+	if r.Level == "" {
+		r.Level = diagnose.HealthyLevelWarn
+	}
+
+	if !r.Level.Verify() {
+		return fmt.Errorf("level %s is illegal", r.Level)
+	}
+
+	switch r.Op {
+	case OpEqual:
+	case OpMin, OpMax:
+		n, err := strconv.ParseInt(r.Value, 10, 64)
+		if err != nil {
+			return errors.Wrapf(err, "value of %s must be a number", r.Op)
+		}
+		r.number = n
+	case OpOneOf:
+		if len(r.Values) == 0 {
+			return fmt.Errorf("values of %s can not be empty", r.Op)
+		}
+	default:
+		return fmt.Errorf("unknown op %s", r.Op)
+	}
+	return nil
+}
+
+// match return true if curVal is expected
+func (r *Rule) match(curVal string, exist bool) bool {
+	if !exist {
+		return false
+	}
+
+	curVal = strings.Join(strings.Fields(curVal), " ")
+	switch r.Op {
+	case OpMin, OpMax:
+		n, err := strconv.ParseInt(curVal, 10, 64)
+		if err != nil {
+			return false
+		}
+		if r.Op == OpMin {
+			return n >= r.number
+		}
+		return n <= r.number
+	case OpOneOf:
+		for _, v := range r.Values {
+			if curVal == v {
+				return true
+			}
+		}
+		return false
+	default:
+		return curVal == strings.Join(strings.Fields(r.Value), " ")
+	}
+}
+
+// target return the expected value for showing
+func (r *Rule) target() string {
+	if r.Op == OpOneOf {
+		return strings.Join(r.Values, ",")
+	}
+	return r.Value
+}
+
+// proposal return the translation key of proposal
+func (r *Rule) proposal() string {
+	if r.Op == OpEqual {
+		return "kernel-para-proposal"
+	}
+	return fmt.Sprintf("kernel-para-%s-proposal", r.Op)
+}
+
+func (s *Selector) match(labels map[string]string) bool {
+	for k, v := range s.Labels {
+		if val, exist := labels[k]; !exist || val != v {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeProfiles return built-in profiles overwritten by user profiles with the same name
+func mergeProfiles(profiles map[string][]*Rule) (map[string][]*Rule, error) {
+	merged := map[string][]*Rule{}
+	if err := yaml.Unmarshal([]byte(builtinProfiles), &merged); err != nil {
+		return nil, errors.Wrap(err, "parse built-in profiles failed")
+	}
+
+	for name, rules := range profiles {
+		merged[name] = rules
+	}
+
+	for name, rules := range merged {
+		for _, r := range rules {
+			if err := r.complete(); err != nil {
+				return nil, errors.Wrapf(err, "complete rule %s of profile %s failed", r.Key, name)
+			}
+		}
+	}
+	return merged, nil
+}
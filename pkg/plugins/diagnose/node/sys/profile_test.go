@@ -0,0 +1,119 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package sys
+
+import (
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+func TestRule_Match(t *testing.T) {
+	var cases = []struct {
+		rule   Rule
+		curVal string
+		exist  bool
+		match  bool
+	}{
+		{rule: Rule{Key: "a", Value: "1"}, curVal: "1", exist: true, match: true},
+		{rule: Rule{Key: "a", Value: "1"}, curVal: "0", exist: true, match: false},
+		{rule: Rule{Key: "a", Value: "1"}, curVal: "", exist: false, match: false},
+		{rule: Rule{Key: "a", Value: "4096 87380"}, curVal: "4096\t87380", exist: true, match: true},
+		{rule: Rule{Key: "a", Op: OpMin, Value: "100"}, curVal: "100", exist: true, match: true},
+		{rule: Rule{Key: "a", Op: OpMin, Value: "100"}, curVal: "99", exist: true, match: false},
+		{rule: Rule{Key: "a", Op: OpMin, Value: "100"}, curVal: "abc", exist: true, match: false},
+		{rule: Rule{Key: "a", Op: OpMax, Value: "30"}, curVal: "60", exist: true, match: false},
+		{rule: Rule{Key: "a", Op: OpMax, Value: "30"}, curVal: "15", exist: true, match: true},
+		{rule: Rule{Key: "a", Op: OpOneOf, Values: []string{"bbr", "cubic"}}, curVal: "cubic", exist: true, match: true},
+		{rule: Rule{Key: "a", Op: OpOneOf, Values: []string{"bbr", "cubic"}}, curVal: "reno", exist: true, match: false},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.rule.Op+cs.curVal, func(t *testing.T) {
+			if err := cs.rule.complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if cs.rule.match(cs.curVal, cs.exist) != cs.match {
+				t.Fatalf("want %v but not", cs.match)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		name      string
+		profiles  map[string][]*Rule
+		selectors []Selector
+		def       string
+		pass      bool
+	}{
+		{
+			name: "built-in",
+			pass: true,
+		},
+		{
+			name: "user profile",
+			profiles: map[string][]*Rule{
+				"db": {{Key: "vm.swappiness", Op: OpMax, Value: "10"}},
+			},
+			selectors: []Selector{{Profile: "db", Labels: map[string]string{"role": "db"}}},
+			def:       "ingress",
+			pass:      true,
+		},
+		{
+			name:     "unknown op",
+			profiles: map[string][]*Rule{"db": {{Key: "vm.swappiness", Op: "less"}}},
+		},
+		{
+			name:     "min without number",
+			profiles: map[string][]*Rule{"db": {{Key: "vm.swappiness", Op: OpMin, Value: "a"}}},
+		},
+		{
+			name:     "one-of without values",
+			profiles: map[string][]*Rule{"db": {{Key: "vm.swappiness", Op: OpOneOf}}},
+		},
+		{
+			name:     "illegal level",
+			profiles: map[string][]*Rule{"db": {{Key: "vm.swappiness", Value: "1", Level: "bad"}}},
+		},
+		{
+			name:      "unknown selector profile",
+			selectors: []Selector{{Profile: "db"}},
+		},
+		{
+			name: "unknown default profile",
+			def:  "db",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+			d.Profiles = cs.profiles
+			d.Selectors = cs.selectors
+			d.Default = cs.def
+
+			err := d.Complete()
+			if (err == nil) != cs.pass {
+				t.Fatalf("want pass %v but get err %v", cs.pass, err)
+			}
+		})
+	}
+}
@@ -19,7 +19,11 @@ package sys
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
 )
 
@@ -28,14 +32,20 @@ const (
 	DiagnosticType = "node-sys"
 )
 
-// Diagnostic is a example diagnostic shows how to write a diagnostic
+// Diagnostic check kernel parameters of nodes with baseline profiles
 type Diagnostic struct {
 	*diagnose.MetaData
-	result chan *diagnose.Result
-	param  *diagnose.StartDiagnoseParam
+	// Profiles is the user defined profiles, a built-in profile will be replaced by the one with the same name
+	Profiles map[string][]*Rule
+	// Selectors choose profile for nodes via node labels, the first matched one is used
+	Selectors []Selector
+	// Default is the profile of nodes that match no selector, default is "general"
+	Default string
+	result  chan *diagnose.Result
+	param   *diagnose.StartDiagnoseParam
 }
 
-// NewDiagnostic return a example diagnostic
+// NewDiagnostic return a node-sys diagnostic
 func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
 	return &Diagnostic{
 		result:   make(chan *diagnose.Result, 1000),
@@ -45,9 +55,38 @@ func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
 
 // Complete check and complete config items
 func (d *Diagnostic) Complete() error {
+	profiles, err := mergeProfiles(d.Profiles)
+	if err != nil {
+		return err
+	}
+	d.Profiles = profiles
+
+	if d.Default == "" {
+		d.Default = DefaultProfile
+	}
+
+	if _, exist := d.Profiles[d.Default]; !exist {
+		return fmt.Errorf("default profile %s not found", d.Default)
+	}
+
+	for _, s := range d.Selectors {
+		if _, exist := d.Profiles[s.Profile]; !exist {
+			return fmt.Errorf("profile %s of selector not found", s.Profile)
+		}
+	}
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"kernel-para-title", "kernel-para-desc", "kernel-para-good-desc",
+		"kernel-para-group-desc", "kernel-para-group-good-desc"}
+	for _, op := range []string{OpEqual, OpMin, OpMax, OpOneOf} {
+		ids = append(ids, (&Rule{Op: op}).proposal())
+	}
+	return ids
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context,
 	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
@@ -56,49 +95,138 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context,
 
 	go func() {
 		defer diagnose.CommonDeafer(d.result)
-		for node := range d.param.Resources.Machines {
-			// net.ipv4.tcp_tw_reuse
-			d.diagnoseKernelParam("net.ipv4.tcp_tw_reuse", "1", node)
-
-			// net.ipv4.ip_forward
-			d.diagnoseKernelParam("net.ipv4.ip_forward", "1", node)
+		profiles := d.nodeProfiles()
+		// machines are collected by sampling, report results per group
+		if len(d.param.Resources.MachineGroups) != 0 {
+			for _, g := range d.param.Resources.MachineGroups {
+				d.diagnoseGroup(g, profiles)
+			}
+			return
+		}
 
-			// net.bridge.bridge-nf-call-iptables
-			d.diagnoseKernelParam("net.bridge.bridge-nf-call-iptables", "1", node)
+		for node := range d.param.Resources.Machines {
+			for _, r := range d.Profiles[profiles[node]] {
+				d.diagnoseKernelParam(profiles[node], r, node)
+			}
 		}
 	}()
 	return d.result, nil
 }
 
-func (d *Diagnostic) diagnoseKernelParam(key string, targetVal string, node string) {
+// nodeProfiles return the profile name of every machine
+func (d *Diagnostic) nodeProfiles() map[string]string {
+	labels := map[string]map[string]string{}
+	if d.param.Resources.Nodes != nil {
+		for _, n := range d.param.Resources.Nodes.Items {
+			labels[n.Name] = n.Labels
+		}
+	}
+
+	profiles := map[string]string{}
+	for node := range d.param.Resources.Machines {
+		profiles[node] = d.Default
+		for _, s := range d.Selectors {
+			if s.match(labels[node]) {
+				profiles[node] = s.Profile
+				break
+			}
+		}
+	}
+	return profiles
+}
+
+func (d *Diagnostic) diagnoseKernelParam(profile string, r *Rule, node string) {
 	m := d.param.Resources.Machines[node]
-	curVal := m.SysCtl[key]
-	level := diagnose.HealthyLevelGood
+	curVal, exist := m.SysCtl[r.Key]
 
 	obj := map[string]interface{}{
 		"Node":      node,
-		"Name":      key,
+		"Profile":   profile,
+		"Name":      r.Key,
 		"CurVal":    curVal,
-		"TargetVal": targetVal,
+		"TargetVal": r.target(),
 	}
 
-	if curVal != targetVal {
-		level = diagnose.HealthyLevelWarn
+	if !r.match(curVal, exist) {
 		d.result <- &diagnose.Result{
-			Level:    level,
+			Level:    r.Level,
 			Title:    d.Translator.Message("kernel-para-title", nil),
 			ObjName:  node,
+			Obj:      diagnose.NewNodeRef(node),
 			ObjInfo:  obj,
 			Desc:     d.Translator.Message("kernel-para-desc", obj),
-			Proposal: d.Translator.Message("kernel-para-proposal", obj),
+			Proposal: d.Translator.Message(r.proposal(), obj),
 		}
 	} else {
 		d.result <- &diagnose.Result{
-			Level:   level,
+			Level:   diagnose.HealthyLevelGood,
 			Title:   d.Translator.Message("kernel-para-title", nil),
 			ObjName: node,
+			Obj:     diagnose.NewNodeRef(node),
 			ObjInfo: obj,
 			Desc:    d.Translator.Message("kernel-para-good-desc", obj),
 		}
 	}
 }
+
+// diagnoseGroup check sampled nodes of group with the profiles of them
+func (d *Diagnostic) diagnoseGroup(g cluster.MachineGroup, profiles map[string]string) {
+	sampled := map[string][]string{}
+	for _, node := range g.Sampled {
+		sampled[profiles[node]] = append(sampled[profiles[node]], node)
+	}
+
+	names := make([]string, 0, len(sampled))
+	for name := range sampled {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, r := range d.Profiles[name] {
+			d.diagnoseGroupKernelParam(name, r, g, sampled[name])
+		}
+	}
+}
+
+func (d *Diagnostic) diagnoseGroupKernelParam(profile string, r *Rule, g cluster.MachineGroup, nodes []string) {
+	badNodes := make([]string, 0)
+	curVal := ""
+	for _, node := range nodes {
+		val, exist := d.param.Resources.Machines[node].SysCtl[r.Key]
+		if !r.match(val, exist) {
+			badNodes = append(badNodes, node)
+			curVal = val
+		}
+	}
+
+	obj := map[string]interface{}{
+		"Group":     g.Name,
+		"Profile":   profile,
+		"Sampled":   len(g.Sampled),
+		"Total":     len(g.Nodes),
+		"Nodes":     strings.Join(badNodes, ","),
+		"Name":      r.Key,
+		"CurVal":    curVal,
+		"TargetVal": r.target(),
+	}
+
+	if len(badNodes) != 0 {
+		d.result <- &diagnose.Result{
+			Level:    r.Level,
+			Title:    d.Translator.Message("kernel-para-title", nil),
+			ObjName:  g.Name,
+			ObjInfo:  obj,
+			Desc:     d.Translator.Message("kernel-para-group-desc", obj),
+			Proposal: d.Translator.Message(r.proposal(), obj),
+		}
+	} else {
+		d.result <- &diagnose.Result{
+			Level:   diagnose.HealthyLevelGood,
+			Title:   d.Translator.Message("kernel-para-title", nil),
+			ObjName: g.Name,
+			ObjInfo: obj,
+			Desc:    d.Translator.Message("kernel-para-group-good-desc", obj),
+		}
+	}
+}
@@ -0,0 +1,166 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package sys
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	goodSysCtl := map[string]string{
+		"net.ipv4.tcp_tw_reuse":              "1",
+		"net.ipv4.ip_forward":                "1",
+		"net.bridge.bridge-nf-call-iptables": "1",
+	}
+	badSysCtl := map[string]string{
+		"net.ipv4.tcp_tw_reuse":              "1",
+		"net.ipv4.ip_forward":                "0",
+		"net.bridge.bridge-nf-call-iptables": "1",
+	}
+
+	var cases = []struct {
+		name   string
+		groups []cluster.MachineGroup
+		objs   map[string]diagnose.HealthyLevel
+	}{
+		{
+			name: "per node",
+			objs: map[string]diagnose.HealthyLevel{
+				"node1": diagnose.HealthyLevelGood,
+				"node2": diagnose.HealthyLevelWarn,
+			},
+		},
+		{
+			name: "per group",
+			groups: []cluster.MachineGroup{
+				{Name: "pool=a", Nodes: []string{"node1", "node3"}, Sampled: []string{"node1"}},
+				{Name: "pool=b", Nodes: []string{"node2", "node4"}, Sampled: []string{"node2"}},
+			},
+			objs: map[string]diagnose.HealthyLevel{
+				"pool=a": diagnose.HealthyLevelGood,
+				"pool=b": diagnose.HealthyLevelWarn,
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			res := cluster.NewResources()
+			res.Machines["node1"] = cluster.Machine{SysCtl: goodSysCtl}
+			res.Machines["node2"] = cluster.Machine{SysCtl: badSysCtl}
+			res.MachineGroups = cs.groups
+
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+			})
+			if err := d.Complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				CloudType: "fake",
+				Resources: res,
+			})
+
+			// the worst level of each object
+			levels := map[string]diagnose.HealthyLevel{}
+			total := 0
+			for r := range results {
+				total++
+				if r.Level != diagnose.HealthyLevelGood || levels[r.ObjName] == "" {
+					levels[r.ObjName] = r.Level
+				}
+			}
+
+			rules := len(d.(*Diagnostic).Profiles[DefaultProfile])
+			if total != len(cs.objs)*rules {
+				t.Fatalf("want %d results but get %d", len(cs.objs)*rules, total)
+			}
+
+			if fmt.Sprint(levels) != fmt.Sprint(cs.objs) {
+				t.Fatalf("want %v but get %v", cs.objs, levels)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_Selectors(t *testing.T) {
+	res := cluster.NewResources()
+	res.Nodes = &v1.NodeList{Items: []v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"role": "ingress"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+	}}
+	sysCtl := map[string]string{
+		"net.ipv4.tcp_tw_reuse":              "1",
+		"net.ipv4.ip_forward":                "1",
+		"net.bridge.bridge-nf-call-iptables": "1",
+		"net.core.somaxconn":                 "128",
+	}
+	res.Machines["node1"] = cluster.Machine{SysCtl: sysCtl}
+	res.Machines["node2"] = cluster.Machine{SysCtl: sysCtl}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	d.Profiles = map[string][]*Rule{
+		"ingress": {{Key: "net.core.somaxconn", Op: OpMin, Value: "32768", Level: diagnose.HealthyLevelRisk}},
+	}
+	d.Selectors = []Selector{{Profile: "ingress", Labels: map[string]string{"role": "ingress"}}}
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	counts := map[string]int{}
+	for r := range results {
+		counts[fmt.Sprintf("%s/%s/%s", r.ObjName, r.ObjInfo["Profile"], r.Level)]++
+	}
+
+	want := map[string]int{
+		"node1/ingress/risk": 1,
+		"node2/general/good": 3,
+	}
+	if fmt.Sprint(counts) != fmt.Sprint(want) {
+		t.Fatalf("want %v but get %v", want, counts)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
 )
 
 const (
@@ -48,6 +49,11 @@ func (d *Diagnostic) Complete() error {
 	return nil
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"message", "proposal"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context,
 	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
@@ -60,9 +66,13 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context,
 		}
 
 		d.result <- &diagnose.Result{
-			Level:    diagnose.HealthyLevelRisk,
-			Title:    "example",
-			ObjName:  "example-obj",
+			Level:   diagnose.HealthyLevelRisk,
+			Title:   translate.Literal("example"),
+			ObjName: "example-obj",
+			Obj: &diagnose.ObjectRef{
+				Kind: "Example",
+				Name: "example-obj",
+			},
 			ObjInfo:  obj,
 			Desc:     d.Translator.Message("message", obj),
 			Proposal: d.Translator.Message("proposal", nil),
@@ -70,9 +80,13 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context,
 
 		// if any Error occur , send a failed result
 		d.result <- &diagnose.Result{
-			Level:    diagnose.HealthyLevelFailed,
-			Title:    "example",
-			ObjName:  "example-obj",
+			Level:   diagnose.HealthyLevelFailed,
+			Title:   translate.Literal("example"),
+			ObjName: "example-obj",
+			Obj: &diagnose.ObjectRef{
+				Kind: "Example",
+				Name: "example-obj",
+			},
 			ObjInfo:  obj,
 			Desc:     d.Translator.Message("message", obj),
 			Proposal: d.Translator.Message("proposal", nil),
@@ -0,0 +1,258 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "external"
+	// maxStderrLen is the max length of stderr that will be reported
+	maxStderrLen = 4096
+	// maxLineLen is the max length of a result line
+	maxLineLen = 1024 * 1024
+)
+
+// Input is the json object that will be written to stdin of external process
+type Input struct {
+	// CloudType is the cloud provider type fo cluster
+	CloudType string
+	// Resources contains the selected fields of cluster.Resources
+	Resources map[string]interface{}
+	// Config is the "config" of this Diagnostic
+	Config interface{}
+}
+
+// Diagnostic run an external executable to diagnose cluster
+// Input will be written to stdin of the executable as json
+// every line of stdout should be a json of diagnose.Result
+type Diagnostic struct {
+	*diagnose.MetaData
+	// Command is the path of executable
+	Command string
+	// Args is the arguments of executable
+	Args []string
+	// Env is the extra environment variables of executable, e.g. "KEY=VALUE"
+	Env []string
+	// Timeout is the max running time of executable, default is "60s"
+	Timeout string
+	// Resources is the field names of cluster.Resources that will be passed to executable
+	// e.g. "Deployments", "Pods", "Machines", all fields will be passed if it is empty
+	Resources []string
+	// Config will be passed to executable directly
+	Config interface{}
+
+	timeout time.Duration
+	result  chan *diagnose.Result
+}
+
+// NewDiagnostic return a external Diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		MetaData: meta,
+		result:   make(chan *diagnose.Result, 1000),
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.Command == "" {
+		return fmt.Errorf("command can not be empty")
+	}
+
+	if d.Timeout == "" {
+		d.Timeout = "60s"
+	}
+
+	var err error
+	d.timeout, err = time.ParseDuration(d.Timeout)
+	if err != nil {
+		return errors.Wrapf(err, "parse timeout failed")
+	}
+
+	return cluster.CheckResourcesFields(d.Resources)
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"failed-title", "failed-desc", "failed-proposal"}
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		if err := d.run(ctx, param); err != nil {
+			d.Logger.Errorf("run %s failed : %v", d.Command, err)
+			d.sendFailedResult(err)
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) run(ctx context.Context, param diagnose.StartDiagnoseParam) error {
+	input, err := json.Marshal(&Input{
+		CloudType: param.CloudType,
+		Resources: d.selectResources(param.Resources),
+		Config:    util.JSONCompatible(d.Config),
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal input failed")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	stderr := bytes.NewBuffer(nil)
+	cmd := exec.Command(d.Command, d.Args...)
+	setProcessGroup(cmd)
+	cmd.Env = append(os.Environ(), d.Env...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "get stdout failed")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return errors.Wrap(err, "start command failed")
+	}
+
+	// children of command may still hold stdout and stderr after command is killed,
+	// so the whole process group is killed and stdout is closed once ctx is done
+	scanned := make(chan struct{})
+	defer close(scanned)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd)
+			_ = stdout.Close()
+		case <-scanned:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLen)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		r := &diagnose.Result{}
+		if err := json.Unmarshal([]byte(line), r); err != nil {
+			d.sendFailedResult(errors.Wrapf(err, "unmarshal result '%s' failed", line))
+			continue
+		}
+
+		if !r.Level.Verify() {
+			d.sendFailedResult(fmt.Errorf("level of result '%s' is illegal", line))
+			continue
+		}
+		d.result <- r
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		_ = cmd.Wait()
+		return fmt.Errorf("timeout after %s, stderr: %s", d.Timeout, tail(stderr.String()))
+	}
+
+	// stdout is not drained if scanner stopped with error, kill the command so that it will not block until timeout
+	if err := scanner.Err(); err != nil {
+		killProcessGroup(cmd)
+		_ = cmd.Wait()
+		return errors.Wrapf(err, "read stdout failed, stderr: %s", tail(stderr.String()))
+	}
+
+	err = cmd.Wait()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout after %s, stderr: %s", d.Timeout, tail(stderr.String()))
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "stderr: %s", tail(stderr.String()))
+	}
+
+	if stderr.Len() != 0 {
+		d.Logger.Infof("stderr of %s: %s", d.Command, tail(stderr.String()))
+	}
+	return nil
+}
+
+// selectResources return the selected fields of cluster.Resources
+func (d *Diagnostic) selectResources(res *cluster.Resources) map[string]interface{} {
+	result := map[string]interface{}{}
+	if res == nil {
+		return result
+	}
+
+	val := reflect.ValueOf(res).Elem()
+	if len(d.Resources) == 0 {
+		for i := 0; i < val.NumField(); i++ {
+			result[val.Type().Field(i).Name] = val.Field(i).Interface()
+		}
+		return result
+	}
+
+	for _, r := range d.Resources {
+		result[r] = val.FieldByName(r).Interface()
+	}
+	return result
+}
+
+func (d *Diagnostic) sendFailedResult(err error) {
+	obj := map[string]interface{}{
+		"Command": d.Command,
+		"Error":   err.Error(),
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    diagnose.HealthyLevelFailed,
+		ObjName:  "*",
+		ObjInfo:  obj,
+		Title:    d.Translator.Message("failed-title", obj),
+		Desc:     d.Translator.Message("failed-desc", obj),
+		Proposal: d.Translator.Message("failed-proposal", obj),
+	}
+}
+
+// tail return the last maxStderrLen bytes of s
+func tail(s string) string {
+	if len(s) <= maxStderrLen {
+		return s
+	}
+	return "..." + s[len(s)-maxStderrLen:]
+}
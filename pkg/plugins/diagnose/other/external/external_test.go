@@ -0,0 +1,202 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package external
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	var cases = []struct {
+		name    string
+		script  string
+		timeout string
+		levels  []diagnose.HealthyLevel
+		errMsg  string
+	}{
+		{
+			name: "good",
+			script: `input=$(cat)
+case "$input" in
+  *'"Namespaces"'*'"Message":"hello"'*) ;;
+  *) echo "unexpected input" >&2; exit 1 ;;
+esac
+echo '{"Level":"warn","ObjName":"default:a","Obj":{"Kind":"Deployment","Namespace":"default","Name":"a"},"Title":"title","Desc":"desc"}'
+echo ''
+echo '{"Level":"good","ObjName":"*","Title":{"ID":"diagnostics.external.title"}}'`,
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelWarn, diagnose.HealthyLevelGood},
+		},
+		{
+			name: "bad-output",
+			script: `cat > /dev/null
+echo 'not json'
+echo '{"Level":"unknown"}'`,
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelFailed, diagnose.HealthyLevelFailed},
+		},
+		{
+			name: "exit-code",
+			script: `cat > /dev/null
+echo '{"Level":"risk"}'
+echo "something wrong" >&2
+exit 2`,
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelRisk, diagnose.HealthyLevelFailed},
+			errMsg: "something wrong",
+		},
+		{
+			name: "timeout",
+			script: `cat > /dev/null
+exec sleep 10`,
+			timeout: "200ms",
+			levels:  []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+			errMsg:  "timeout",
+		},
+		{
+			name: "timeout-with-child",
+			script: `cat > /dev/null
+sleep 10
+echo '{"Level":"good"}'`,
+			timeout: "200ms",
+			levels:  []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+			errMsg:  "timeout",
+		},
+		{
+			name: "long-line",
+			script: `cat > /dev/null
+head -c 1100000 /dev/zero | tr '\0' a
+echo
+exec sleep 10`,
+			timeout: "5s",
+			levels:  []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+			errMsg:  "token too long",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs.name), func(t *testing.T) {
+			script := filepath.Join(dir, cs.name+".sh")
+			if err := ioutil.WriteFile(script, []byte("#!/bin/sh\n"+cs.script+"\n"), 0755); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+			}).(*Diagnostic)
+			d.Command = script
+			d.Timeout = cs.timeout
+			d.Resources = []string{"Namespaces"}
+			d.Config = map[interface{}]interface{}{
+				"Message": "hello",
+			}
+
+			if err := d.Complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			start := time.Now()
+			res := cluster.NewResources()
+			res.Namespaces = &v1.NamespaceList{}
+			result, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				Resources: res,
+			})
+
+			levels := make([]diagnose.HealthyLevel, 0)
+			for r := range result {
+				t.Logf("%+v", r)
+				levels = append(levels, r.Level)
+				if r.Level == diagnose.HealthyLevelFailed && cs.errMsg != "" &&
+					!strings.Contains(fmt.Sprint(r.ObjInfo["Error"]), cs.errMsg) {
+					t.Fatalf("error should contains %s", cs.errMsg)
+				}
+			}
+
+			if fmt.Sprint(levels) != fmt.Sprint(cs.levels) {
+				t.Fatalf("want %v but get %v", cs.levels, levels)
+			}
+
+			if cost := time.Since(start); cost > 3*time.Second {
+				t.Fatalf("command should be stopped in time but cost %s", cost)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		d       Diagnostic
+		wantErr bool
+	}{
+		{
+			d:       Diagnostic{},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Command:   "check.py",
+				Resources: []string{"Unknown"},
+			},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Command: "check.py",
+				Timeout: "abc",
+			},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Command:   "check.py",
+				Resources: []string{"Pods", "Machines"},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			if err := cs.d.Complete(); (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "remote"
+	// handshakeTimeout is the max time of connecting and handshaking
+	handshakeTimeout = time.Second * 10
+)
+
+// Diagnostic call a Diagnostic served by a remote plugin server via gRPC
+// see package "tkestack.io/kube-jarvis/pkg/plugins/remote" for the protocol
+type Diagnostic struct {
+	*diagnose.MetaData
+	// Address is the address of remote plugin server, e.g. "127.0.0.1:9000"
+	Address string
+	// CAFile is the CA file to verify server certificate, TLS will not be used if it is empty
+	CAFile string
+	// ServerName is used to verify the hostname of server certificate
+	ServerName string
+	// Plugin is the type of remote Diagnostic
+	// it can be empty if server only serve one Diagnostic
+	Plugin string
+	// Timeout is the max running time of remote Diagnostic, default is "10m"
+	Timeout string
+	// Resources is the field names of cluster.Resources that will be sent to remote Diagnostic
+	// e.g. "Deployments", "Pods", "Machines", all fields will be sent if it is empty
+	Resources []string
+	// Config is the config of remote Diagnostic
+	Config interface{}
+
+	timeout time.Duration
+	result  chan *diagnose.Result
+}
+
+// NewDiagnostic return a remote Diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		MetaData: meta,
+		result:   make(chan *diagnose.Result, 1000),
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.Address == "" {
+		return fmt.Errorf("address can not be empty")
+	}
+
+	if d.Timeout == "" {
+		d.Timeout = "10m"
+	}
+
+	var err error
+	d.timeout, err = time.ParseDuration(d.Timeout)
+	if err != nil {
+		return errors.Wrapf(err, "parse timeout failed")
+	}
+
+	return cluster.CheckResourcesFields(d.Resources)
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"failed-title", "failed-desc", "failed-proposal"}
+}
+
+// StartDiagnose return a result chan that will output results
+// Catalogue and Desc will be updated according to the remote Diagnostic
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.result = make(chan *diagnose.Result, 1000)
+	client, info, err := d.connect(ctx)
+	if err == nil && !plugins.IsSupportedCloud(info.SupportedClouds, param.CloudType) {
+		d.Logger.Infof("remote diagnostic [%s] don't support cloud [%s], skipped", info.Type, param.CloudType)
+		_ = client.Close()
+		close(d.result)
+		return d.result, nil
+	}
+
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		if err == nil {
+			defer client.Close()
+			err = d.diagnose(ctx, client, info, param)
+		}
+
+		if err != nil {
+			d.Logger.Errorf("remote diagnostic %s failed : %v", d.Address, err)
+			d.sendFailedResult(err)
+		}
+	}()
+	return d.result, nil
+}
+
+// connect dial remote server and choose the target Diagnostic
+func (d *Diagnostic) connect(ctx context.Context) (*remote.Client, *remote.PluginInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout)
+	defer cancel()
+
+	client, err := remote.Dial(ctx, remote.ClientConfig{
+		Address:    d.Address,
+		CAFile:     d.CAFile,
+		ServerName: d.ServerName,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := client.Handshake(ctx)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, err
+	}
+
+	info, err := d.choose(resp.Diagnostics)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, err
+	}
+
+	if len(info.Catalogue) != 0 {
+		d.Catalogue = info.Catalogue
+	}
+	d.Desc = info.Desc
+	return client, info, nil
+}
+
+func (d *Diagnostic) choose(infos []remote.PluginInfo) (*remote.PluginInfo, error) {
+	if d.Plugin == "" {
+		if len(infos) != 1 {
+			return nil, fmt.Errorf("server serve %d diagnostics, plugin must be specified", len(infos))
+		}
+		return &infos[0], nil
+	}
+
+	for i, info := range infos {
+		if info.Type == d.Plugin {
+			return &infos[i], nil
+		}
+	}
+	return nil, fmt.Errorf("can not found diagnostic %s in server", d.Plugin)
+}
+
+func (d *Diagnostic) diagnose(ctx context.Context, client *remote.Client,
+	info *remote.PluginInfo, param diagnose.StartDiagnoseParam) error {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	res := param.Resources
+	if res == nil {
+		res = cluster.NewResources()
+	}
+
+	return client.Diagnose(ctx, &remote.DiagnoseRequest{
+		Type:      info.Type,
+		Name:      d.Name,
+		Config:    util.JSONCompatible(d.Config),
+		CloudType: param.CloudType,
+		Resources: res.Select(d.Resources),
+	}, d.result)
+}
+
+func (d *Diagnostic) sendFailedResult(err error) {
+	obj := map[string]interface{}{
+		"Address": d.Address,
+		"Error":   err.Error(),
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    diagnose.HealthyLevelFailed,
+		ObjName:  "*",
+		ObjInfo:  obj,
+		Title:    d.Translator.Message("failed-title", obj),
+		Desc:     d.Translator.Message("failed-desc", obj),
+		Proposal: d.Translator.Message("failed-proposal", obj),
+	}
+}
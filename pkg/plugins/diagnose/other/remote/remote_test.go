@@ -0,0 +1,209 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+type fakeDiagnostic struct {
+	*diagnose.MetaData
+	Message string
+}
+
+func (f *fakeDiagnostic) Complete() error {
+	return nil
+}
+
+func (f *fakeDiagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	if param.Resources.Nodes == nil {
+		return nil, fmt.Errorf("nodes not found")
+	}
+
+	result := make(chan *diagnose.Result, 10)
+	for _, n := range param.Resources.Nodes.Items {
+		result <- &diagnose.Result{
+			Level:   diagnose.HealthyLevelRisk,
+			ObjName: n.Name,
+			Obj:     diagnose.NewNodeRef(n.Name),
+			Title:   translate.Literal(f.Message),
+		}
+	}
+	close(result)
+	return result, nil
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	s := remote.NewServer()
+	for _, typ := range []string{"a", "b"} {
+		s.AddDiagnostic(typ, diagnose.Factory{
+			Creator: func(d *diagnose.MetaData) diagnose.Diagnostic {
+				return &fakeDiagnostic{MetaData: d}
+			},
+			Catalogue:       diagnose.CatalogueNode,
+			SupportedClouds: []string{"qcloud"},
+		})
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	var cases = []struct {
+		plugin    string
+		cloud     string
+		resources []string
+		levels    []diagnose.HealthyLevel
+	}{
+		{
+			plugin: "a",
+			cloud:  "qcloud",
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelRisk},
+		},
+		{
+			plugin: "a",
+			cloud:  "other",
+			levels: []diagnose.HealthyLevel{},
+		},
+		{
+			plugin: "",
+			cloud:  "qcloud",
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+		},
+		{
+			plugin: "c",
+			cloud:  "qcloud",
+			levels: []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+		},
+		{
+			plugin:    "b",
+			cloud:     "qcloud",
+			resources: []string{"Pods"},
+			levels:    []diagnose.HealthyLevel{diagnose.HealthyLevelFailed},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+				Catalogue: diagnose.CatalogueOther,
+			}).(*Diagnostic)
+			d.Address = lis.Addr().String()
+			d.Plugin = cs.plugin
+			d.Resources = cs.resources
+			d.Config = map[interface{}]interface{}{
+				"message": "hello",
+			}
+
+			if err := d.Complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			res := cluster.NewResources()
+			res.Nodes = &v1.NodeList{Items: []v1.Node{{}}}
+			res.Nodes.Items[0].Name = "node1"
+			result, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				CloudType: cs.cloud,
+				Resources: res,
+			})
+
+			levels := make([]diagnose.HealthyLevel, 0)
+			for r := range result {
+				t.Logf("%+v", r)
+				levels = append(levels, r.Level)
+				if r.Level == diagnose.HealthyLevelRisk && (r.Title.String() != "hello" || r.ObjName != "node1") {
+					t.Fatalf("wrong result %+v", r)
+				}
+			}
+
+			if fmt.Sprint(levels) != fmt.Sprint(cs.levels) {
+				t.Fatalf("want %v but get %v", cs.levels, levels)
+			}
+
+			if len(cs.levels) != 0 && cs.levels[0] == diagnose.HealthyLevelRisk &&
+				fmt.Sprint(d.Meta().Catalogue) != fmt.Sprint(diagnose.CatalogueNode) {
+				t.Fatalf("catalogue should be updated to %v", diagnose.CatalogueNode)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		d       Diagnostic
+		wantErr bool
+	}{
+		{
+			d:       Diagnostic{},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Address: "127.0.0.1:9000",
+				Timeout: "abc",
+			},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Address:   "127.0.0.1:9000",
+				Resources: []string{"Unknown"},
+			},
+			wantErr: true,
+		},
+		{
+			d: Diagnostic{
+				Address:   "127.0.0.1:9000",
+				Resources: []string{"Nodes"},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			if err := cs.d.Complete(); (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package quota
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "resource-quota"
+
+	// DefaultWarnPercent is the default value of WarnPercent
+	DefaultWarnPercent = 80
+	// DefaultRiskPercent is the default value of RiskPercent
+	DefaultRiskPercent = 95
+)
+
+// systemNamespaces are never required to have ResourceQuota or LimitRange
+var systemNamespaces = map[string]bool{
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// Diagnostic check the usage of ResourceQuotas and the LimitRanges of namespaces
+type Diagnostic struct {
+	*diagnose.MetaData
+	// WarnPercent is the used percent of quota above which is reported as warn
+	WarnPercent float64
+	// RiskPercent is the used percent of quota above which is reported as risk
+	RiskPercent float64
+	// RequireQuota report namespaces that have no ResourceQuota
+	RequireQuota bool
+	// RequireLimitRange report namespaces that have no LimitRange
+	RequireLimitRange bool
+	// Filter is the namespaces that are not required to have ResourceQuota or LimitRange
+	// only Namespace and Name of items are used, Name is the name of namespace
+	Filter cluster.ResourcesFilter
+	result chan *diagnose.Result
+	param  *diagnose.StartDiagnoseParam
+}
+
+// NewDiagnostic return a resource-quota diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.WarnPercent == 0 {
+		d.WarnPercent = DefaultWarnPercent
+	}
+
+	if d.RiskPercent == 0 {
+		d.RiskPercent = DefaultRiskPercent
+	}
+
+	if d.WarnPercent < 0 || d.RiskPercent < d.WarnPercent || d.RiskPercent > 100 {
+		return fmt.Errorf("warnpercent and riskpercent must meet 0 < warnpercent <= riskpercent <= 100")
+	}
+	return d.Filter.Compile()
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"quota-title", "quota-usage-desc", "quota-exhausted-desc", "quota-usage-proposal",
+		"quota-missing-desc", "quota-missing-proposal", "limitrange-missing-desc", "limitrange-missing-proposal",
+		"limitrange-no-default-desc", "limitrange-no-default-proposal", "limitrange-conflict-desc",
+		"limitrange-conflict-proposal"}
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.param = &param
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		quotas := map[string][]corev1.ResourceQuota{}
+		if param.Resources.ResourceQuotas != nil {
+			for _, q := range param.Resources.ResourceQuotas.Items {
+				quotas[q.Namespace] = append(quotas[q.Namespace], q)
+				d.diagnoseUsage(&q)
+			}
+		}
+
+		limits := map[string][]corev1.LimitRange{}
+		if param.Resources.LimitRanges != nil {
+			for _, l := range param.Resources.LimitRanges.Items {
+				limits[l.Namespace] = append(limits[l.Namespace], l)
+			}
+		}
+
+		if param.Resources.Namespaces != nil {
+			for _, ns := range param.Resources.Namespaces.Items {
+				d.diagnoseNamespace(&ns, quotas[ns.Name], limits[ns.Name])
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+// diagnoseUsage report resources of quota that are near or at exhaustion
+func (d *Diagnostic) diagnoseUsage(q *corev1.ResourceQuota) {
+	for _, name := range resourceNames(q.Status.Hard) {
+		hard := q.Status.Hard[name]
+		used := q.Status.Used[name]
+		// a zero hard limit is used to forbid the resource
+		if hard.IsZero() {
+			continue
+		}
+
+		percent := float64(used.MilliValue()) / float64(hard.MilliValue()) * 100
+		if percent < d.WarnPercent {
+			continue
+		}
+
+		level := diagnose.HealthyLevelWarn
+		key := "quota-usage-desc"
+		if percent >= d.RiskPercent {
+			level = diagnose.HealthyLevelRisk
+		}
+
+		if used.Cmp(hard) >= 0 {
+			level = diagnose.HealthyLevelRisk
+			key = "quota-exhausted-desc"
+		}
+
+		obj := map[string]interface{}{
+			"Namespace": q.Namespace,
+			"Name":      q.Name,
+			"Resource":  string(name),
+			"Used":      used.String(),
+			"Hard":      hard.String(),
+			"Percent":   fmt.Sprintf("%.1f", percent),
+		}
+		d.sendResult(level, "ResourceQuota", q.Namespace, q.Name, q.UID, obj, key, "quota-usage-proposal")
+	}
+}
+
+// diagnoseNamespace check ResourceQuotas and LimitRanges of namespace
+func (d *Diagnostic) diagnoseNamespace(ns *corev1.Namespace, quotas []corev1.ResourceQuota,
+	limits []corev1.LimitRange) {
+	required := !systemNamespaces[ns.Name] && !d.Filter.Filtered(ns.Name, "Namespace", ns.Name)
+	obj := map[string]interface{}{
+		"Namespace": ns.Name,
+		"Name":      ns.Name,
+	}
+
+	if required && d.RequireQuota && len(quotas) == 0 {
+		d.sendResult(diagnose.HealthyLevelWarn, "Namespace", "", ns.Name, ns.UID, obj,
+			"quota-missing-desc", "quota-missing-proposal")
+	}
+
+	if required && d.RequireLimitRange && len(limits) == 0 {
+		d.sendResult(diagnose.HealthyLevelWarn, "Namespace", "", ns.Name, ns.UID, obj,
+			"limitrange-missing-desc", "limitrange-missing-proposal")
+	}
+
+	if len(quotas) != 0 {
+		d.diagnoseDefaults(ns.Name, quotas, limits)
+	}
+}
+
+// diagnoseDefaults check the container defaults of LimitRanges with hard limits of ResourceQuotas
+func (d *Diagnostic) diagnoseDefaults(ns string, quotas []corev1.ResourceQuota, limits []corev1.LimitRange) {
+	defLimits := corev1.ResourceList{}
+	defRequests := corev1.ResourceList{}
+	limitRanges := map[corev1.ResourceName]string{}
+	for _, l := range limits {
+		for _, item := range l.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+
+			for name, val := range item.Default {
+				defLimits[name] = val
+				limitRanges[name] = l.Name
+			}
+
+			// request is defaulted to the default limit if default request is not set
+			for name, val := range item.Default {
+				if _, exist := item.DefaultRequest[name]; !exist {
+					defRequests[name] = val
+				}
+			}
+
+			for name, val := range item.DefaultRequest {
+				defRequests[name] = val
+				limitRanges[name] = l.Name
+			}
+		}
+	}
+
+	for _, q := range quotas {
+		for _, name := range resourceNames(q.Spec.Hard) {
+			hard := q.Spec.Hard[name]
+			var target corev1.ResourceName
+			defaults := defRequests
+			switch {
+			case strings.HasPrefix(string(name), "limits."):
+				target = corev1.ResourceName(strings.TrimPrefix(string(name), "limits."))
+				defaults = defLimits
+			case strings.HasPrefix(string(name), "requests."):
+				target = corev1.ResourceName(strings.TrimPrefix(string(name), "requests."))
+			case name == corev1.ResourceCPU || name == corev1.ResourceMemory:
+				target = name
+			default:
+				continue
+			}
+
+			obj := map[string]interface{}{
+				"Namespace": ns,
+				"Name":      q.Name,
+				"Resource":  string(name),
+				"Hard":      hard.String(),
+			}
+
+			def, exist := defaults[target]
+			if !exist {
+				// pods without this resource set will be rejected by quota admission
+				d.sendResult(diagnose.HealthyLevelWarn, "ResourceQuota", ns, q.Name, q.UID, obj,
+					"limitrange-no-default-desc", "limitrange-no-default-proposal")
+				continue
+			}
+
+			if def.Cmp(hard) > 0 {
+				obj["LimitRange"] = limitRanges[target]
+				obj["Default"] = def.String()
+				d.sendResult(diagnose.HealthyLevelRisk, "ResourceQuota", ns, q.Name, q.UID, obj,
+					"limitrange-conflict-desc", "limitrange-conflict-proposal")
+			}
+		}
+	}
+}
+
+// resourceNames return sorted resource names of list
+func resourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func (d *Diagnostic) sendResult(level diagnose.HealthyLevel, kind, ns, name string, uid types.UID,
+	obj map[string]interface{}, descKey, proposalKey string) {
+	objName := name
+	if ns != "" {
+		objName = fmt.Sprintf("%s:%s", ns, name)
+	}
+
+	d.result <- &diagnose.Result{
+		Level:   level,
+		ObjName: objName,
+		Obj: &diagnose.ObjectRef{
+			Kind:      kind,
+			Namespace: ns,
+			Name:      name,
+			UID:       uid,
+		},
+		ObjInfo:  obj,
+		Title:    d.Translator.Message("quota-title", obj),
+		Desc:     d.Translator.Message(descKey, obj),
+		Proposal: d.Translator.Message(proposalKey, obj),
+	}
+}
@@ -0,0 +1,170 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package quota
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_Complete(t *testing.T) {
+	var cases = []struct {
+		name string
+		warn float64
+		risk float64
+		pass bool
+	}{
+		{
+			name: "default",
+			pass: true,
+		},
+		{
+			name: "custom",
+			warn: 50,
+			risk: 100,
+			pass: true,
+		},
+		{
+			name: "warn greater than risk",
+			warn: 90,
+			risk: 80,
+		},
+		{
+			name: "risk greater than 100",
+			risk: 120,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+			d.WarnPercent = cs.warn
+			d.RiskPercent = cs.risk
+			err := d.Complete()
+			if (err == nil) != cs.pass {
+				t.Fatalf("want pass %v but get err %v", cs.pass, err)
+			}
+		})
+	}
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	q := resource.MustParse
+	res := cluster.NewResources()
+	res.Namespaces = &corev1.NamespaceList{}
+	for _, ns := range []string{"kube-system", "tenant1", "tenant2", "ignored"} {
+		res.Namespaces.Items = append(res.Namespaces.Items, corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: ns},
+		})
+	}
+
+	res.ResourceQuotas = &corev1.ResourceQuotaList{Items: []corev1.ResourceQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant1", Name: "quota"},
+			Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+				"limits.cpu":      q("4"),
+				"requests.memory": q("4Gi"),
+				"pods":            q("10"),
+				"services":        q("0"),
+			}},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{
+					"limits.cpu":      q("4"),
+					"requests.memory": q("4Gi"),
+					"pods":            q("10"),
+					"services":        q("0"),
+				},
+				Used: corev1.ResourceList{
+					"limits.cpu":      q("3500m"),
+					"requests.memory": q("1Gi"),
+					"pods":            q("10"),
+					"services":        q("0"),
+				},
+			},
+		},
+	}}
+
+	res.LimitRanges = &corev1.LimitRangeList{Items: []corev1.LimitRange{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant1", Name: "limits"},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{
+				{
+					Type:    corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{"cpu": q("8")},
+				},
+			}},
+		},
+	}}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	d.RequireQuota = true
+	d.RequireLimitRange = true
+	d.Filter = cluster.ResourcesFilter{{Namespace: "^ignored$"}}
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	for r := range results {
+		key := r.ObjName + "/" + string(r.Desc.ID)
+		if name, exist := r.ObjInfo["Resource"]; exist {
+			key += "/" + name.(string)
+		}
+		got[key] = r.Level
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"tenant1:quota/quota-usage-desc/limits.cpu":                diagnose.HealthyLevelWarn,
+		"tenant1:quota/quota-exhausted-desc/pods":                  diagnose.HealthyLevelRisk,
+		"tenant1:quota/limitrange-conflict-desc/limits.cpu":        diagnose.HealthyLevelRisk,
+		"tenant1:quota/limitrange-no-default-desc/requests.memory": diagnose.HealthyLevelWarn,
+		"tenant2/quota-missing-desc":                               diagnose.HealthyLevelWarn,
+		"tenant2/limitrange-missing-desc":                          diagnose.HealthyLevelWarn,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d results, got %d: %v", len(want), len(got), got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+}
@@ -0,0 +1,317 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package rule
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"text/template"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "rule"
+)
+
+// Rule is a user defined check on one kind of resources
+type Rule struct {
+	// Name is the name of rule
+	Name string
+	// Kind is the target kind of rule, e.g. "Deployment", "Service"
+	Kind string
+	// Expr is a CEL expression that must return a bool,
+	// the object is healthy if Expr return true
+	// the target object can be accessed via variable "object", e.g. 'has(object.metadata.labels.team)'
+	Expr string
+	// Level is the HealthyLevel of result if Expr return false, default is "warn"
+	Level diagnose.HealthyLevel
+	// Title, Desc and Proposal are golang templates of result
+	// {{.Rule}},{{.Kind}},{{.Namespace}},{{.Name}} and {{.Object}} can be used in templates
+	Title    string
+	Desc     string
+	Proposal string
+
+	kind      *kindInfo
+	program   cel.Program
+	templates [3]*template.Template
+}
+
+// Diagnostic check resources with user defined rules
+type Diagnostic struct {
+	*diagnose.MetaData
+	Filter cluster.ResourcesFilter
+	Rules  []*Rule
+	result chan *diagnose.Result
+	param  *diagnose.StartDiagnoseParam
+}
+
+// NewDiagnostic return a rule Diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		MetaData: meta,
+		result:   make(chan *diagnose.Result, 1000),
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewIdent("object", decls.NewMapType(decls.String, decls.Dyn), nil)))
+	if err != nil {
+		return errors.Wrap(err, "create cel env failed")
+	}
+
+	for _, r := range d.Rules {
+		if err := r.complete(env); err != nil {
+			return errors.Wrapf(err, "complete rule %s failed", r.Name)
+		}
+	}
+
+	return d.Filter.Compile()
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"failed-title", "failed-desc", "failed-proposal"}
+}
+
+func (r *Rule) complete(env *cel.Env) error {
+	if r.Name == "" {
+		return fmt.Errorf("name can not be empty")
+	}
+
+	r.kind = kinds[r.Kind]
+	if r.kind == nil {
+		return fmt.Errorf("unknown kind %s", r.Kind)
+	}
+
+	if r.Level == "" {
+		r.Level = diagnose.HealthyLevelWarn
+	}
+
+	if !r.Level.Verify() {
+		return fmt.Errorf("level %s is illegal", r.Level)
+	}
+
+	if r.Title == "" {
+		r.Title = r.Name
+	}
+
+	ast, iss := env.Compile(r.Expr)
+	if iss != nil && iss.Err() != nil {
+		return errors.Wrap(iss.Err(), "compile expr failed")
+	}
+
+	if !proto.Equal(ast.ResultType(), decls.Bool) && !proto.Equal(ast.ResultType(), decls.Dyn) {
+		return fmt.Errorf("expr must return a bool")
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return errors.Wrap(err, "create cel program failed")
+	}
+	r.program = prg
+
+	for i, text := range []string{r.Title, r.Desc, r.Proposal} {
+		tpl, err := template.New(r.Name).Parse(text)
+		if err != nil {
+			return errors.Wrapf(err, "parse template %s failed", text)
+		}
+		r.templates[i] = tpl
+	}
+	return nil
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.param = &param
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		for _, r := range d.Rules {
+			for _, obj := range r.kind.objects(d.param.Resources) {
+				if d.Filter.Filtered(obj.GetNamespace(), r.Kind, obj.GetName()) {
+					continue
+				}
+				d.diagnoseObject(r, obj)
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) diagnoseObject(r *Rule, obj runtimeObject) {
+	ref := &diagnose.ObjectRef{
+		Kind:      r.Kind,
+		Group:     r.kind.gvk.Group,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+		UID:       obj.GetUID(),
+	}
+
+	objName := obj.GetName()
+	if obj.GetNamespace() != "" {
+		objName = fmt.Sprintf("%s:%s", obj.GetNamespace(), obj.GetName())
+	}
+
+	info := map[string]interface{}{
+		"Rule":      r.Name,
+		"Kind":      r.Kind,
+		"Namespace": obj.GetNamespace(),
+		"Name":      obj.GetName(),
+	}
+
+	passed, err := r.eval(obj)
+	if err != nil {
+		info["Error"] = err.Error()
+		d.result <- &diagnose.Result{
+			Level:    diagnose.HealthyLevelFailed,
+			ObjName:  objName,
+			Obj:      ref,
+			ObjInfo:  info,
+			Title:    d.Translator.Message("failed-title", info),
+			Desc:     d.Translator.Message("failed-desc", info),
+			Proposal: d.Translator.Message("failed-proposal", info),
+		}
+		return
+	}
+
+	if passed {
+		return
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range info {
+		data[k] = v
+	}
+	data["Object"] = obj
+
+	d.result <- &diagnose.Result{
+		Level:    r.Level,
+		ObjName:  objName,
+		Obj:      ref,
+		ObjInfo:  info,
+		Title:    translate.Literal(render(r.templates[0], data)),
+		Desc:     translate.Literal(render(r.templates[1], data)),
+		Proposal: translate.Literal(render(r.templates[2], data)),
+	}
+}
+
+// eval return the result of r.Expr on obj
+func (r *Rule) eval(obj runtimeObject) (bool, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return false, errors.Wrap(err, "convert object failed")
+	}
+
+	out, _, err := r.program.Eval(map[string]interface{}{
+		"object": content,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	passed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expr return %v, but bool is expected", out.Value())
+	}
+	return passed, nil
+}
+
+func render(tpl *template.Template, data interface{}) string {
+	buf := bytes.NewBuffer(nil)
+	if err := tpl.Execute(buf, data); err != nil {
+		return err.Error()
+	}
+	return buf.String()
+}
+
+// runtimeObject is an item of resource lists
+type runtimeObject interface {
+	runtime.Object
+	metav1.Object
+}
+
+// kindInfo show how to get objects of a kind from cluster.Resources
+type kindInfo struct {
+	field int
+	gvk   schema.GroupVersionKind
+}
+
+// kinds contains all supported kinds, it is generated from fields of cluster.Resources
+var kinds = map[string]*kindInfo{}
+
+func init() {
+	typ := reflect.TypeOf(cluster.Resources{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
+			continue
+		}
+
+		items, exist := field.Type.Elem().FieldByName("Items")
+		if !exist || items.Type.Kind() != reflect.Slice {
+			continue
+		}
+
+		obj, ok := reflect.New(items.Type.Elem()).Interface().(runtimeObject)
+		if !ok {
+			continue
+		}
+
+		gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+		if err != nil || len(gvks) == 0 {
+			continue
+		}
+
+		kinds[gvks[0].Kind] = &kindInfo{
+			field: i,
+			gvk:   gvks[0],
+		}
+	}
+}
+
+// objects return all objects of this kind
+func (k *kindInfo) objects(res *cluster.Resources) []runtimeObject {
+	list := reflect.ValueOf(res).Elem().Field(k.field)
+	if list.IsNil() {
+		return nil
+	}
+
+	items := list.Elem().FieldByName("Items")
+	objs := make([]runtimeObject, 0, items.Len())
+	for i := 0; i < items.Len(); i++ {
+		objs = append(objs, items.Index(i).Addr().Interface().(runtimeObject))
+	}
+	return objs
+}
@@ -0,0 +1,169 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package rule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	res := cluster.NewResources()
+	res.Deployments = &appv1.DeploymentList{}
+	res.Services = &v1.ServiceList{}
+
+	deploy := appv1.Deployment{}
+	deploy.Name = "deploy1"
+	deploy.Namespace = "default"
+	deploy.Labels = map[string]string{"team": "a"}
+	deploy.Spec.Template.Spec.Containers = []v1.Container{{Name: "c1", Image: "docker.io/nginx"}}
+	res.Deployments.Items = append(res.Deployments.Items, deploy)
+
+	deploy.Name = "deploy2"
+	deploy.Labels = nil
+	deploy.Spec.Template.Spec.Containers = []v1.Container{{Name: "c1", Image: "my.registry/nginx"}}
+	res.Deployments.Items = append(res.Deployments.Items, deploy)
+
+	svc := v1.Service{}
+	svc.Name = "svc1"
+	svc.Namespace = "default"
+	svc.Spec.Type = v1.ServiceTypeNodePort
+	res.Services.Items = append(res.Services.Items, svc)
+
+	var cases = []struct {
+		rule    Rule
+		wantErr bool
+		results map[string]diagnose.HealthyLevel
+	}{
+		{
+			rule: Rule{
+				Name: "team-label",
+				Kind: "Deployment",
+				Expr: `has(object.metadata.labels) && "team" in object.metadata.labels`,
+				Desc: "{{.Kind}} {{.Namespace}}:{{.Name}} has no team label",
+			},
+			results: map[string]diagnose.HealthyLevel{
+				"default:deploy2": diagnose.HealthyLevelWarn,
+			},
+		},
+		{
+			rule: Rule{
+				Name:  "forbidden-image",
+				Kind:  "Deployment",
+				Expr:  `object.spec.template.spec.containers.all(c, !c.image.startsWith("docker.io/"))`,
+				Level: diagnose.HealthyLevelRisk,
+			},
+			results: map[string]diagnose.HealthyLevel{
+				"default:deploy1": diagnose.HealthyLevelRisk,
+			},
+		},
+		{
+			rule: Rule{
+				Name:  "no-node-port",
+				Kind:  "Service",
+				Expr:  `object.spec.type != "NodePort"`,
+				Level: diagnose.HealthyLevelSerious,
+			},
+			results: map[string]diagnose.HealthyLevel{
+				"default:svc1": diagnose.HealthyLevelSerious,
+			},
+		},
+		{
+			rule: Rule{
+				Name: "missing-field",
+				Kind: "Deployment",
+				Expr: `object.metadata.labels.team == "a"`,
+			},
+			results: map[string]diagnose.HealthyLevel{
+				"default:deploy2": diagnose.HealthyLevelFailed,
+			},
+		},
+		{
+			rule: Rule{
+				Name: "unknown-kind",
+				Kind: "Unknown",
+				Expr: `true`,
+			},
+			wantErr: true,
+		},
+		{
+			rule: Rule{
+				Name: "not-bool",
+				Kind: "Deployment",
+				Expr: `object.metadata.name + "a"`,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs.rule.Name), func(t *testing.T) {
+			r := cs.rule
+			d := NewDiagnostic(&diagnose.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       DiagnosticType,
+					Name:       DiagnosticType,
+				},
+			}).(*Diagnostic)
+			d.Rules = []*Rule{&r}
+
+			err := d.Complete()
+			if (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			result, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+				Resources: res,
+			})
+
+			got := map[string]diagnose.HealthyLevel{}
+			for s := range result {
+				got[s.ObjName] = s.Level
+				if s.Obj == nil || s.Obj.Kind != r.Kind {
+					t.Fatalf("wrong object reference %+v", s.Obj)
+				}
+				t.Logf("%+v", s)
+			}
+
+			if len(got) != len(cs.results) {
+				t.Fatalf("want %d results but get %d", len(cs.results), len(got))
+			}
+
+			for name, level := range cs.results {
+				if got[name] != level {
+					t.Fatalf("want %s of %s but get %s", level, name, got[name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "service"
+)
+
+// Diagnostic check Services with their pods, Endpoints and EndpointSlices
+type Diagnostic struct {
+	*diagnose.MetaData
+	// Filter is the Services that will not be checked
+	Filter cluster.ResourcesFilter
+	result chan *diagnose.Result
+	param  *diagnose.StartDiagnoseParam
+}
+
+// NewDiagnostic return a service diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	return d.Filter.Compile()
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"service-title"}
+	for _, check := range []string{"lb-no-ingress", "no-pods", "no-ready-endpoints", "named-port-mismatch",
+		"port-mismatch"} {
+		ids = append(ids, fmt.Sprintf("service-%s-desc", check), fmt.Sprintf("service-%s-proposal", check))
+	}
+	return ids
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.param = &param
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		if param.Resources.Services == nil {
+			return
+		}
+
+		for i := range param.Resources.Services.Items {
+			svc := &param.Resources.Services.Items[i]
+			if svc.Spec.Type == corev1.ServiceTypeExternalName ||
+				d.Filter.Filtered(svc.Namespace, "Service", svc.Name) {
+				continue
+			}
+			d.diagnoseService(svc)
+		}
+	}()
+	return d.result, nil
+}
+
+func (d *Diagnostic) diagnoseService(svc *corev1.Service) {
+	obj := map[string]interface{}{
+		"Namespace": svc.Namespace,
+		"Name":      svc.Name,
+		"Type":      string(svc.Spec.Type),
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 {
+		d.sendResult(svc, diagnose.HealthyLevelRisk, "lb-no-ingress", obj)
+	}
+
+	// endpoints of Services without selector are managed manually
+	if len(svc.Spec.Selector) != 0 {
+		pods := d.selectPods(svc)
+		if len(pods) == 0 {
+			d.sendResult(svc, diagnose.HealthyLevelRisk, "no-pods", obj)
+			return
+		}
+		d.diagnosePorts(svc, pods, obj)
+	}
+
+	if d.param.Resources.ReadyEndpoints(svc) == 0 {
+		d.sendResult(svc, diagnose.HealthyLevelRisk, "no-ready-endpoints", obj)
+	}
+}
+
+// diagnosePorts check targetPort of Service ports with container ports of pods
+func (d *Diagnostic) diagnosePorts(svc *corev1.Service, pods []*corev1.Pod, obj map[string]interface{}) {
+	for _, port := range svc.Spec.Ports {
+		target := port.TargetPort
+		if target.Type == intstr.Int && target.IntVal == 0 {
+			target = intstr.FromInt(int(port.Port))
+		}
+
+		declared, matched := false, false
+		for _, pod := range pods {
+			for _, c := range pod.Spec.Containers {
+				for _, cp := range c.Ports {
+					if protocol(cp.Protocol) != protocol(port.Protocol) {
+						continue
+					}
+
+					declared = true
+					if (target.Type == intstr.String && cp.Name == target.StrVal) ||
+						(target.Type == intstr.Int && cp.ContainerPort == target.IntVal) {
+						matched = true
+					}
+				}
+			}
+		}
+
+		if matched {
+			continue
+		}
+
+		info := map[string]interface{}{
+			"Port":       strconv.Itoa(int(port.Port)),
+			"TargetPort": target.String(),
+		}
+		for k, v := range obj {
+			info[k] = v
+		}
+
+		// named port must be declared by containers, while a numeric port works without declaring
+		if target.Type == intstr.String {
+			d.sendResult(svc, diagnose.HealthyLevelRisk, "named-port-mismatch", info)
+		} else if declared {
+			d.sendResult(svc, diagnose.HealthyLevelWarn, "port-mismatch", info)
+		}
+	}
+}
+
+// protocol return the protocol with default value TCP
+func protocol(p corev1.Protocol) corev1.Protocol {
+	if p == "" {
+		return corev1.ProtocolTCP
+	}
+	return p
+}
+
+// selectPods return running pods selected by Service
+func (d *Diagnostic) selectPods(svc *corev1.Service) []*corev1.Pod {
+	pods := make([]*corev1.Pod, 0)
+	if d.param.Resources.Pods == nil {
+		return pods
+	}
+
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	for i := range d.param.Resources.Pods.Items {
+		pod := &d.param.Resources.Pods.Items[i]
+		if pod.Namespace != svc.Namespace || pod.Status.Phase == corev1.PodSucceeded ||
+			pod.Status.Phase == corev1.PodFailed || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+func (d *Diagnostic) sendResult(svc *corev1.Service, level diagnose.HealthyLevel,
+	check string, obj map[string]interface{}) {
+	info := map[string]interface{}{
+		"Check": check,
+	}
+	for k, v := range obj {
+		info[k] = v
+	}
+
+	d.result <- &diagnose.Result{
+		Level:   level,
+		ObjName: fmt.Sprintf("%s:%s", svc.Namespace, svc.Name),
+		Obj: &diagnose.ObjectRef{
+			Kind:      "Service",
+			Namespace: svc.Namespace,
+			Name:      svc.Name,
+			UID:       svc.UID,
+		},
+		ObjInfo:  info,
+		Title:    d.Translator.Message("service-title", info),
+		Desc:     d.Translator.Message(fmt.Sprintf("service-%s-desc", check), info),
+		Proposal: d.Translator.Message(fmt.Sprintf("service-%s-proposal", check), info),
+	}
+}
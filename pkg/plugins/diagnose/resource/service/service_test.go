@@ -0,0 +1,153 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func newService(name string, selector map[string]string, ports ...corev1.ServicePort) corev1.Service {
+	return corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       corev1.ServiceSpec{Selector: selector, Ports: ports},
+	}
+}
+
+func newEndpoints(name string, ready int) corev1.Endpoints {
+	ep := corev1.Endpoints{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	sub := corev1.EndpointSubset{}
+	for i := 0; i < ready; i++ {
+		sub.Addresses = append(sub.Addresses, corev1.EndpointAddress{IP: "10.0.0.1"})
+	}
+	ep.Subsets = []corev1.EndpointSubset{sub}
+	return ep
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	notReady := false
+	res := cluster.NewResources()
+	res.Pods = &corev1.PodList{Items: []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web", Labels: map[string]string{"app": "web"}},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "done", Labels: map[string]string{"app": "done"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+	}}
+
+	lb := newService("lb", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)})
+	lb.Spec.Type = corev1.ServiceTypeLoadBalancer
+	external := newService("external", nil)
+	external.Spec.Type = corev1.ServiceTypeExternalName
+	res.Services = &corev1.ServiceList{Items: []corev1.Service{
+		newService("good", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")}),
+		newService("no-pods", map[string]string{"app": "done"}),
+		newService("not-ready", map[string]string{"app": "web"}, corev1.ServicePort{Port: 8080}),
+		newService("sliced", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}),
+		newService("ports", map[string]string{"app": "web"},
+			corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("web")},
+			corev1.ServicePort{Port: 81, TargetPort: intstr.FromInt(9090)},
+			corev1.ServicePort{Port: 53, Protocol: corev1.ProtocolUDP, TargetPort: intstr.FromInt(53)},
+		),
+		newService("manual", nil),
+		newService("filtered", map[string]string{"app": "none"}),
+		lb,
+		external,
+	}}
+
+	res.Endpoints = &corev1.EndpointsList{Items: []corev1.Endpoints{
+		newEndpoints("good", 1),
+		newEndpoints("not-ready", 0),
+		newEndpoints("sliced", 1),
+		newEndpoints("ports", 1),
+		newEndpoints("manual", 0),
+		newEndpoints("lb", 1),
+	}}
+
+	res.EndpointSlices = &discoveryv1beta1.EndpointSliceList{Items: []discoveryv1beta1.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sliced-abc",
+				Labels: map[string]string{discoveryv1beta1.LabelServiceName: "sliced"}},
+			Endpoints: []discoveryv1beta1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1beta1.EndpointConditions{Ready: &notReady}},
+			},
+		},
+	}}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	d.Filter = cluster.ResourcesFilter{{Name: "^filtered$"}}
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	for r := range results {
+		key := r.ObjName + "/" + r.ObjInfo["Check"].(string)
+		if port, exist := r.ObjInfo["Port"]; exist {
+			key += "/" + port.(string)
+		}
+		got[key] = r.Level
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"default:no-pods/no-pods":              diagnose.HealthyLevelRisk,
+		"default:not-ready/no-ready-endpoints": diagnose.HealthyLevelRisk,
+		"default:sliced/no-ready-endpoints":    diagnose.HealthyLevelRisk,
+		"default:ports/named-port-mismatch/80": diagnose.HealthyLevelRisk,
+		"default:ports/port-mismatch/81":       diagnose.HealthyLevelWarn,
+		"default:manual/no-ready-endpoints":    diagnose.HealthyLevelRisk,
+		"default:lb/lb-no-ingress":             diagnose.HealthyLevelRisk,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d results, got %d: %v", len(want), len(got), got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+}
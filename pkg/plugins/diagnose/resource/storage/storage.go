@@ -0,0 +1,284 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "storage"
+
+	// DefaultCriticalSelector is the default value of CriticalSelector
+	DefaultCriticalSelector = "kube-jarvis/critical=true"
+
+	defaultClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+	betaClassAnnotation        = "volume.beta.kubernetes.io/storage-class"
+)
+
+// Diagnostic check PersistentVolumes, PersistentVolumeClaims and StorageClasses
+type Diagnostic struct {
+	*diagnose.MetaData
+	// CriticalSelector is the label selector of critical PersistentVolumes or PersistentVolumeClaims,
+	// critical volumes should not use reclaim policy Delete
+	CriticalSelector string
+	critical         labels.Selector
+	classes          map[string]*storagev1.StorageClass
+	defaultClass     string
+	result           chan *diagnose.Result
+	param            *diagnose.StartDiagnoseParam
+}
+
+// NewDiagnostic return a storage diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.CriticalSelector == "" {
+		d.CriticalSelector = DefaultCriticalSelector
+	}
+
+	var err error
+	d.critical, err = labels.Parse(d.CriticalSelector)
+	if err != nil {
+		return fmt.Errorf("criticalselector is illegal: %v", err)
+	}
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"storage-title"}
+	for _, check := range []string{"pvc-pending", "class-not-found", "no-default-class", "pv-released",
+		"pv-failed", "critical-delete"} {
+		ids = append(ids, fmt.Sprintf("storage-%s-desc", check), fmt.Sprintf("storage-%s-proposal", check))
+	}
+	return ids
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.param = &param
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		res := param.Resources
+		d.classes = map[string]*storagev1.StorageClass{}
+		d.defaultClass = ""
+		if res.StorageClasses != nil {
+			for i := range res.StorageClasses.Items {
+				sc := &res.StorageClasses.Items[i]
+				d.classes[sc.Name] = sc
+				if sc.Annotations[defaultClassAnnotation] == "true" ||
+					sc.Annotations[betaDefaultClassAnnotation] == "true" {
+					d.defaultClass = sc.Name
+				}
+			}
+		}
+
+		pvcs := map[string]*corev1.PersistentVolumeClaim{}
+		if res.PersistentVolumeClaims != nil {
+			for i := range res.PersistentVolumeClaims.Items {
+				pvc := &res.PersistentVolumeClaims.Items[i]
+				pvcs[pvc.Namespace+"/"+pvc.Name] = pvc
+				d.diagnosePVC(pvc)
+			}
+		}
+
+		if res.PersistentVolumes != nil {
+			for i := range res.PersistentVolumes.Items {
+				pv := &res.PersistentVolumes.Items[i]
+				var pvc *corev1.PersistentVolumeClaim
+				if ref := pv.Spec.ClaimRef; ref != nil {
+					pvc = pvcs[ref.Namespace+"/"+ref.Name]
+				}
+				d.diagnosePV(pv, pvc)
+			}
+		}
+
+		if res.StatefulSets != nil {
+			for _, sts := range res.StatefulSets.Items {
+				sts.Kind = "StatefulSet"
+				for _, tpl := range sts.Spec.VolumeClaimTemplates {
+					obj := map[string]interface{}{
+						"Kind":      "StatefulSet",
+						"Namespace": sts.Namespace,
+						"Name":      sts.Name,
+						"Template":  tpl.Name,
+					}
+					d.diagnoseClass(diagnose.NewObjectRef(&sts), &tpl, diagnose.HealthyLevelRisk, obj)
+				}
+			}
+		}
+	}()
+	return d.result, nil
+}
+
+// className return the StorageClass name of pvc, nil is returned if class is not set
+func className(pvc *corev1.PersistentVolumeClaim) *string {
+	if name, exist := pvc.Annotations[betaClassAnnotation]; exist {
+		return &name
+	}
+	return pvc.Spec.StorageClassName
+}
+
+// diagnoseClass check the StorageClass used by pvc, return true if any problem is found
+func (d *Diagnostic) diagnoseClass(ref *diagnose.ObjectRef, pvc *corev1.PersistentVolumeClaim,
+	level diagnose.HealthyLevel, obj map[string]interface{}) bool {
+	class := className(pvc)
+	// an empty class means binding to PersistentVolumes without class
+	if class != nil && *class == "" {
+		return false
+	}
+
+	if class == nil {
+		if d.defaultClass != "" {
+			return false
+		}
+		d.sendResult(level, ref, "no-default-class", obj)
+		return true
+	}
+
+	if _, exist := d.classes[*class]; !exist {
+		obj["StorageClass"] = *class
+		d.sendResult(level, ref, "class-not-found", obj)
+		return true
+	}
+	return false
+}
+
+func (d *Diagnostic) diagnosePVC(pvc *corev1.PersistentVolumeClaim) {
+	ref := diagnose.NewObjectRef(&corev1.PersistentVolumeClaim{
+		TypeMeta:   metav1.TypeMeta{Kind: "PersistentVolumeClaim"},
+		ObjectMeta: pvc.ObjectMeta,
+	})
+	obj := map[string]interface{}{
+		"Kind":      "PersistentVolumeClaim",
+		"Namespace": pvc.Namespace,
+		"Name":      pvc.Name,
+	}
+
+	if pvc.Status.Phase != corev1.ClaimPending {
+		// the class of a bound PersistentVolumeClaim only affects resizing
+		if class := className(pvc); class != nil && *class != "" && d.classes[*class] == nil {
+			obj["StorageClass"] = *class
+			d.sendResult(diagnose.HealthyLevelWarn, ref, "class-not-found", obj)
+		}
+		return
+	}
+
+	if d.diagnoseClass(ref, pvc, diagnose.HealthyLevelRisk, obj) {
+		return
+	}
+
+	// volumes of WaitForFirstConsumer StorageClass are not bound until a pod using it is scheduled
+	if class := className(pvc); class != nil && d.classes[*class] != nil {
+		mode := d.classes[*class].VolumeBindingMode
+		if mode != nil && *mode == storagev1.VolumeBindingWaitForFirstConsumer && !d.used(pvc) {
+			return
+		}
+	}
+	d.sendResult(diagnose.HealthyLevelRisk, ref, "pvc-pending", obj)
+}
+
+// used return true if any pod uses pvc
+func (d *Diagnostic) used(pvc *corev1.PersistentVolumeClaim) bool {
+	if d.param.Resources.Pods == nil {
+		return false
+	}
+
+	for _, pod := range d.param.Resources.Pods.Items {
+		if pod.Namespace != pvc.Namespace {
+			continue
+		}
+
+		for _, v := range pod.Spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d *Diagnostic) diagnosePV(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) {
+	ref := diagnose.NewObjectRef(&corev1.PersistentVolume{
+		TypeMeta:   metav1.TypeMeta{Kind: "PersistentVolume"},
+		ObjectMeta: pv.ObjectMeta,
+	})
+	obj := map[string]interface{}{
+		"Kind":          "PersistentVolume",
+		"Name":          pv.Name,
+		"Phase":         string(pv.Status.Phase),
+		"ReclaimPolicy": string(pv.Spec.PersistentVolumeReclaimPolicy),
+	}
+
+	switch pv.Status.Phase {
+	case corev1.VolumeReleased:
+		d.sendResult(diagnose.HealthyLevelWarn, ref, "pv-released", obj)
+	case corev1.VolumeFailed:
+		d.sendResult(diagnose.HealthyLevelRisk, ref, "pv-failed", obj)
+	}
+
+	critical := d.critical.Matches(labels.Set(pv.Labels)) ||
+		(pvc != nil && d.critical.Matches(labels.Set(pvc.Labels)))
+	if critical && pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimDelete {
+		obj["Selector"] = d.CriticalSelector
+		d.sendResult(diagnose.HealthyLevelRisk, ref, "critical-delete", obj)
+	}
+}
+
+func (d *Diagnostic) sendResult(level diagnose.HealthyLevel, ref *diagnose.ObjectRef,
+	check string, obj map[string]interface{}) {
+	info := map[string]interface{}{
+		"Check": check,
+	}
+	for k, v := range obj {
+		info[k] = v
+	}
+
+	objName := ref.Name
+	if ref.Namespace != "" {
+		objName = fmt.Sprintf("%s:%s", ref.Namespace, ref.Name)
+	}
+
+	d.result <- &diagnose.Result{
+		Level:    level,
+		ObjName:  objName,
+		Obj:      ref,
+		ObjInfo:  info,
+		Title:    d.Translator.Message("storage-title", info),
+		Desc:     d.Translator.Message(fmt.Sprintf("storage-%s-desc", check), info),
+		Proposal: d.Translator.Message(fmt.Sprintf("storage-%s-proposal", check), info),
+	}
+}
@@ -0,0 +1,171 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package storage
+
+import (
+	"context"
+	"testing"
+
+	appv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func newPVC(name string, class *string, phase corev1.PersistentVolumeClaimPhase) corev1.PersistentVolumeClaim {
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: class},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func newPV(name string, phase corev1.PersistentVolumePhase, policy corev1.PersistentVolumeReclaimPolicy,
+	lb map[string]string, claim string) corev1.PersistentVolume {
+	pv := corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lb},
+		Spec:       corev1.PersistentVolumeSpec{PersistentVolumeReclaimPolicy: policy},
+		Status:     corev1.PersistentVolumeStatus{Phase: phase},
+	}
+	if claim != "" {
+		pv.Spec.ClaimRef = &corev1.ObjectReference{Namespace: "default", Name: claim}
+	}
+	return pv
+}
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	standard, local, missing, empty := "standard", "local", "missing", ""
+	wait := storagev1.VolumeBindingWaitForFirstConsumer
+
+	res := cluster.NewResources()
+	res.StorageClasses = &storagev1.StorageClassList{Items: []storagev1.StorageClass{
+		{ObjectMeta: metav1.ObjectMeta{Name: standard}},
+		{ObjectMeta: metav1.ObjectMeta{Name: local}, VolumeBindingMode: &wait},
+	}}
+
+	critical := newPVC("critical", &standard, corev1.ClaimBound)
+	critical.Labels = map[string]string{"kube-jarvis/critical": "true"}
+	res.PersistentVolumeClaims = &corev1.PersistentVolumeClaimList{Items: []corev1.PersistentVolumeClaim{
+		newPVC("bound", &standard, corev1.ClaimBound),
+		newPVC("pending", &standard, corev1.ClaimPending),
+		newPVC("waiting", &local, corev1.ClaimPending),
+		newPVC("waiting-used", &local, corev1.ClaimPending),
+		newPVC("missing", &missing, corev1.ClaimPending),
+		newPVC("bound-missing", &missing, corev1.ClaimBound),
+		newPVC("no-default", nil, corev1.ClaimPending),
+		newPVC("static", &empty, corev1.ClaimBound),
+		critical,
+	}}
+
+	res.Pods = &corev1.PodList{Items: []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod"},
+			Spec: corev1.PodSpec{Volumes: []corev1.Volume{
+				{VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "waiting-used",
+				}}},
+			}},
+		},
+	}}
+
+	res.PersistentVolumes = &corev1.PersistentVolumeList{Items: []corev1.PersistentVolume{
+		newPV("pv-bound", corev1.VolumeBound, corev1.PersistentVolumeReclaimDelete, nil, "bound"),
+		newPV("pv-released", corev1.VolumeReleased, corev1.PersistentVolumeReclaimRetain, nil, ""),
+		newPV("pv-failed", corev1.VolumeFailed, corev1.PersistentVolumeReclaimDelete, nil, ""),
+		newPV("pv-critical", corev1.VolumeBound, corev1.PersistentVolumeReclaimDelete,
+			map[string]string{"kube-jarvis/critical": "true"}, ""),
+		newPV("pv-critical-claim", corev1.VolumeBound, corev1.PersistentVolumeReclaimDelete, nil, "critical"),
+	}}
+
+	sts := appv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"}}
+	sts.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
+		newPVC("data", &missing, ""),
+		newPVC("log", &standard, ""),
+	}
+	res.StatefulSets = &appv1.StatefulSetList{Items: []appv1.StatefulSet{sts}}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	refs := map[string]string{}
+	for r := range results {
+		got[r.ObjName+"/"+r.ObjInfo["Check"].(string)] = r.Level
+		refs[r.ObjName] = r.Obj.Group + "/" + r.Obj.Kind
+	}
+
+	for name, want := range map[string]string{
+		"default:db":      "apps/StatefulSet",
+		"default:pending": "/PersistentVolumeClaim",
+		"pv-failed":       "/PersistentVolume",
+	} {
+		if refs[name] != want {
+			t.Fatalf("want object of %s %s but get %s", name, want, refs[name])
+		}
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"default:pending/pvc-pending":           diagnose.HealthyLevelRisk,
+		"default:waiting-used/pvc-pending":      diagnose.HealthyLevelRisk,
+		"default:missing/class-not-found":       diagnose.HealthyLevelRisk,
+		"default:bound-missing/class-not-found": diagnose.HealthyLevelWarn,
+		"default:no-default/no-default-class":   diagnose.HealthyLevelRisk,
+		"default:db/class-not-found":            diagnose.HealthyLevelRisk,
+		"pv-released/pv-released":               diagnose.HealthyLevelWarn,
+		"pv-failed/pv-failed":                   diagnose.HealthyLevelRisk,
+		"pv-critical/critical-delete":           diagnose.HealthyLevelRisk,
+		"pv-critical-claim/critical-delete":     diagnose.HealthyLevelRisk,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d results, got %d: %v", len(want), len(got), got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+	d.CriticalSelector = "a in (b"
+	if err := d.Complete(); err == nil {
+		t.Fatalf("want error")
+	}
+}
@@ -0,0 +1,264 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	ar "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+)
+
+const (
+	// DiagnosticType is type name of this Diagnostic
+	DiagnosticType = "admission-webhook"
+
+	// DefaultMaxTimeout is the default value of MaxTimeout
+	DefaultMaxTimeout = 10
+	// defaultTimeout is the timeout used by api server if timeoutSeconds is not set in v1beta1
+	defaultTimeout = 30
+
+	admissionGroup = "admissionregistration.k8s.io"
+)
+
+// webhook is the common fields of mutating and validating webhook
+type webhook struct {
+	Kind              string
+	Config            metav1.ObjectMeta
+	Name              string
+	ClientConfig      ar.WebhookClientConfig
+	FailurePolicy     *ar.FailurePolicyType
+	NamespaceSelector *metav1.LabelSelector
+	TimeoutSeconds    *int32
+}
+
+// failClosed return true if requests are rejected once webhook can not be called
+func (w *webhook) failClosed() bool {
+	// the default FailurePolicy of v1beta1 is Ignore
+	return w.FailurePolicy != nil && *w.FailurePolicy == ar.Fail
+}
+
+// Diagnostic check the configurations of admission webhooks and the services that serve them
+type Diagnostic struct {
+	*diagnose.MetaData
+	// MaxTimeout is the max recommended timeoutSeconds of webhooks
+	MaxTimeout int32
+	result     chan *diagnose.Result
+	param      *diagnose.StartDiagnoseParam
+}
+
+// NewDiagnostic return a admission-webhook diagnostic
+func NewDiagnostic(meta *diagnose.MetaData) diagnose.Diagnostic {
+	return &Diagnostic{
+		result:   make(chan *diagnose.Result, 1000),
+		MetaData: meta,
+	}
+}
+
+// Complete check and complete config items
+func (d *Diagnostic) Complete() error {
+	if d.MaxTimeout == 0 {
+		d.MaxTimeout = DefaultMaxTimeout
+	}
+
+	if d.MaxTimeout < 0 {
+		return fmt.Errorf("maxtimeout must be positive")
+	}
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	ids := []string{"webhook-title"}
+	for _, check := range []string{"service-not-found", "no-ready-endpoints", "no-cabundle", "kube-system", "timeout"} {
+		ids = append(ids, fmt.Sprintf("webhook-%s-desc", check), fmt.Sprintf("webhook-%s-proposal", check))
+	}
+	return ids
+}
+
+// StartDiagnose return a result chan that will output results
+func (d *Diagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	d.param = &param
+	d.result = make(chan *diagnose.Result, 1000)
+	go func() {
+		defer diagnose.CommonDeafer(d.result)
+		for _, w := range webhooks(param.Resources) {
+			d.diagnoseWebhook(w)
+		}
+	}()
+	return d.result, nil
+}
+
+// webhooks return all mutating and validating webhooks in resources
+func webhooks(res *cluster.Resources) []webhook {
+	result := make([]webhook, 0)
+	if res.MutatingWebhookConfigurations != nil {
+		for _, c := range res.MutatingWebhookConfigurations.Items {
+			for _, w := range c.Webhooks {
+				result = append(result, webhook{
+					Kind:              "MutatingWebhookConfiguration",
+					Config:            c.ObjectMeta,
+					Name:              w.Name,
+					ClientConfig:      w.ClientConfig,
+					FailurePolicy:     w.FailurePolicy,
+					NamespaceSelector: w.NamespaceSelector,
+					TimeoutSeconds:    w.TimeoutSeconds,
+				})
+			}
+		}
+	}
+
+	if res.ValidatingWebhookConfigurations != nil {
+		for _, c := range res.ValidatingWebhookConfigurations.Items {
+			for _, w := range c.Webhooks {
+				result = append(result, webhook{
+					Kind:              "ValidatingWebhookConfiguration",
+					Config:            c.ObjectMeta,
+					Name:              w.Name,
+					ClientConfig:      w.ClientConfig,
+					FailurePolicy:     w.FailurePolicy,
+					NamespaceSelector: w.NamespaceSelector,
+					TimeoutSeconds:    w.TimeoutSeconds,
+				})
+			}
+		}
+	}
+	return result
+}
+
+func (d *Diagnostic) diagnoseWebhook(w webhook) {
+	obj := map[string]interface{}{
+		"Kind":          w.Kind,
+		"Config":        w.Config.Name,
+		"Name":          w.Name,
+		"FailurePolicy": string(ar.Ignore),
+	}
+	if w.FailurePolicy != nil {
+		obj["FailurePolicy"] = string(*w.FailurePolicy)
+	}
+
+	if svc := w.ClientConfig.Service; svc != nil {
+		obj["Service"] = fmt.Sprintf("%s:%s", svc.Namespace, svc.Name)
+		d.diagnoseService(w, obj)
+
+		if len(w.ClientConfig.CABundle) == 0 {
+			d.sendResult(w, diagnose.HealthyLevelRisk, "no-cabundle", obj)
+		}
+	}
+
+	if w.failClosed() && d.selectKubeSystem(w) {
+		d.sendResult(w, diagnose.HealthyLevelRisk, "kube-system", obj)
+	}
+
+	timeout := int32(defaultTimeout)
+	if w.TimeoutSeconds != nil {
+		timeout = *w.TimeoutSeconds
+	}
+	if timeout > d.MaxTimeout {
+		obj["Timeout"] = timeout
+		obj["MaxTimeout"] = d.MaxTimeout
+		d.sendResult(w, diagnose.HealthyLevelWarn, "timeout", obj)
+	}
+}
+
+// diagnoseService check the Service referenced by webhook exists and has ready endpoints
+func (d *Diagnostic) diagnoseService(w webhook, obj map[string]interface{}) {
+	ref := w.ClientConfig.Service
+	var svc *corev1.Service
+	if d.param.Resources.Services != nil {
+		for i, s := range d.param.Resources.Services.Items {
+			if s.Namespace == ref.Namespace && s.Name == ref.Name {
+				svc = &d.param.Resources.Services.Items[i]
+				break
+			}
+		}
+	}
+
+	level := diagnose.HealthyLevelWarn
+	if w.failClosed() {
+		level = diagnose.HealthyLevelSerious
+	}
+
+	if svc == nil {
+		d.sendResult(w, level, "service-not-found", obj)
+		return
+	}
+
+	// ExternalName Services have no endpoints
+	if svc.Spec.Type == corev1.ServiceTypeExternalName || !w.failClosed() {
+		return
+	}
+
+	if d.param.Resources.ReadyEndpoints(svc) == 0 {
+		d.sendResult(w, level, "no-ready-endpoints", obj)
+	}
+}
+
+// selectKubeSystem return true if objects in namespace kube-system are sent to webhook
+func (d *Diagnostic) selectKubeSystem(w webhook) bool {
+	if w.NamespaceSelector == nil {
+		return true
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(w.NamespaceSelector)
+	if err != nil {
+		d.Logger.Errorf("parse namespaceSelector of webhook %s failed: %v", w.Name, err)
+		return false
+	}
+
+	nsLabels := labels.Set{}
+	if d.param.Resources.Namespaces != nil {
+		for _, ns := range d.param.Resources.Namespaces.Items {
+			if ns.Name == metav1.NamespaceSystem {
+				nsLabels = ns.Labels
+				break
+			}
+		}
+	}
+	return selector.Matches(nsLabels)
+}
+
+func (d *Diagnostic) sendResult(w webhook, level diagnose.HealthyLevel, check string, obj map[string]interface{}) {
+	info := map[string]interface{}{
+		"Check": check,
+	}
+	for k, v := range obj {
+		info[k] = v
+	}
+
+	d.result <- &diagnose.Result{
+		Level:   level,
+		ObjName: fmt.Sprintf("%s/%s", w.Config.Name, w.Name),
+		Obj: &diagnose.ObjectRef{
+			Kind:  w.Kind,
+			Group: admissionGroup,
+			Name:  w.Config.Name,
+			UID:   w.Config.UID,
+		},
+		ObjInfo:  info,
+		Title:    d.Translator.Message("webhook-title", info),
+		Desc:     d.Translator.Message(fmt.Sprintf("webhook-%s-desc", check), info),
+		Proposal: d.Translator.Message(fmt.Sprintf("webhook-%s-proposal", check), info),
+	}
+}
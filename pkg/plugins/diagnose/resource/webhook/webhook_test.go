@@ -0,0 +1,193 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	ar "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1beta1 "k8s.io/api/discovery/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestDiagnostic_StartDiagnose(t *testing.T) {
+	fail := ar.Fail
+	ignore := ar.Ignore
+	timeout := int32(5)
+	excludeSystem := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "name", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"kube-system"}},
+		},
+	}
+	svcConfig := func(name string, ca bool) ar.WebhookClientConfig {
+		c := ar.WebhookClientConfig{Service: &ar.ServiceReference{Namespace: "default", Name: name}}
+		if ca {
+			c.CABundle = []byte("ca")
+		}
+		return c
+	}
+
+	res := cluster.NewResources()
+	res.Namespaces = &corev1.NamespaceList{Items: []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Labels: map[string]string{"name": "kube-system"}}},
+	}}
+	res.Services = &corev1.ServiceList{Items: []corev1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ready"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "ready"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "down"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "down"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "manual"},
+		},
+	}}
+	ready := true
+	res.EndpointSlices = &discoveryv1beta1.EndpointSliceList{Items: []discoveryv1beta1.EndpointSlice{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "ready-abc",
+				Labels: map[string]string{discoveryv1beta1.LabelServiceName: "ready"}},
+			Endpoints: []discoveryv1beta1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1beta1.EndpointConditions{Ready: &ready}},
+			},
+		},
+	}}
+	res.Endpoints = &corev1.EndpointsList{Items: []corev1.Endpoints{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "down"},
+			Subsets: []corev1.EndpointSubset{
+				{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "manual"},
+			Subsets: []corev1.EndpointSubset{
+				{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}}},
+			},
+		},
+	}}
+	res.MutatingWebhookConfigurations = &ar.MutatingWebhookConfigurationList{
+		Items: []ar.MutatingWebhookConfiguration{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "mutating"},
+				Webhooks: []ar.MutatingWebhook{
+					{
+						Name:              "good",
+						ClientConfig:      svcConfig("ready", true),
+						FailurePolicy:     &fail,
+						NamespaceSelector: excludeSystem,
+						TimeoutSeconds:    &timeout,
+					},
+					{
+						Name:           "down",
+						ClientConfig:   svcConfig("down", true),
+						FailurePolicy:  &fail,
+						TimeoutSeconds: &timeout,
+					},
+					{
+						Name:              "manual",
+						ClientConfig:      svcConfig("manual", true),
+						FailurePolicy:     &fail,
+						NamespaceSelector: excludeSystem,
+						TimeoutSeconds:    &timeout,
+					},
+				},
+			},
+		},
+	}
+	res.ValidatingWebhookConfigurations = &ar.ValidatingWebhookConfigurationList{
+		Items: []ar.ValidatingWebhookConfiguration{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "validating"},
+				Webhooks: []ar.ValidatingWebhook{
+					{
+						Name:          "missing",
+						ClientConfig:  svcConfig("missing", false),
+						FailurePolicy: &ignore,
+					},
+					{
+						Name:           "ignore-down",
+						ClientConfig:   svcConfig("down", true),
+						TimeoutSeconds: &timeout,
+					},
+				},
+			},
+		},
+	}
+
+	d := NewDiagnostic(&diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: translate.NewFake(),
+			Logger:     logger.NewLogger(),
+			Type:       DiagnosticType,
+			Name:       DiagnosticType,
+		},
+	}).(*Diagnostic)
+	if err := d.Complete(); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	results, _ := d.StartDiagnose(context.Background(), diagnose.StartDiagnoseParam{
+		CloudType: "fake",
+		Resources: res,
+	})
+
+	got := map[string]diagnose.HealthyLevel{}
+	for r := range results {
+		got[r.ObjName+"/"+r.ObjInfo["Check"].(string)] = r.Level
+	}
+
+	want := map[string]diagnose.HealthyLevel{
+		"mutating/down/no-ready-endpoints":     diagnose.HealthyLevelSerious,
+		"mutating/down/kube-system":            diagnose.HealthyLevelRisk,
+		"validating/missing/service-not-found": diagnose.HealthyLevelWarn,
+		"validating/missing/no-cabundle":       diagnose.HealthyLevelRisk,
+		"validating/missing/timeout":           diagnose.HealthyLevelWarn,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("want %d results, got %d: %v", len(want), len(got), got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("want %s level %s but get %s", k, v, got[k])
+		}
+	}
+}
+
+func TestDiagnostic_Complete(t *testing.T) {
+	d := NewDiagnostic(&diagnose.MetaData{}).(*Diagnostic)
+	if err := d.Complete(); err != nil || d.MaxTimeout != DefaultMaxTimeout {
+		t.Fatalf("want default maxtimeout")
+	}
+
+	d.MaxTimeout = -1
+	if err := d.Complete(); err == nil {
+		t.Fatalf("want error")
+	}
+}
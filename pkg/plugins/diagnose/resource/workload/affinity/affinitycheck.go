@@ -56,6 +56,11 @@ func (d *Diagnostic) Complete() error {
 	return d.Filter.Compile()
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"title", "desc", "proposal"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
 	d.param = &param
@@ -145,6 +150,7 @@ func (d *Diagnostic) diagnosePod(pod *v12.Pod, rootOwner diagnose.MetaObject) {
 		d.result <- &diagnose.Result{
 			Level:    diagnose.HealthyLevelWarn,
 			ObjName:  fmt.Sprintf("%s:%s", rootOwner.GetNamespace(), rootOwner.GetName()),
+			Obj:      diagnose.NewObjectRef(rootOwner),
 			ObjInfo:  obj,
 			Title:    d.Translator.Message("title", nil),
 			Desc:     d.Translator.Message("desc", obj),
@@ -54,6 +54,15 @@ func (d *Diagnostic) Complete() error {
 	return d.Filter.Compile()
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"job-backofflimit-title", "job-backofflimit-desc", "job-backofflimit-proposal",
+		"cronjob-failedjobhistorylimit-title", "cronjob-failedjobhistorylimit-desc",
+		"cronjob-failedjobhistorylimit-proposal", "cronjob-successfuljobshistorylimit-title",
+		"cronjob-successfuljobshistorylimit-desc", "cronjob-successfuljobshistorylimit-proposal",
+		"cronjob-concurrencypolicy-title", "cronjob-concurrencypolicy-desc", "cronjob-concurrencypolicy-proposal"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
 	d.param = &param
@@ -82,11 +91,19 @@ func (d *Diagnostic) diagnoseJob(job v1.Job) {
 		"Name":             job.Name,
 		"RecommendedValue": 10,
 	}
+	ref := &diagnose.ObjectRef{
+		Kind:      "Job",
+		Group:     "batch",
+		Namespace: job.Namespace,
+		Name:      job.Name,
+		UID:       job.UID,
+	}
 
 	if job.Spec.BackoffLimit != nil && *job.Spec.BackoffLimit > 10 {
 		d.result <- &diagnose.Result{
 			Level:    diagnose.HealthyLevelWarn,
 			ObjName:  fmt.Sprintf("%s:%s", job.Namespace, job.Name),
+			Obj:      ref,
 			ObjInfo:  obj,
 			Title:    d.Translator.Message("job-backofflimit-title", nil),
 			Desc:     d.Translator.Message("job-backofflimit-desc", obj),
@@ -101,11 +118,19 @@ func (d *Diagnostic) diagnoseCronJob(cronJob v1beta1.CronJob) {
 		"Name":             cronJob.Name,
 		"RecommendedValue": 10,
 	}
+	ref := &diagnose.ObjectRef{
+		Kind:      "CronJob",
+		Group:     "batch",
+		Namespace: cronJob.Namespace,
+		Name:      cronJob.Name,
+		UID:       cronJob.UID,
+	}
 
 	if cronJob.Spec.FailedJobsHistoryLimit != nil && *cronJob.Spec.FailedJobsHistoryLimit > 10 {
 		d.result <- &diagnose.Result{
 			Level:    diagnose.HealthyLevelWarn,
 			ObjName:  fmt.Sprintf("CronJob:%s:%s", cronJob.Namespace, cronJob.Name),
+			Obj:      ref,
 			ObjInfo:  obj,
 			Title:    d.Translator.Message("cronjob-failedjobhistorylimit-title", nil),
 			Desc:     d.Translator.Message("cronjob-failedjobhistorylimit-desc", obj),
@@ -117,10 +142,11 @@ func (d *Diagnostic) diagnoseCronJob(cronJob v1beta1.CronJob) {
 		d.result <- &diagnose.Result{
 			Level:    diagnose.HealthyLevelWarn,
 			ObjName:  fmt.Sprintf("%s:%s", cronJob.Namespace, cronJob.Name),
+			Obj:      ref,
 			ObjInfo:  obj,
 			Title:    d.Translator.Message("cronjob-successfuljobshistorylimit-title", nil),
-			Desc:     d.Translator.Message("cronjob-failedjobhistorylimit-desc", obj),
-			Proposal: d.Translator.Message("cronjob-failedjobhistorylimit-proposal", obj),
+			Desc:     d.Translator.Message("cronjob-successfuljobshistorylimit-desc", obj),
+			Proposal: d.Translator.Message("cronjob-successfuljobshistorylimit-proposal", obj),
 		}
 	}
 
@@ -135,6 +161,7 @@ func (d *Diagnostic) diagnoseCronJob(cronJob v1beta1.CronJob) {
 		d.result <- &diagnose.Result{
 			Level:    diagnose.HealthyLevelWarn,
 			ObjName:  fmt.Sprintf("%s:%s", cronJob.Namespace, cronJob.Name),
+			Obj:      ref,
 			ObjInfo:  obj2,
 			Title:    d.Translator.Message("cronjob-concurrencypolicy-title", nil),
 			Desc:     d.Translator.Message("cronjob-concurrencypolicy-desc", obj2),
@@ -53,6 +53,11 @@ func (d *Diagnostic) Complete() error {
 	return d.Filter.Compile()
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"title", "desc", "proposal"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context,
 	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
@@ -151,9 +156,12 @@ func (d *Diagnostic) diagnoseBad(rootOwner diagnose.MetaObject, node string) {
 		"Node":      node,
 	}
 
+	ref := diagnose.NewObjectRef(rootOwner)
+	ref.Node = node
 	d.result <- &diagnose.Result{
 		Level:    diagnose.HealthyLevelWarn,
 		ObjName:  fmt.Sprintf("%s:%s", rootOwner.GetNamespace(), rootOwner.GetName()),
+		Obj:      ref,
 		ObjInfo:  obj,
 		Title:    d.Translator.Message("title", nil),
 		Desc:     d.Translator.Message("desc", obj),
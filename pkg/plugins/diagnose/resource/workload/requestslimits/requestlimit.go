@@ -56,6 +56,11 @@ func (d *Diagnostic) Complete() error {
 	return d.Filter.Compile()
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"title", "desc", "proposal"}
+}
+
 // StartDiagnose return a result chan that will output results
 func (d *Diagnostic) StartDiagnose(ctx context.Context,
 	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
@@ -121,6 +126,7 @@ func (d *Diagnostic) diagnosePod(pod v12.Pod, rootOwner diagnose.MetaObject) {
 				Level:    diagnose.HealthyLevelWarn,
 				Title:    d.Translator.Message("title", nil),
 				ObjName:  fmt.Sprintf("%s:%s", rootOwner.GetNamespace(), rootOwner.GetName()),
+				Obj:      diagnose.NewObjectRef(rootOwner),
 				ObjInfo:  obj,
 				Desc:     d.Translator.Message("desc", obj),
 				Proposal: d.Translator.Message("proposal", obj),
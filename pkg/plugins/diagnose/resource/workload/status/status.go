@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/types"
 	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
 
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
@@ -42,10 +43,18 @@ func (d *Diagnostic) Complete() error {
 	return d.Filter.Compile()
 }
 
+// MessageIDs return all message IDs that may be used by this Diagnostic
+func (d *Diagnostic) MessageIDs() []string {
+	return []string{"workload-status-title", "workload-status-desc", "workload-status-proposal",
+		"workload-status-good-desc", "workload-status-good-proposal"}
+}
+
 // ResourceItem is a inner struct
 type ResourceItem struct {
 	// Name is the name of workload.
 	Name string
+	// UID is the uid of workload.
+	UID types.UID
 	// Replicas is the replicas of workload.
 	Replicas int32
 	// Available is the available replicas of workload.
@@ -92,6 +101,7 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiag
 				}
 				appendWhatever(rsMap, deploy.Namespace, 0, ResourceItem{
 					Name:      deploy.Name,
+					UID:       deploy.UID,
 					Replicas:  replicas,
 					Available: deploy.Status.AvailableReplicas,
 				})
@@ -106,6 +116,7 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiag
 
 				appendWhatever(rsMap, ds.Namespace, 1, ResourceItem{
 					Name:      ds.Name,
+					UID:       ds.UID,
 					Replicas:  ds.Status.DesiredNumberScheduled,
 					Available: ds.Status.NumberReady,
 				})
@@ -124,6 +135,7 @@ func (d *Diagnostic) StartDiagnose(ctx context.Context, param diagnose.StartDiag
 				}
 				appendWhatever(rsMap, sts.Namespace, 2, ResourceItem{
 					Name:      sts.Name,
+					UID:       sts.UID,
 					Replicas:  replicas,
 					Available: sts.Status.ReadyReplicas,
 				})
@@ -161,9 +173,16 @@ func (d *Diagnostic) diagnose(rsMap ResourceMap) {
 				}
 
 				d.result <- &diagnose.Result{
-					Level:    level,
-					Title:    d.Translator.Message("workload-status-title", nil),
-					ObjName:  fmt.Sprintf("%s:%s", namespace, rsLists[typ][idx].Name),
+					Level:   level,
+					Title:   d.Translator.Message("workload-status-title", nil),
+					ObjName: fmt.Sprintf("%s:%s", namespace, rsLists[typ][idx].Name),
+					Obj: &diagnose.ObjectRef{
+						Kind:      WorkloadType[typ],
+						Group:     "apps",
+						Namespace: namespace,
+						Name:      rs.Name,
+						UID:       rs.UID,
+					},
 					ObjInfo:  obj,
 					Desc:     d.Translator.Message(descId, obj),
 					Proposal: d.Translator.Message(proposalId, obj),
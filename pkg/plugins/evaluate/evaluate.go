@@ -0,0 +1,75 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package evaluate
+
+import (
+	"context"
+
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+)
+
+// MetaData contains core attributes of a Evaluator
+type MetaData struct {
+	plugins.MetaData
+}
+
+// Meta return core MetaData
+// this function can be use for struct implement Evaluator interface
+func (m *MetaData) Meta() MetaData {
+	return *m
+}
+
+// EvaluateParam contains all items that Evaluate need
+type EvaluateParam struct {
+	// CloudType is the cloud provider type fo cluster
+	CloudType string
+	// Resources contains all diagnose able resources
+	Resources *cluster.Resources
+	// Result contains results of all diagnostics
+	// Evaluator can add, annotate, re-level or correlate results in it
+	Result *export.AllResult
+}
+
+// Evaluator evaluate all diagnostic results before they are exported
+type Evaluator interface {
+	// Complete check and complete config items
+	Complete() error
+	// Meta return core attributes
+	Meta() MetaData
+	// Evaluate update param.Result in place
+	// Statistics of param.Result will be refreshed after all evaluators done
+	Evaluate(ctx context.Context, param EvaluateParam) error
+}
+
+// Factory create a new Evaluator
+type Factory struct {
+	// Creator is a factory function to create Evaluator
+	Creator func(d *MetaData) Evaluator
+	// SupportedClouds indicate what cloud providers will be supported of this evaluator
+	SupportedClouds []string
+}
+
+// Factories store all registered Evaluator Creator
+var Factories = map[string]Factory{}
+
+// Add register a Evaluator Factory
+func Add(typ string, f Factory) {
+	Factories[typ] = f
+}
@@ -0,0 +1,160 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package correlation
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	nodestatus "tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/status"
+	workloadstatus "tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/status"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+)
+
+const (
+	// EvaluatorType is type name of this Evaluator
+	EvaluatorType = "node-correlation"
+)
+
+// Evaluator explain unhealthy workload-status results by unhealthy node-status results
+// if pods of a unhealthy workload run on unhealthy nodes, an annotation will be added
+type Evaluator struct {
+	*evaluate.MetaData
+}
+
+// NewEvaluator return a node-correlation Evaluator
+func NewEvaluator(m *evaluate.MetaData) evaluate.Evaluator {
+	return &Evaluator{
+		MetaData: m,
+	}
+}
+
+// Complete check and complete config items
+func (e *Evaluator) Complete() error {
+	return nil
+}
+
+// MessageIDs return all message IDs that may be used by this Evaluator
+func (e *Evaluator) MessageIDs() []string {
+	return []string{"bad-node"}
+}
+
+// Evaluate add annotations to unhealthy workload-status results
+func (e *Evaluator) Evaluate(ctx context.Context, param evaluate.EvaluateParam) error {
+	badNodes := map[string]*diagnose.Result{}
+	for _, r := range resultsOf(param.Result, nodestatus.DiagnosticType) {
+		if r.Obj != nil && r.Level.Compare(diagnose.HealthyLevelGood) < 0 {
+			badNodes[r.Obj.Name] = r
+		}
+	}
+
+	if len(badNodes) == 0 || param.Resources == nil || param.Resources.Pods == nil {
+		return nil
+	}
+
+	workloadNodes := e.workloadNodes(param.Resources)
+	for _, r := range resultsOf(param.Result, workloadstatus.DiagnosticType) {
+		if r.Obj == nil || r.Level.Compare(diagnose.HealthyLevelGood) >= 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", r.Obj.Kind, r.Obj.Namespace, r.Obj.Name)
+		for _, node := range workloadNodes[key] {
+			nodeResult, exist := badNodes[node]
+			if !exist {
+				continue
+			}
+
+			r.Annotations = append(r.Annotations, e.Translator.Message("bad-node", map[string]interface{}{
+				"Workload":  r.Obj.Kind,
+				"Namespace": r.Obj.Namespace,
+				"Name":      r.Obj.Name,
+				"Node":      node,
+				"Type":      nodeResult.ObjInfo["Type"],
+				"Status":    nodeResult.ObjInfo["Status"],
+			}))
+		}
+	}
+	return nil
+}
+
+// workloadNodes return the nodes that pods of every workload run at
+// the key of returned map is "Kind/Namespace/Name"
+func (e *Evaluator) workloadNodes(res *cluster.Resources) map[string][]string {
+	uid2obj := make(map[types.UID]diagnose.MetaObject)
+	if res.Deployments != nil {
+		for _, deploy := range res.Deployments.Items {
+			deploy.Kind = "Deployment"
+			uid2obj[deploy.UID] = deploy.DeepCopy()
+		}
+	}
+
+	if res.ReplicaSets != nil {
+		for _, rs := range res.ReplicaSets.Items {
+			rs.Kind = "ReplicaSet"
+			uid2obj[rs.UID] = rs.DeepCopy()
+		}
+	}
+
+	if res.StatefulSets != nil {
+		for _, sts := range res.StatefulSets.Items {
+			sts.Kind = "StatefulSet"
+			uid2obj[sts.UID] = sts.DeepCopy()
+		}
+	}
+
+	if res.DaemonSets != nil {
+		for _, ds := range res.DaemonSets.Items {
+			ds.Kind = "DaemonSet"
+			uid2obj[ds.UID] = ds.DeepCopy()
+		}
+	}
+
+	result := map[string][]string{}
+	exist := map[string]bool{}
+	for _, pod := range res.Pods.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+
+		pod.Kind = "Pod"
+		rootOwner := diagnose.GetRootOwner(&pod, uid2obj)
+		key := fmt.Sprintf("%s/%s/%s", rootOwner.GroupVersionKind().Kind,
+			rootOwner.GetNamespace(), rootOwner.GetName())
+		if exist[key+"/"+pod.Spec.NodeName] {
+			continue
+		}
+		exist[key+"/"+pod.Spec.NodeName] = true
+		result[key] = append(result[key], pod.Spec.NodeName)
+	}
+	return result
+}
+
+func resultsOf(all *export.AllResult, typ string) []*diagnose.Result {
+	results := make([]*diagnose.Result, 0)
+	for _, dia := range all.Diagnostics {
+		if dia.Type == typ {
+			results = append(results, dia.Results...)
+		}
+	}
+	return results
+}
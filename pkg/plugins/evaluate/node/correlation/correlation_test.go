@@ -0,0 +1,152 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package correlation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	nodestatus "tkestack.io/kube-jarvis/pkg/plugins/diagnose/node/status"
+	workloadstatus "tkestack.io/kube-jarvis/pkg/plugins/diagnose/resource/workload/status"
+	"tkestack.io/kube-jarvis/pkg/plugins/evaluate"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+func TestEvaluator_Evaluate(t *testing.T) {
+	var cases = []struct {
+		nodeLevel   diagnose.HealthyLevel
+		podNode     string
+		annotations int
+	}{
+		{
+			nodeLevel:   diagnose.HealthyLevelRisk,
+			podNode:     "node1",
+			annotations: 1,
+		},
+		{
+			nodeLevel:   diagnose.HealthyLevelGood,
+			podNode:     "node1",
+			annotations: 0,
+		},
+		{
+			nodeLevel:   diagnose.HealthyLevelRisk,
+			podNode:     "node2",
+			annotations: 0,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			ctl := true
+			res := cluster.NewResources()
+			res.Deployments = &appv1.DeploymentList{}
+			res.ReplicaSets = &appv1.ReplicaSetList{}
+			res.Pods = &v1.PodList{}
+
+			deploy := appv1.Deployment{}
+			deploy.Name = "deploy1"
+			deploy.Namespace = "default"
+			deploy.UID = "deploy1"
+			res.Deployments.Items = append(res.Deployments.Items, deploy)
+
+			rs := appv1.ReplicaSet{}
+			rs.Name = "rs1"
+			rs.Namespace = "default"
+			rs.UID = "rs1"
+			rs.OwnerReferences = []metav1.OwnerReference{{UID: deploy.UID, Controller: &ctl}}
+			res.ReplicaSets.Items = append(res.ReplicaSets.Items, rs)
+
+			pod := v1.Pod{}
+			pod.Name = "pod1"
+			pod.Namespace = "default"
+			pod.UID = "pod1"
+			pod.OwnerReferences = []metav1.OwnerReference{{UID: rs.UID, Controller: &ctl}}
+			pod.Spec.NodeName = cs.podNode
+			res.Pods.Items = append(res.Pods.Items, pod)
+
+			workloadResult := &diagnose.Result{
+				Level:   diagnose.HealthyLevelWarn,
+				ObjName: "default:deploy1",
+				Obj: &diagnose.ObjectRef{
+					Kind:      "Deployment",
+					Group:     "apps",
+					Namespace: "default",
+					Name:      "deploy1",
+				},
+				ObjInfo: map[string]interface{}{
+					"Name":      "deploy1",
+					"Namespace": "default",
+					"Workload":  "Deployment",
+				},
+			}
+
+			all := export.NewAllResult()
+			all.AddDiagnosticResultItem(&export.DiagnosticResultItem{
+				Type: nodestatus.DiagnosticType,
+				Results: []*diagnose.Result{
+					{
+						Level:   cs.nodeLevel,
+						ObjName: "node1",
+						Obj:     diagnose.NewNodeRef("node1"),
+						ObjInfo: map[string]interface{}{
+							"Type":   v1.NodeReady,
+							"Status": v1.ConditionFalse,
+						},
+					},
+				},
+			})
+			all.AddDiagnosticResultItem(&export.DiagnosticResultItem{
+				Type:    workloadstatus.DiagnosticType,
+				Results: []*diagnose.Result{workloadResult},
+			})
+
+			e := NewEvaluator(&evaluate.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       EvaluatorType,
+					Name:       EvaluatorType,
+				},
+			})
+
+			if err := e.Complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if err := e.Evaluate(context.Background(), evaluate.EvaluateParam{
+				Resources: res,
+				Result:    all,
+			}); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			if len(workloadResult.Annotations) != cs.annotations {
+				t.Fatalf("want %d annotations but get %d", cs.annotations, len(workloadResult.Annotations))
+			}
+		})
+	}
+}
@@ -19,6 +19,7 @@ package all
 
 import (
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/export/remote"
 	"tkestack.io/kube-jarvis/pkg/plugins/export/stdout"
 	"tkestack.io/kube-jarvis/pkg/plugins/export/store"
 )
@@ -30,4 +31,7 @@ func init() {
 	export.Add(store.ExporterType, export.Factory{
 		Creator: store.NewExporter,
 	})
+	export.Add(remote.ExporterType, export.Factory{
+		Creator: remote.NewExporter,
+	})
 }
@@ -0,0 +1,128 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+const (
+	// ExporterType is type name of this Exporter
+	ExporterType = "remote"
+)
+
+// Exporter send results to a Exporter served by a remote plugin server via gRPC
+// see package "tkestack.io/kube-jarvis/pkg/plugins/remote" for the protocol
+type Exporter struct {
+	*export.MetaData
+	// Address is the address of remote plugin server, e.g. "127.0.0.1:9000"
+	Address string
+	// CAFile is the CA file to verify server certificate, TLS will not be used if it is empty
+	CAFile string
+	// ServerName is used to verify the hostname of server certificate
+	ServerName string
+	// Plugin is the type of remote Exporter
+	// it can be empty if server only serve one Exporter
+	Plugin string
+	// Timeout is the max running time of remote Exporter, default is "1m"
+	Timeout string
+	// Config is the config of remote Exporter
+	Config interface{}
+
+	timeout time.Duration
+}
+
+// NewExporter return a remote Exporter
+func NewExporter(m *export.MetaData) export.Exporter {
+	return &Exporter{
+		MetaData: m,
+	}
+}
+
+// Complete check and complete config items
+func (e *Exporter) Complete() error {
+	if e.Address == "" {
+		return fmt.Errorf("address can not be empty")
+	}
+
+	if e.Timeout == "" {
+		e.Timeout = "1m"
+	}
+
+	var err error
+	e.timeout, err = time.ParseDuration(e.Timeout)
+	if err != nil {
+		return errors.Wrapf(err, "parse timeout failed")
+	}
+	return nil
+}
+
+// Export export result
+func (e *Exporter) Export(ctx context.Context, result *export.AllResult) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	client, err := remote.Dial(ctx, remote.ClientConfig{
+		Address:    e.Address,
+		CAFile:     e.CAFile,
+		ServerName: e.ServerName,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	resp, err := client.Handshake(ctx)
+	if err != nil {
+		return err
+	}
+
+	typ, err := e.choose(resp.Exporters)
+	if err != nil {
+		return err
+	}
+
+	return client.Export(ctx, &remote.ExportRequest{
+		Type:   typ,
+		Name:   e.Name,
+		Config: util.JSONCompatible(e.Config),
+	}, result)
+}
+
+func (e *Exporter) choose(infos []remote.PluginInfo) (string, error) {
+	if e.Plugin == "" {
+		if len(infos) != 1 {
+			return "", fmt.Errorf("server serve %d exporters, plugin must be specified", len(infos))
+		}
+		return infos[0].Type, nil
+	}
+
+	for _, info := range infos {
+		if info.Type == e.Plugin {
+			return info.Type, nil
+		}
+	}
+	return "", fmt.Errorf("can not found exporter %s in server", e.Plugin)
+}
@@ -0,0 +1,119 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+type fakeExporter struct {
+	*export.MetaData
+	Path   string
+	result chan string
+}
+
+func (f *fakeExporter) Complete() error {
+	return nil
+}
+
+func (f *fakeExporter) Export(ctx context.Context, result *export.AllResult) error {
+	f.result <- fmt.Sprintf("%s:%d", f.Path, len(result.Diagnostics))
+	return nil
+}
+
+func TestExporter_Export(t *testing.T) {
+	exported := make(chan string, 10)
+	s := remote.NewServer()
+	for _, typ := range []string{"a", "b"} {
+		s.AddExporter(typ, export.Factory{
+			Creator: func(e *export.MetaData) export.Exporter {
+				return &fakeExporter{MetaData: e, result: exported}
+			},
+		})
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	defer s.Stop()
+
+	var cases = []struct {
+		plugin  string
+		wantErr bool
+	}{
+		{
+			plugin: "a",
+		},
+		{
+			plugin:  "",
+			wantErr: true,
+		},
+		{
+			plugin:  "c",
+			wantErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			e := NewExporter(&export.MetaData{
+				MetaData: plugins.MetaData{
+					Translator: translate.NewFake(),
+					Logger:     logger.NewLogger(),
+					Type:       ExporterType,
+					Name:       ExporterType,
+				},
+			}).(*Exporter)
+			e.Address = lis.Addr().String()
+			e.Plugin = cs.plugin
+			e.Config = map[interface{}]interface{}{
+				"path": "/tmp/result",
+			}
+
+			if err := e.Complete(); err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			result := export.NewAllResult()
+			result.AddDiagnosticResultItem(&export.DiagnosticResultItem{Name: "test"})
+			err := e.Export(context.Background(), result)
+			if (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+
+			if !cs.wantErr {
+				if r := <-exported; r != "/tmp/result:1" {
+					t.Fatalf("want /tmp/result:1 but get %s", r)
+				}
+			}
+		})
+	}
+}
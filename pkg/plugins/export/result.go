@@ -56,6 +56,38 @@ func (d *DiagnosticResultItem) AddResult(r *diagnose.Result) {
 	d.Statistics[r.Level]++
 }
 
+// RefreshStatistics recount Statistics according to Results
+func (d *DiagnosticResultItem) RefreshStatistics() {
+	d.Statistics = map[diagnose.HealthyLevel]int{}
+	for _, r := range d.Results {
+		d.Statistics[r.Level]++
+	}
+}
+
+// Translate return a copy of DiagnosticResultItem with all messages translated by t
+func (d *DiagnosticResultItem) Translate(t translate.Translator) *DiagnosticResultItem {
+	newDia := *d
+	newDia.Desc = translate.Literal(t.Translate(d.Desc))
+	newDia.Results = make([]*diagnose.Result, 0, len(d.Results))
+	for _, r := range d.Results {
+		newDia.Results = append(newDia.Results, TranslateResult(r, t))
+	}
+	return &newDia
+}
+
+// TranslateResult return a copy of Result with all messages translated by t
+func TranslateResult(r *diagnose.Result, t translate.Translator) *diagnose.Result {
+	newResult := *r
+	newResult.Title = translate.Literal(t.Translate(r.Title))
+	newResult.Desc = translate.Literal(t.Translate(r.Desc))
+	newResult.Proposal = translate.Literal(t.Translate(r.Proposal))
+	newResult.Annotations = nil
+	for _, a := range r.Annotations {
+		newResult.Annotations = append(newResult.Annotations, translate.Literal(t.Translate(a)))
+	}
+	return &newResult
+}
+
 // AllResult just collect diagnostic results and progress
 type AllResult struct {
 	StartTime   time.Time
@@ -81,6 +113,27 @@ func (r *AllResult) AddDiagnosticResultItem(d *DiagnosticResultItem) {
 	}
 }
 
+// RefreshStatistics recount Statistics of AllResult and all DiagnosticResultItems
+func (r *AllResult) RefreshStatistics() {
+	r.Statistics = map[diagnose.HealthyLevel]int{}
+	for _, d := range r.Diagnostics {
+		d.RefreshStatistics()
+		for level, num := range d.Statistics {
+			r.Statistics[level] += num
+		}
+	}
+}
+
+// Translate return a copy of AllResult with all messages translated by t
+func (r *AllResult) Translate(t translate.Translator) *AllResult {
+	newResult := *r
+	newResult.Diagnostics = make([]*DiagnosticResultItem, 0, len(r.Diagnostics))
+	for _, d := range r.Diagnostics {
+		newResult.Diagnostics = append(newResult.Diagnostics, d.Translate(t))
+	}
+	return &newResult
+}
+
 // Marshal make AllResult become json
 func (r *AllResult) Marshal() ([]byte, error) {
 	return json.Marshal(r)
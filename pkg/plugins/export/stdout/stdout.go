@@ -66,6 +66,10 @@ func (e *Exporter) Complete() error {
 
 // Export export result
 func (e *Exporter) Export(ctx context.Context, result *export.AllResult) error {
+	if e.Translator != nil {
+		result = result.Translate(e.Translator)
+	}
+
 	if e.Format != "fmt" {
 		data, err := result.Marshal()
 		if err != nil {
@@ -116,6 +120,9 @@ func (e *Exporter) Export(ctx context.Context, result *export.AllResult) error {
 			pt("[%s] %s -> %s\n", result.Level, result.Title, result.ObjName)
 			pt("    Describe : %s\n", result.Desc)
 			pt("    Proposal : %s\n", result.Proposal)
+			for _, a := range result.Annotations {
+				pt("    Annotation : %s\n", a)
+			}
 			fmt.Printf("- -----------------------------\n")
 		}
 	}
@@ -55,22 +55,22 @@ func TestExporter_Export(t *testing.T) {
 				Results: []*diagnose.Result{
 					{
 						Level: diagnose.HealthyLevelGood,
-						Title: "good",
+						Title: translate.Literal("good"),
 					},
 					{
 						Level: diagnose.HealthyLevelWarn,
-						Title: "warn",
+						Title: translate.Literal("warn"),
 					}, {
 						Level: diagnose.HealthyLevelRisk,
-						Title: "risk",
+						Title: translate.Literal("risk"),
 					},
 					{
 						Level: diagnose.HealthyLevelSerious,
-						Title: "serious",
+						Title: translate.Literal("serious"),
 					},
 					{
 						Level: diagnose.HealthyLevelFailed,
-						Title: "failed",
+						Title: translate.Literal("failed"),
 					},
 				},
 				Statistics: map[diagnose.HealthyLevel]int{
@@ -28,6 +28,7 @@ import (
 
 	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
 	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/translate"
 )
 
 func (e *Exporter) queryHandler(w http.ResponseWriter, r *http.Request) {
@@ -80,6 +81,11 @@ func (e *Exporter) queryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	trans := e.Translator
+	if lang := requestLang(r, param); lang != "" {
+		trans = trans.WithLang(lang)
+	}
+
 	respResult := httpserver.NewQueryResponse()
 	respResult.StartTime = allResults.StartTime
 	respResult.EndTime = allResults.EndTime
@@ -100,7 +106,7 @@ func (e *Exporter) queryHandler(w http.ResponseWriter, r *http.Request) {
 			Catalogue:  dia.Catalogue,
 			Type:       dia.Type,
 			Name:       dia.Name,
-			Desc:       dia.Desc,
+			Desc:       translate.Literal(trans.Translate(dia.Desc)),
 			Results:    []*diagnose.Result{},
 			Statistics: dia.Statistics,
 		}
@@ -121,7 +127,7 @@ func (e *Exporter) queryHandler(w http.ResponseWriter, r *http.Request) {
 				break
 			}
 
-			newDia.Results = append(newDia.Results, item)
+			newDia.Results = append(newDia.Results, export.TranslateResult(item, trans))
 		}
 		respResult.Diagnostics = append(respResult.Diagnostics, newDia)
 	}
@@ -143,3 +149,16 @@ func (e *Exporter) queryHandler(w http.ResponseWriter, r *http.Request) {
 		total += n
 	}
 }
+
+// requestLang return the target language of a query request
+func requestLang(r *http.Request, param *httpserver.QueryRequest) string {
+	if param.Lang != "" {
+		return param.Lang
+	}
+
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		return lang
+	}
+
+	return r.Header.Get("Accept-Language")
+}
@@ -0,0 +1,211 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote/pluginv1"
+)
+
+// ClientConfig contains all items that connecting to a remote plugin server need
+type ClientConfig struct {
+	// Address is the address of remote plugin server, e.g. "127.0.0.1:9000"
+	Address string
+	// CAFile is the CA file to verify server certificate
+	// TLS will not be used if it is empty
+	CAFile string
+	// ServerName is used to verify the hostname of server certificate
+	// the host of Address will be used if it is empty
+	ServerName string
+	// MaxMsgSize is the max size of message, default is DefaultMaxMsgSize
+	MaxMsgSize int
+}
+
+// Client call a remote plugin server
+type Client struct {
+	conn   *grpc.ClientConn
+	plugin pluginv1.PluginClient
+}
+
+// Dial connect to a remote plugin server
+func Dial(ctx context.Context, config ClientConfig) (*Client, error) {
+	if config.MaxMsgSize == 0 {
+		config.MaxMsgSize = DefaultMaxMsgSize
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithBlock(),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(config.MaxMsgSize),
+			grpc.MaxCallSendMsgSize(config.MaxMsgSize),
+		),
+	}
+
+	if config.CAFile == "" {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		cred, err := credentials.NewClientTLSFromFile(config.CAFile, config.ServerName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load ca file failed")
+		}
+		opts = append(opts, grpc.WithTransportCredentials(cred))
+	}
+
+	conn, err := grpc.DialContext(ctx, config.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "dial %s failed", config.Address)
+	}
+
+	return &Client{conn: conn, plugin: pluginv1.NewPluginClient(conn)}, nil
+}
+
+// Handshake return the attributes of all plugins served by server
+// an error will be returned if protocol version of server is different from client
+func (c *Client) Handshake(ctx context.Context) (*HandshakeResponse, error) {
+	resp, err := c.plugin.Handshake(ctx, &pluginv1.HandshakeRequest{
+		ProtocolVersion: ProtocolVersion,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "handshake failed")
+	}
+
+	if resp.ProtocolVersion != ProtocolVersion {
+		return nil, fmt.Errorf("protocol version of server is %s, but %s is required",
+			resp.ProtocolVersion, ProtocolVersion)
+	}
+
+	return &HandshakeResponse{
+		ProtocolVersion: resp.ProtocolVersion,
+		Diagnostics:     pluginInfosFromPB(resp.Diagnostics),
+		Exporters:       pluginInfosFromPB(resp.Exporters),
+	}, nil
+}
+
+// Diagnose start a remote Diagnostic and send results to "result" one by one
+// "result" will not be closed
+func (c *Client) Diagnose(ctx context.Context, req *DiagnoseRequest, result chan *diagnose.Result) error {
+	config, err := marshalJSON(req.Config)
+	if err != nil {
+		return errors.Wrap(err, "marshal config failed")
+	}
+
+	resources, err := marshalJSON(req.Resources)
+	if err != nil {
+		return errors.Wrap(err, "marshal resources failed")
+	}
+
+	stream, err := c.plugin.Diagnose(ctx, &pluginv1.DiagnoseRequest{
+		Type:      req.Type,
+		Name:      req.Name,
+		Config:    config,
+		CloudType: req.CloudType,
+		Resources: resources,
+	})
+	if err != nil {
+		return errors.Wrap(err, "create stream failed")
+	}
+
+	for {
+		pr, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrap(err, "receive result failed")
+		}
+
+		r, err := resultFromPB(pr)
+		if err != nil {
+			return err
+		}
+		result <- r
+	}
+}
+
+// Export send "result" to a remote Exporter
+// the diagnostics of result will be sent one by one
+func (c *Client) Export(ctx context.Context, req *ExportRequest, result *export.AllResult) error {
+	config, err := marshalJSON(req.Config)
+	if err != nil {
+		return errors.Wrap(err, "marshal config failed")
+	}
+
+	header, err := marshalJSON(exportHeader(result))
+	if err != nil {
+		return errors.Wrap(err, "marshal result failed")
+	}
+
+	stream, err := c.plugin.Export(ctx)
+	if err != nil {
+		return errors.Wrap(err, "create stream failed")
+	}
+
+	if err := c.sendExport(stream, result, &pluginv1.ExportRequest{
+		Type:   req.Type,
+		Name:   req.Name,
+		Config: config,
+		Result: header,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return errors.Wrap(err, "export failed")
+	}
+	return nil
+}
+
+// sendExport send all messages of Export
+// io.EOF means the stream is closed by server, the real error will be returned by CloseAndRecv
+func (c *Client) sendExport(stream pluginv1.Plugin_ExportClient, result *export.AllResult,
+	first *pluginv1.ExportRequest) error {
+	if err := stream.Send(first); err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		return errors.Wrap(err, "send request failed")
+	}
+
+	for _, d := range result.Diagnostics {
+		data, err := marshalJSON(d)
+		if err != nil {
+			return errors.Wrapf(err, "marshal diagnostic %s failed", d.Name)
+		}
+
+		if err := stream.Send(&pluginv1.ExportRequest{Diagnostic: data}); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrapf(err, "send diagnostic %s failed", d.Name)
+		}
+	}
+	return nil
+}
+
+// Close close the connection to server
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,849 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugin.proto
+
+package pluginv1
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// PluginInfo is the core attributes of a remote plugin
+type PluginInfo struct {
+	// type is the type of plugin
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// desc is the translated description of plugin
+	Desc string `protobuf:"bytes,2,opt,name=desc,proto3" json:"desc,omitempty"`
+	// catalogue is the catalogue of Diagnostic, it is empty for Exporter
+	Catalogue []string `protobuf:"bytes,3,rep,name=catalogue,proto3" json:"catalogue,omitempty"`
+	// supported_clouds are the supported cloud providers, all cloud providers are supported if it is empty
+	SupportedClouds      []string `protobuf:"bytes,4,rep,name=supported_clouds,json=supportedClouds,proto3" json:"supported_clouds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PluginInfo) Reset()         { *m = PluginInfo{} }
+func (m *PluginInfo) String() string { return proto.CompactTextString(m) }
+func (*PluginInfo) ProtoMessage()    {}
+func (*PluginInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{0}
+}
+
+func (m *PluginInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PluginInfo.Unmarshal(m, b)
+}
+func (m *PluginInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PluginInfo.Marshal(b, m, deterministic)
+}
+func (m *PluginInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PluginInfo.Merge(m, src)
+}
+func (m *PluginInfo) XXX_Size() int {
+	return xxx_messageInfo_PluginInfo.Size(m)
+}
+func (m *PluginInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_PluginInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PluginInfo proto.InternalMessageInfo
+
+func (m *PluginInfo) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *PluginInfo) GetDesc() string {
+	if m != nil {
+		return m.Desc
+	}
+	return ""
+}
+
+func (m *PluginInfo) GetCatalogue() []string {
+	if m != nil {
+		return m.Catalogue
+	}
+	return nil
+}
+
+func (m *PluginInfo) GetSupportedClouds() []string {
+	if m != nil {
+		return m.SupportedClouds
+	}
+	return nil
+}
+
+// HandshakeRequest is the request of Handshake
+type HandshakeRequest struct {
+	// protocol_version is the protocol version of client, e.g. "v1"
+	ProtocolVersion      string   `protobuf:"bytes,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HandshakeRequest) Reset()         { *m = HandshakeRequest{} }
+func (m *HandshakeRequest) String() string { return proto.CompactTextString(m) }
+func (*HandshakeRequest) ProtoMessage()    {}
+func (*HandshakeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{1}
+}
+
+func (m *HandshakeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeRequest.Unmarshal(m, b)
+}
+func (m *HandshakeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeRequest.Marshal(b, m, deterministic)
+}
+func (m *HandshakeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeRequest.Merge(m, src)
+}
+func (m *HandshakeRequest) XXX_Size() int {
+	return xxx_messageInfo_HandshakeRequest.Size(m)
+}
+func (m *HandshakeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeRequest proto.InternalMessageInfo
+
+func (m *HandshakeRequest) GetProtocolVersion() string {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return ""
+}
+
+// HandshakeResponse is the response of Handshake
+type HandshakeResponse struct {
+	// protocol_version is the protocol version of server
+	ProtocolVersion string `protobuf:"bytes,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"`
+	// diagnostics are all Diagnostics served by server
+	Diagnostics []*PluginInfo `protobuf:"bytes,2,rep,name=diagnostics,proto3" json:"diagnostics,omitempty"`
+	// exporters are all Exporters served by server
+	Exporters            []*PluginInfo `protobuf:"bytes,3,rep,name=exporters,proto3" json:"exporters,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *HandshakeResponse) Reset()         { *m = HandshakeResponse{} }
+func (m *HandshakeResponse) String() string { return proto.CompactTextString(m) }
+func (*HandshakeResponse) ProtoMessage()    {}
+func (*HandshakeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{2}
+}
+
+func (m *HandshakeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HandshakeResponse.Unmarshal(m, b)
+}
+func (m *HandshakeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HandshakeResponse.Marshal(b, m, deterministic)
+}
+func (m *HandshakeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HandshakeResponse.Merge(m, src)
+}
+func (m *HandshakeResponse) XXX_Size() int {
+	return xxx_messageInfo_HandshakeResponse.Size(m)
+}
+func (m *HandshakeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HandshakeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HandshakeResponse proto.InternalMessageInfo
+
+func (m *HandshakeResponse) GetProtocolVersion() string {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return ""
+}
+
+func (m *HandshakeResponse) GetDiagnostics() []*PluginInfo {
+	if m != nil {
+		return m.Diagnostics
+	}
+	return nil
+}
+
+func (m *HandshakeResponse) GetExporters() []*PluginInfo {
+	if m != nil {
+		return m.Exporters
+	}
+	return nil
+}
+
+// DiagnoseRequest is the request of Diagnose
+type DiagnoseRequest struct {
+	// type is the type of target Diagnostic
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// name is the custom name of Diagnostic
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// config is the json of Diagnostic config
+	Config []byte `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	// cloud_type is the cloud provider type of cluster
+	CloudType string `protobuf:"bytes,4,opt,name=cloud_type,json=cloudType,proto3" json:"cloud_type,omitempty"`
+	// resources is the json of cluster.Resources, it contains the k8s objects and machines of cluster
+	Resources            []byte   `protobuf:"bytes,5,opt,name=resources,proto3" json:"resources,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DiagnoseRequest) Reset()         { *m = DiagnoseRequest{} }
+func (m *DiagnoseRequest) String() string { return proto.CompactTextString(m) }
+func (*DiagnoseRequest) ProtoMessage()    {}
+func (*DiagnoseRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{3}
+}
+
+func (m *DiagnoseRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DiagnoseRequest.Unmarshal(m, b)
+}
+func (m *DiagnoseRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DiagnoseRequest.Marshal(b, m, deterministic)
+}
+func (m *DiagnoseRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DiagnoseRequest.Merge(m, src)
+}
+func (m *DiagnoseRequest) XXX_Size() int {
+	return xxx_messageInfo_DiagnoseRequest.Size(m)
+}
+func (m *DiagnoseRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DiagnoseRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DiagnoseRequest proto.InternalMessageInfo
+
+func (m *DiagnoseRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *DiagnoseRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *DiagnoseRequest) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *DiagnoseRequest) GetCloudType() string {
+	if m != nil {
+		return m.CloudType
+	}
+	return ""
+}
+
+func (m *DiagnoseRequest) GetResources() []byte {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
+// ObjectRef is the reference to a diagnosed object
+type ObjectRef struct {
+	// kind is the kind of object, e.g. "Deployment", "Node"
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// group is the API group of object, it is empty for core group
+	Group string `protobuf:"bytes,2,opt,name=group,proto3" json:"group,omitempty"`
+	// namespace is the namespace of object, it is empty for cluster scoped objects
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// name is the name of object
+	Name string `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	// uid is the uid of object, it may be empty
+	Uid string `protobuf:"bytes,5,opt,name=uid,proto3" json:"uid,omitempty"`
+	// node is the node that object located at, if any
+	Node                 string   `protobuf:"bytes,6,opt,name=node,proto3" json:"node,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ObjectRef) Reset()         { *m = ObjectRef{} }
+func (m *ObjectRef) String() string { return proto.CompactTextString(m) }
+func (*ObjectRef) ProtoMessage()    {}
+func (*ObjectRef) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{4}
+}
+
+func (m *ObjectRef) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ObjectRef.Unmarshal(m, b)
+}
+func (m *ObjectRef) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ObjectRef.Marshal(b, m, deterministic)
+}
+func (m *ObjectRef) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ObjectRef.Merge(m, src)
+}
+func (m *ObjectRef) XXX_Size() int {
+	return xxx_messageInfo_ObjectRef.Size(m)
+}
+func (m *ObjectRef) XXX_DiscardUnknown() {
+	xxx_messageInfo_ObjectRef.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ObjectRef proto.InternalMessageInfo
+
+func (m *ObjectRef) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ObjectRef) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *ObjectRef) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *ObjectRef) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ObjectRef) GetUid() string {
+	if m != nil {
+		return m.Uid
+	}
+	return ""
+}
+
+func (m *ObjectRef) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
+}
+
+// Result is a diagnostic result, all messages are translated by server
+type Result struct {
+	// level is the healthy level, e.g. "good", "warn", "risk", "serious", "failed"
+	Level string `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	// obj_name is the human readable name of diagnosed object
+	ObjName string `protobuf:"bytes,2,opt,name=obj_name,json=objName,proto3" json:"obj_name,omitempty"`
+	// obj is the reference to diagnosed object, it is empty if result is not about a certain object
+	Obj *ObjectRef `protobuf:"bytes,3,opt,name=obj,proto3" json:"obj,omitempty"`
+	// obj_info is the json object of the core information of obj
+	ObjInfo []byte `protobuf:"bytes,4,opt,name=obj_info,json=objInfo,proto3" json:"obj_info,omitempty"`
+	// title is the title of result
+	Title string `protobuf:"bytes,5,opt,name=title,proto3" json:"title,omitempty"`
+	// desc is the full description of result
+	Desc string `protobuf:"bytes,6,opt,name=desc,proto3" json:"desc,omitempty"`
+	// proposal shows how to solve the problem
+	Proposal string `protobuf:"bytes,7,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	// annotations are extra descriptions of result
+	Annotations          []string `protobuf:"bytes,8,rep,name=annotations,proto3" json:"annotations,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Result) Reset()         { *m = Result{} }
+func (m *Result) String() string { return proto.CompactTextString(m) }
+func (*Result) ProtoMessage()    {}
+func (*Result) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{5}
+}
+
+func (m *Result) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Result.Unmarshal(m, b)
+}
+func (m *Result) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Result.Marshal(b, m, deterministic)
+}
+func (m *Result) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Result.Merge(m, src)
+}
+func (m *Result) XXX_Size() int {
+	return xxx_messageInfo_Result.Size(m)
+}
+func (m *Result) XXX_DiscardUnknown() {
+	xxx_messageInfo_Result.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Result proto.InternalMessageInfo
+
+func (m *Result) GetLevel() string {
+	if m != nil {
+		return m.Level
+	}
+	return ""
+}
+
+func (m *Result) GetObjName() string {
+	if m != nil {
+		return m.ObjName
+	}
+	return ""
+}
+
+func (m *Result) GetObj() *ObjectRef {
+	if m != nil {
+		return m.Obj
+	}
+	return nil
+}
+
+func (m *Result) GetObjInfo() []byte {
+	if m != nil {
+		return m.ObjInfo
+	}
+	return nil
+}
+
+func (m *Result) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Result) GetDesc() string {
+	if m != nil {
+		return m.Desc
+	}
+	return ""
+}
+
+func (m *Result) GetProposal() string {
+	if m != nil {
+		return m.Proposal
+	}
+	return ""
+}
+
+func (m *Result) GetAnnotations() []string {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+// ExportRequest is the message of Export
+// the first message contains type, name, config and result, then every following message contains one diagnostic
+type ExportRequest struct {
+	// type is the type of target Exporter
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// name is the custom name of Exporter
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// config is the json of Exporter config
+	Config []byte `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"`
+	// result is the json of export.AllResult without diagnostics
+	Result []byte `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	// diagnostic is the json of export.DiagnosticResultItem
+	Diagnostic           []byte   `protobuf:"bytes,5,opt,name=diagnostic,proto3" json:"diagnostic,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportRequest) Reset()         { *m = ExportRequest{} }
+func (m *ExportRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()    {}
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{6}
+}
+
+func (m *ExportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportRequest.Unmarshal(m, b)
+}
+func (m *ExportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportRequest.Marshal(b, m, deterministic)
+}
+func (m *ExportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportRequest.Merge(m, src)
+}
+func (m *ExportRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportRequest.Size(m)
+}
+func (m *ExportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportRequest proto.InternalMessageInfo
+
+func (m *ExportRequest) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *ExportRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExportRequest) GetConfig() []byte {
+	if m != nil {
+		return m.Config
+	}
+	return nil
+}
+
+func (m *ExportRequest) GetResult() []byte {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (m *ExportRequest) GetDiagnostic() []byte {
+	if m != nil {
+		return m.Diagnostic
+	}
+	return nil
+}
+
+// ExportResponse is the response of Export
+type ExportResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportResponse) Reset()         { *m = ExportResponse{} }
+func (m *ExportResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportResponse) ProtoMessage()    {}
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{7}
+}
+
+func (m *ExportResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportResponse.Unmarshal(m, b)
+}
+func (m *ExportResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportResponse.Marshal(b, m, deterministic)
+}
+func (m *ExportResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportResponse.Merge(m, src)
+}
+func (m *ExportResponse) XXX_Size() int {
+	return xxx_messageInfo_ExportResponse.Size(m)
+}
+func (m *ExportResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*PluginInfo)(nil), "kubejarvis.plugin.v1.PluginInfo")
+	proto.RegisterType((*HandshakeRequest)(nil), "kubejarvis.plugin.v1.HandshakeRequest")
+	proto.RegisterType((*HandshakeResponse)(nil), "kubejarvis.plugin.v1.HandshakeResponse")
+	proto.RegisterType((*DiagnoseRequest)(nil), "kubejarvis.plugin.v1.DiagnoseRequest")
+	proto.RegisterType((*ObjectRef)(nil), "kubejarvis.plugin.v1.ObjectRef")
+	proto.RegisterType((*Result)(nil), "kubejarvis.plugin.v1.Result")
+	proto.RegisterType((*ExportRequest)(nil), "kubejarvis.plugin.v1.ExportRequest")
+	proto.RegisterType((*ExportResponse)(nil), "kubejarvis.plugin.v1.ExportResponse")
+}
+
+func init() {
+	proto.RegisterFile("plugin.proto", fileDescriptor_22a625af4bc1cc87)
+}
+
+var fileDescriptor_22a625af4bc1cc87 = []byte{
+	// 632 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0x95, 0xeb, 0xd4, 0x8d, 0x27, 0x85, 0x86, 0x55, 0x55, 0x99, 0xa8, 0x40, 0x64, 0xbe, 0xc2,
+	0x81, 0x84, 0x96, 0x23, 0xd0, 0x43, 0x01, 0x09, 0x2e, 0x7c, 0x58, 0xc0, 0x01, 0x21, 0x45, 0x8e,
+	0x3d, 0x09, 0x4e, 0xdc, 0x1d, 0xe3, 0x5d, 0x47, 0x70, 0xe0, 0xcc, 0x09, 0xc4, 0x6f, 0xe2, 0x0f,
+	0xf1, 0x17, 0xd0, 0xee, 0xda, 0x8e, 0xa9, 0xc2, 0x97, 0xc4, 0x6d, 0xe6, 0x79, 0xde, 0xec, 0xdb,
+	0xd9, 0x79, 0x86, 0xed, 0x2c, 0x2d, 0x66, 0x09, 0x1f, 0x66, 0x39, 0x49, 0x62, 0xbb, 0x8b, 0x62,
+	0x82, 0xf3, 0x30, 0x5f, 0x26, 0x62, 0x58, 0x7e, 0x58, 0x1e, 0xf8, 0x1f, 0x01, 0x9e, 0xe9, 0xe4,
+	0x31, 0x9f, 0x12, 0x63, 0xd0, 0x92, 0x1f, 0x32, 0xf4, 0xac, 0xbe, 0x35, 0x70, 0x03, 0x1d, 0x2b,
+	0x2c, 0x46, 0x11, 0x79, 0x1b, 0x06, 0x53, 0x31, 0xdb, 0x07, 0x37, 0x0a, 0x65, 0x98, 0xd2, 0xac,
+	0x40, 0xcf, 0xee, 0xdb, 0x03, 0x37, 0x58, 0x01, 0xec, 0x06, 0x74, 0x45, 0x91, 0x65, 0x94, 0x4b,
+	0x8c, 0xc7, 0x51, 0x4a, 0x45, 0x2c, 0xbc, 0x96, 0x2e, 0xda, 0xa9, 0xf1, 0xfb, 0x1a, 0xf6, 0xef,
+	0x41, 0xf7, 0x51, 0xc8, 0x63, 0xf1, 0x36, 0x5c, 0x60, 0x80, 0xef, 0x0a, 0x14, 0x52, 0xd1, 0xb5,
+	0xe2, 0x88, 0xd2, 0xf1, 0x12, 0x73, 0x91, 0x10, 0x2f, 0x05, 0xed, 0x54, 0xf8, 0x2b, 0x03, 0xfb,
+	0xdf, 0x2c, 0x38, 0xd7, 0xe0, 0x8b, 0x8c, 0xb8, 0xc0, 0x7f, 0x68, 0xc0, 0x8e, 0xa1, 0x13, 0x27,
+	0xe1, 0x8c, 0x93, 0x90, 0x49, 0x24, 0xbc, 0x8d, 0xbe, 0x3d, 0xe8, 0x1c, 0xf6, 0x87, 0xeb, 0x46,
+	0x35, 0x5c, 0xcd, 0x29, 0x68, 0x92, 0xd8, 0x11, 0xb8, 0xf8, 0x5e, 0xdf, 0x2a, 0x17, 0x9e, 0xfd,
+	0x97, 0x1d, 0x56, 0x14, 0xff, 0x8b, 0x05, 0x3b, 0x0f, 0x4c, 0xbf, 0x7a, 0x06, 0xbf, 0x78, 0x08,
+	0x1e, 0x9e, 0x60, 0xf5, 0x10, 0x2a, 0x66, 0x7b, 0xe0, 0x44, 0xc4, 0xa7, 0xc9, 0xcc, 0xb3, 0xfb,
+	0xd6, 0x60, 0x3b, 0x28, 0x33, 0x76, 0x01, 0x40, 0x0f, 0x7e, 0xac, 0xbb, 0xb4, 0x34, 0xc3, 0xd5,
+	0xc8, 0x0b, 0xd5, 0x6a, 0x1f, 0xdc, 0x1c, 0x05, 0x15, 0x79, 0x84, 0xc2, 0xdb, 0xd4, 0xcc, 0x15,
+	0xe0, 0x7f, 0xb6, 0xc0, 0x7d, 0x3a, 0x99, 0x63, 0x24, 0x03, 0x9c, 0xaa, 0x63, 0x17, 0x09, 0x8f,
+	0x2b, 0x29, 0x2a, 0x66, 0xbb, 0xb0, 0x39, 0xcb, 0xa9, 0xc8, 0x4a, 0x2d, 0x26, 0x51, 0x5d, 0x95,
+	0x28, 0x91, 0x85, 0x11, 0x6a, 0x3d, 0x6e, 0xb0, 0x02, 0x6a, 0xf9, 0xad, 0x86, 0xfc, 0x2e, 0xd8,
+	0x45, 0x12, 0x6b, 0x05, 0x6e, 0xa0, 0x42, 0x5d, 0x45, 0x31, 0x7a, 0x4e, 0x59, 0x45, 0x31, 0xfa,
+	0xdf, 0x2d, 0x70, 0x02, 0x14, 0x45, 0x2a, 0xd5, 0xc1, 0x29, 0x2e, 0x31, 0x2d, 0xd5, 0x98, 0x84,
+	0x9d, 0x87, 0x36, 0x4d, 0xe6, 0xe3, 0xc6, 0x74, 0xb6, 0x68, 0x32, 0x7f, 0xa2, 0x4e, 0x38, 0x00,
+	0x9b, 0x26, 0x73, 0xad, 0xa6, 0x73, 0x78, 0x69, 0xfd, 0xb3, 0xd4, 0x77, 0x0d, 0x54, 0x6d, 0xd5,
+	0x2d, 0xe1, 0x53, 0xd2, 0x62, 0xb7, 0x75, 0x37, 0xed, 0x8f, 0x5d, 0xd8, 0x94, 0x89, 0x4c, 0xb1,
+	0x54, 0x6c, 0x92, 0xda, 0x21, 0x4e, 0xc3, 0x21, 0x3d, 0x68, 0x67, 0x39, 0x65, 0x24, 0xc2, 0xd4,
+	0xdb, 0xd2, 0x78, 0x9d, 0xb3, 0x3e, 0x74, 0x42, 0xce, 0x49, 0x86, 0x32, 0x21, 0x2e, 0xbc, 0xb6,
+	0xb6, 0x46, 0x13, 0xf2, 0x3f, 0x59, 0x70, 0xe6, 0xa1, 0x5e, 0x90, 0xff, 0xb5, 0x10, 0x7b, 0xe0,
+	0xe4, 0x7a, 0x84, 0xe5, 0x95, 0xca, 0x8c, 0x5d, 0x04, 0x58, 0xed, 0x72, 0xb9, 0x0a, 0x0d, 0xc4,
+	0xef, 0xc2, 0xd9, 0x4a, 0x88, 0x71, 0xd7, 0xe1, 0xd7, 0x0d, 0x70, 0xcc, 0x22, 0xb3, 0x37, 0xe0,
+	0xd6, 0xee, 0x63, 0xd7, 0xd6, 0x0f, 0xf7, 0xb4, 0xbd, 0x7b, 0xd7, 0xff, 0x58, 0x57, 0xda, 0xf8,
+	0x39, 0xb4, 0x2b, 0x5b, 0xb0, 0xab, 0xeb, 0x49, 0xa7, 0x6c, 0xd3, 0xdb, 0x5f, 0x5f, 0x66, 0x96,
+	0xe7, 0x96, 0xc5, 0x5e, 0x82, 0x63, 0x6e, 0xc3, 0x2e, 0xaf, 0xaf, 0xfc, 0x69, 0xe8, 0xbd, 0x2b,
+	0xbf, 0x2f, 0x32, 0x3a, 0x07, 0xd6, 0xf1, 0xd1, 0xeb, 0xbb, 0x72, 0x81, 0x42, 0x86, 0xd1, 0x62,
+	0x98, 0xd0, 0x48, 0x91, 0x6e, 0x1a, 0xd6, 0x28, 0x5b, 0xcc, 0x46, 0x86, 0x29, 0x46, 0x39, 0x9e,
+	0x90, 0xc4, 0x32, 0x5d, 0x1e, 0xdc, 0xa9, 0x82, 0x89, 0xa3, 0x7f, 0x4b, 0xb7, 0x7f, 0x0c, 0x00,
+	0x97, 0x96, 0xfb, 0x6d, 0xb1, 0x05, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PluginClient is the client API for Plugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PluginClient interface {
+	// Handshake return the protocol version and all plugins served by server
+	Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error)
+	// Diagnose run a Diagnostic and send its results one by one
+	Diagnose(ctx context.Context, in *DiagnoseRequest, opts ...grpc.CallOption) (Plugin_DiagnoseClient, error)
+	// Export receive a result header and then one diagnostic per message, and export them
+	Export(ctx context.Context, opts ...grpc.CallOption) (Plugin_ExportClient, error)
+}
+
+type pluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginClient(cc grpc.ClientConnInterface) PluginClient {
+	return &pluginClient{cc}
+}
+
+func (c *pluginClient) Handshake(ctx context.Context, in *HandshakeRequest, opts ...grpc.CallOption) (*HandshakeResponse, error) {
+	out := new(HandshakeResponse)
+	err := c.cc.Invoke(ctx, "/kubejarvis.plugin.v1.Plugin/Handshake", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) Diagnose(ctx context.Context, in *DiagnoseRequest, opts ...grpc.CallOption) (Plugin_DiagnoseClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[0], "/kubejarvis.plugin.v1.Plugin/Diagnose", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginDiagnoseClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Plugin_DiagnoseClient interface {
+	Recv() (*Result, error)
+	grpc.ClientStream
+}
+
+type pluginDiagnoseClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginDiagnoseClient) Recv() (*Result, error) {
+	m := new(Result)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pluginClient) Export(ctx context.Context, opts ...grpc.CallOption) (Plugin_ExportClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[1], "/kubejarvis.plugin.v1.Plugin/Export", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginExportClient{stream}
+	return x, nil
+}
+
+type Plugin_ExportClient interface {
+	Send(*ExportRequest) error
+	CloseAndRecv() (*ExportResponse, error)
+	grpc.ClientStream
+}
+
+type pluginExportClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginExportClient) Send(m *ExportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pluginExportClient) CloseAndRecv() (*ExportResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ExportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PluginServer is the server API for Plugin service.
+type PluginServer interface {
+	// Handshake return the protocol version and all plugins served by server
+	Handshake(context.Context, *HandshakeRequest) (*HandshakeResponse, error)
+	// Diagnose run a Diagnostic and send its results one by one
+	Diagnose(*DiagnoseRequest, Plugin_DiagnoseServer) error
+	// Export receive a result header and then one diagnostic per message, and export them
+	Export(Plugin_ExportServer) error
+}
+
+// UnimplementedPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedPluginServer struct {
+}
+
+func (*UnimplementedPluginServer) Handshake(ctx context.Context, req *HandshakeRequest) (*HandshakeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Handshake not implemented")
+}
+func (*UnimplementedPluginServer) Diagnose(req *DiagnoseRequest, srv Plugin_DiagnoseServer) error {
+	return status.Errorf(codes.Unimplemented, "method Diagnose not implemented")
+}
+func (*UnimplementedPluginServer) Export(srv Plugin_ExportServer) error {
+	return status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+
+func RegisterPluginServer(s *grpc.Server, srv PluginServer) {
+	s.RegisterService(&_Plugin_serviceDesc, srv)
+}
+
+func _Plugin_Handshake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandshakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Handshake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/kubejarvis.plugin.v1.Plugin/Handshake",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Handshake(ctx, req.(*HandshakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_Diagnose_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DiagnoseRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PluginServer).Diagnose(m, &pluginDiagnoseServer{stream})
+}
+
+type Plugin_DiagnoseServer interface {
+	Send(*Result) error
+	grpc.ServerStream
+}
+
+type pluginDiagnoseServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginDiagnoseServer) Send(m *Result) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Plugin_Export_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PluginServer).Export(&pluginExportServer{stream})
+}
+
+type Plugin_ExportServer interface {
+	SendAndClose(*ExportResponse) error
+	Recv() (*ExportRequest, error)
+	grpc.ServerStream
+}
+
+type pluginExportServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginExportServer) SendAndClose(m *ExportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pluginExportServer) Recv() (*ExportRequest, error) {
+	m := new(ExportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Plugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "kubejarvis.plugin.v1.Plugin",
+	HandlerType: (*PluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Handshake",
+			Handler:    _Plugin_Handshake_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Diagnose",
+			Handler:       _Plugin_Diagnose_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Export",
+			Handler:       _Plugin_Export_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
@@ -0,0 +1,191 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote/pluginv1"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+const (
+	// ProtocolVersion is the version of remote plugin protocol
+	// client and server must use the same version
+	ProtocolVersion = "v1"
+	// ServiceName is the full name of the gRPC service of remote plugins, see pluginv1/plugin.proto
+	ServiceName = "kubejarvis.plugin." + ProtocolVersion + ".Plugin"
+	// DefaultMaxMsgSize is the default max size of a gRPC message
+	// cluster resources may be very large, so it is much bigger than the default value of gRPC
+	DefaultMaxMsgSize = 256 * 1024 * 1024
+)
+
+// PluginInfo is the core attributes of a remote plugin
+type PluginInfo struct {
+	// Type is the type of plugin
+	Type string
+	// Desc is the description of plugin
+	Desc translate.Message
+	// Catalogue is the catalogue of Diagnostic, it is empty for Exporter
+	Catalogue diagnose.Catalogue
+	// SupportedClouds indicate what cloud providers will be supported of this plugin
+	// all cloud providers are supported if it is empty
+	SupportedClouds []string
+}
+
+// HandshakeResponse is the response of method "Handshake"
+type HandshakeResponse struct {
+	// ProtocolVersion is the protocol version of server
+	ProtocolVersion string
+	// Diagnostics are all Diagnostics served by server
+	Diagnostics []PluginInfo
+	// Exporters are all Exporters served by server
+	Exporters []PluginInfo
+}
+
+// DiagnoseRequest is the request of stream method "Diagnose"
+// server will send diagnose.Result one by one, and close stream if diagnostic is finished
+type DiagnoseRequest struct {
+	// Type is the type of target Diagnostic
+	Type string
+	// Name is the custom name of Diagnostic
+	Name string
+	// Config is the config of Diagnostic
+	Config interface{}
+	// CloudType is the cloud provider type fo cluster
+	CloudType string
+	// Resources contains all diagnose able resources
+	Resources *cluster.Resources
+}
+
+// ExportRequest is the message of stream method "Export"
+// the first message contains Type, Name, Config and Result without Diagnostics
+// then every following message contains one Diagnostic
+type ExportRequest struct {
+	// Type is the type of target Exporter
+	Type string
+	// Name is the custom name of Exporter
+	Name string
+	// Config is the config of Exporter
+	Config interface{}
+}
+
+func pluginInfosFromPB(infos []*pluginv1.PluginInfo) []PluginInfo {
+	result := make([]PluginInfo, 0, len(infos))
+	for _, info := range infos {
+		result = append(result, PluginInfo{
+			Type:            info.Type,
+			Desc:            translate.Literal(info.Desc),
+			Catalogue:       info.Catalogue,
+			SupportedClouds: info.SupportedClouds,
+		})
+	}
+	return result
+}
+
+// marshalJSON return nil if v is nil, so that empty fields are not sent
+func marshalJSON(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// unmarshalJSON do nothing if data is empty
+func unmarshalJSON(data []byte, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// resultToPB convert a translated diagnose.Result to pluginv1.Result
+func resultToPB(r *diagnose.Result) (*pluginv1.Result, error) {
+	info, err := marshalJSON(r.ObjInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal obj info failed")
+	}
+
+	result := &pluginv1.Result{
+		Level:    string(r.Level),
+		ObjName:  r.ObjName,
+		ObjInfo:  info,
+		Title:    r.Title.String(),
+		Desc:     r.Desc.String(),
+		Proposal: r.Proposal.String(),
+	}
+
+	if r.Obj != nil {
+		result.Obj = &pluginv1.ObjectRef{
+			Kind:      r.Obj.Kind,
+			Group:     r.Obj.Group,
+			Namespace: r.Obj.Namespace,
+			Name:      r.Obj.Name,
+			Uid:       string(r.Obj.UID),
+			Node:      r.Obj.Node,
+		}
+	}
+
+	for _, a := range r.Annotations {
+		result.Annotations = append(result.Annotations, a.String())
+	}
+	return result, nil
+}
+
+// resultFromPB convert a pluginv1.Result to diagnose.Result with literal messages
+func resultFromPB(r *pluginv1.Result) (*diagnose.Result, error) {
+	result := &diagnose.Result{
+		Level:    diagnose.HealthyLevel(r.Level),
+		ObjName:  r.ObjName,
+		Title:    translate.Literal(r.Title),
+		Desc:     translate.Literal(r.Desc),
+		Proposal: translate.Literal(r.Proposal),
+	}
+
+	if err := unmarshalJSON(r.ObjInfo, &result.ObjInfo); err != nil {
+		return nil, errors.Wrap(err, "unmarshal obj info failed")
+	}
+
+	if r.Obj != nil {
+		result.Obj = &diagnose.ObjectRef{
+			Kind:      r.Obj.Kind,
+			Group:     r.Obj.Group,
+			Namespace: r.Obj.Namespace,
+			Name:      r.Obj.Name,
+			UID:       types.UID(r.Obj.Uid),
+			Node:      r.Obj.Node,
+		}
+	}
+
+	for _, a := range r.Annotations {
+		result.Annotations = append(result.Annotations, translate.Literal(a))
+	}
+	return result, nil
+}
+
+// exportHeader return the result without Diagnostics
+func exportHeader(result *export.AllResult) *export.AllResult {
+	header := *result
+	header.Diagnostics = nil
+	return &header
+}
@@ -0,0 +1,289 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote/pluginv1"
+	"tkestack.io/kube-jarvis/pkg/translate"
+)
+
+type fakeDiagnostic struct {
+	*diagnose.MetaData
+	Level diagnose.HealthyLevel
+}
+
+func (f *fakeDiagnostic) Complete() error {
+	if !f.Level.Verify() {
+		return fmt.Errorf("level %s is illegal", f.Level)
+	}
+	return nil
+}
+
+func (f *fakeDiagnostic) StartDiagnose(ctx context.Context,
+	param diagnose.StartDiagnoseParam) (chan *diagnose.Result, error) {
+	result := make(chan *diagnose.Result, len(param.Resources.Machines))
+	for name, m := range param.Resources.Machines {
+		result <- &diagnose.Result{
+			Level:   f.Level,
+			ObjName: name,
+			Obj:     diagnose.NewNodeRef(name),
+			Title:   f.Translator.Message("title", nil),
+			Desc:    translate.Literal(m.SysCtl["net.ipv4.ip_forward"]),
+		}
+	}
+	close(result)
+	return result, nil
+}
+
+type fakeExporter struct {
+	*export.MetaData
+	Prefix string
+	result chan *export.AllResult
+}
+
+func (f *fakeExporter) Complete() error {
+	return nil
+}
+
+func (f *fakeExporter) Export(ctx context.Context, result *export.AllResult) error {
+	if f.Prefix == "fail" {
+		return fmt.Errorf("export failed")
+	}
+	for _, d := range result.Diagnostics {
+		d.Name = f.Prefix + d.Name
+	}
+	f.result <- result
+	return nil
+}
+
+func startServer(t *testing.T, exported chan *export.AllResult) (*Server, *Client) {
+	s := NewServer()
+	s.AddDiagnostic("fake", diagnose.Factory{
+		Creator: func(d *diagnose.MetaData) diagnose.Diagnostic {
+			d.Desc = translate.Literal("fake diagnostic")
+			return &fakeDiagnostic{MetaData: d}
+		},
+		Catalogue:       diagnose.CatalogueNode,
+		SupportedClouds: []string{"qcloud"},
+	})
+	s.AddExporter("fake", export.Factory{
+		Creator: func(e *export.MetaData) export.Exporter {
+			return &fakeExporter{MetaData: e, result: exported}
+		},
+	})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	client, err := Dial(context.Background(), ClientConfig{
+		Address: lis.Addr().String(),
+	})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return s, client
+}
+
+func TestClient_Handshake(t *testing.T) {
+	s, client := startServer(t, nil)
+	defer s.Stop()
+	defer client.Close()
+
+	resp, err := client.Handshake(context.Background())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if len(resp.Diagnostics) != 1 || len(resp.Exporters) != 1 {
+		t.Fatalf("want 1 diagnostic and 1 exporter but get %+v", resp)
+	}
+
+	if _, exist := s.server.GetServiceInfo()[ServiceName]; !exist {
+		t.Fatalf("service %s should be served", ServiceName)
+	}
+
+	info := resp.Diagnostics[0]
+	if info.Type != "fake" || info.Desc.String() != "fake diagnostic" ||
+		fmt.Sprint(info.Catalogue) != fmt.Sprint(diagnose.CatalogueNode) ||
+		fmt.Sprint(info.SupportedClouds) != "[qcloud]" {
+		t.Fatalf("wrong plugin info %+v", info)
+	}
+
+	_, err = client.plugin.Handshake(context.Background(), &pluginv1.HandshakeRequest{
+		ProtocolVersion: "v0",
+	})
+	if err == nil || !strings.Contains(err.Error(), "FailedPrecondition") {
+		t.Fatalf("want FailedPrecondition error but get %v", err)
+	}
+}
+
+func TestClient_Diagnose(t *testing.T) {
+	s, client := startServer(t, nil)
+	defer s.Stop()
+	defer client.Close()
+
+	var cases = []struct {
+		req     *DiagnoseRequest
+		results int
+		wantErr bool
+	}{
+		{
+			req: &DiagnoseRequest{
+				Type: "fake",
+				Config: map[string]interface{}{
+					"level": "warn",
+				},
+			},
+			results: 0,
+		},
+		{
+			req: &DiagnoseRequest{
+				Type: "fake",
+				Config: map[string]interface{}{
+					"level": "warn",
+				},
+				Resources: fakeResources(),
+			},
+			results: 2,
+		},
+		{
+			req: &DiagnoseRequest{
+				Type: "fake",
+				Config: map[string]interface{}{
+					"level": "unknown",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			req: &DiagnoseRequest{
+				Type: "unknown",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs.req), func(t *testing.T) {
+			result := make(chan *diagnose.Result, 10)
+			err := client.Diagnose(context.Background(), cs.req, result)
+			close(result)
+			if (err != nil) != cs.wantErr {
+				t.Fatalf("want err %v but get %v", cs.wantErr, err)
+			}
+
+			count := 0
+			for r := range result {
+				count++
+				if r.Level != diagnose.HealthyLevelWarn || r.Obj.Kind != "Node" || r.Desc.String() != "1" {
+					t.Fatalf("wrong result %+v", r)
+				}
+
+				if r.Title.ID != "" || r.Title.Text != "title" {
+					t.Fatalf("title should be translated, but get %+v", r.Title)
+				}
+			}
+
+			if count != cs.results {
+				t.Fatalf("want %d results but get %d", cs.results, count)
+			}
+		})
+	}
+}
+
+func TestClient_Export(t *testing.T) {
+	exported := make(chan *export.AllResult, 1)
+	s, client := startServer(t, exported)
+	defer s.Stop()
+	defer client.Close()
+
+	result := export.NewAllResult()
+	for _, name := range []string{"a", "b"} {
+		item := &export.DiagnosticResultItem{
+			Name:       name,
+			Statistics: map[diagnose.HealthyLevel]int{},
+		}
+		item.AddResult(&diagnose.Result{
+			Level: diagnose.HealthyLevelRisk,
+			Title: translate.Message{ID: "diagnostics.a.title"},
+		})
+		result.AddDiagnosticResultItem(item)
+	}
+
+	if err := client.Export(context.Background(), &ExportRequest{
+		Type: "fake",
+		Config: map[string]interface{}{
+			"prefix": "remote-",
+		},
+	}, result); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	r := <-exported
+	if len(r.Diagnostics) != 2 || r.Diagnostics[1].Name != "remote-b" ||
+		r.Statistics[diagnose.HealthyLevelRisk] != 2 ||
+		r.Diagnostics[0].Results[0].Title.ID != "diagnostics.a.title" {
+		t.Fatalf("wrong exported result %+v", r)
+	}
+
+	if len(result.Diagnostics) != 2 || result.Diagnostics[0].Name != "a" {
+		t.Fatalf("result of client should not be changed")
+	}
+
+	if err := client.Export(context.Background(), &ExportRequest{
+		Type: "fake",
+		Config: map[string]interface{}{
+			"prefix": "fail",
+		},
+	}, result); err == nil || !strings.Contains(err.Error(), "export failed") {
+		t.Fatalf("want export failed error but get %v", err)
+	}
+
+	if err := client.Export(context.Background(), &ExportRequest{
+		Type: "unknown",
+	}, result); err == nil || !strings.Contains(err.Error(), "NotFound") {
+		t.Fatalf("want NotFound error but get %v", err)
+	}
+}
+
+func fakeResources() *cluster.Resources {
+	res := cluster.NewResources()
+	res.Machines["node1"] = cluster.Machine{
+		SysCtl: map[string]string{"net.ipv4.ip_forward": "1"},
+	}
+	res.Machines["node2"] = cluster.Machine{
+		SysCtl: map[string]string{"net.ipv4.ip_forward": "1"},
+		Error:  fmt.Errorf("iptables-save failed"),
+	}
+	return res
+}
@@ -0,0 +1,296 @@
+/*
+* Tencent is pleased to support the open source community by making TKEStack
+* available.
+*
+* Copyright (C) 2012-2019 Tencent. All Rights Reserved.
+*
+* Licensed under the Apache License, Version 2.0 (the “License”); you may not use
+* this file except in compliance with the License. You may obtain a copy of the
+* License at
+*
+* https://opensource.org/licenses/Apache-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an “AS IS” BASIS, WITHOUT
+* WARRANTIES OF ANY KIND, either express or implied.  See the License for the
+* specific language governing permissions and limitations under the License.
+ */
+package remote
+
+import (
+	"context"
+	"io"
+	"net"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/pkg/plugins"
+	"tkestack.io/kube-jarvis/pkg/plugins/cluster"
+	"tkestack.io/kube-jarvis/pkg/plugins/diagnose"
+	"tkestack.io/kube-jarvis/pkg/plugins/export"
+	"tkestack.io/kube-jarvis/pkg/plugins/remote/pluginv1"
+	"tkestack.io/kube-jarvis/pkg/translate"
+	"tkestack.io/kube-jarvis/pkg/util"
+)
+
+// Server serve Diagnostics and Exporters as remote plugins
+// plugins are written in the same way as built-in plugins
+// example:
+//
+//	s := remote.NewServer()
+//	s.AddDiagnostic("my-diagnostic", diagnose.Factory{
+//		Creator:   NewDiagnostic,
+//		Catalogue: diagnose.CatalogueOther,
+//	})
+//	lis, _ := net.Listen("tcp", ":9000")
+//	s.Serve(lis)
+type Server struct {
+	// Translator is used to create Diagnostics and Exporters
+	// messages of diagnostic results will be translated by Translator before sent to client,
+	// since kube-jarvis has no translations of remote plugins
+	Translator translate.Translator
+	// Logger is used to create Diagnostics and Exporters
+	Logger logger.Logger
+	// MaxMsgSize is the max size of message, default is DefaultMaxMsgSize
+	MaxMsgSize int
+	// Options are extra gRPC server options, e.g. TLS credentials
+	Options []grpc.ServerOption
+
+	diagnostics map[string]diagnose.Factory
+	exporters   map[string]export.Factory
+	server      *grpc.Server
+	lock        sync.Mutex
+}
+
+// NewServer return a new Server
+// messages will not be translated by default, use translate.NewDefault to create a Translator if needed
+func NewServer() *Server {
+	return &Server{
+		Translator:  translate.NewFake(),
+		Logger:      logger.NewLogger(),
+		MaxMsgSize:  DefaultMaxMsgSize,
+		diagnostics: map[string]diagnose.Factory{},
+		exporters:   map[string]export.Factory{},
+	}
+}
+
+// AddDiagnostic register a Diagnostic Factory
+func (s *Server) AddDiagnostic(typ string, f diagnose.Factory) {
+	s.diagnostics[typ] = f
+}
+
+// AddExporter register a Exporter Factory
+func (s *Server) AddExporter(typ string, f export.Factory) {
+	s.exporters[typ] = f
+}
+
+// Serve accepts incoming connections on the listener and serve all registered plugins
+// Serve will return when Stop is called or lis.Accept fails
+func (s *Server) Serve(lis net.Listener) error {
+	opts := append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(s.MaxMsgSize),
+		grpc.MaxSendMsgSize(s.MaxMsgSize),
+	}, s.Options...)
+
+	s.lock.Lock()
+	s.server = grpc.NewServer(opts...)
+	pluginv1.RegisterPluginServer(s.server, s)
+	server := s.server
+	s.lock.Unlock()
+
+	return server.Serve(lis)
+}
+
+// Stop stops the server gracefully
+func (s *Server) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+}
+
+// Handshake implement pluginv1.PluginServer
+func (s *Server) Handshake(ctx context.Context, req *pluginv1.HandshakeRequest) (*pluginv1.HandshakeResponse, error) {
+	if req.ProtocolVersion != ProtocolVersion {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"protocol version of client is %s, but %s is required", req.ProtocolVersion, ProtocolVersion)
+	}
+
+	resp := &pluginv1.HandshakeResponse{
+		ProtocolVersion: ProtocolVersion,
+		Diagnostics:     []*pluginv1.PluginInfo{},
+		Exporters:       []*pluginv1.PluginInfo{},
+	}
+
+	for typ, f := range s.diagnostics {
+		d := f.Creator(s.diagnosticMeta(typ, typ, f))
+		resp.Diagnostics = append(resp.Diagnostics, &pluginv1.PluginInfo{
+			Type:            typ,
+			Desc:            s.Translator.Translate(d.Meta().Desc),
+			Catalogue:       f.Catalogue,
+			SupportedClouds: f.SupportedClouds,
+		})
+	}
+
+	for typ, f := range s.exporters {
+		e := f.Creator(s.exporterMeta(typ, typ))
+		resp.Exporters = append(resp.Exporters, &pluginv1.PluginInfo{
+			Type:            typ,
+			Desc:            s.Translator.Translate(e.Meta().Desc),
+			SupportedClouds: f.SupportedClouds,
+		})
+	}
+
+	sort.Slice(resp.Diagnostics, func(i, j int) bool {
+		return resp.Diagnostics[i].Type < resp.Diagnostics[j].Type
+	})
+	sort.Slice(resp.Exporters, func(i, j int) bool {
+		return resp.Exporters[i].Type < resp.Exporters[j].Type
+	})
+	return resp, nil
+}
+
+// Diagnose implement pluginv1.PluginServer
+func (s *Server) Diagnose(req *pluginv1.DiagnoseRequest, stream pluginv1.Plugin_DiagnoseServer) error {
+	f, exist := s.diagnostics[req.Type]
+	if !exist {
+		return status.Errorf(codes.NotFound, "can not found diagnostic type %s", req.Type)
+	}
+
+	var config interface{}
+	if err := unmarshalJSON(req.Config, &config); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal config failed: %v", err)
+	}
+
+	d := f.Creator(s.diagnosticMeta(req.Type, req.Name, f))
+	if err := util.InitObjViaYaml(d, config); err != nil {
+		return status.Errorf(codes.InvalidArgument, "init config failed: %v", err)
+	}
+
+	if err := d.Complete(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "complete config failed: %v", err)
+	}
+
+	resources := cluster.NewResources()
+	if err := unmarshalJSON(req.Resources, resources); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal resources failed: %v", err)
+	}
+
+	result, err := d.StartDiagnose(stream.Context(), diagnose.StartDiagnoseParam{
+		CloudType: req.CloudType,
+		Resources: resources,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "start diagnostic failed: %v", err)
+	}
+
+	// drain result chan so that the diagnostic will not be blocked if sending failed
+	defer func() {
+		go func() {
+			for range result {
+			}
+		}()
+	}()
+
+	for r := range result {
+		pr, err := resultToPB(export.TranslateResult(r, s.Translator))
+		if err != nil {
+			return status.Errorf(codes.Internal, "convert result failed: %v", err)
+		}
+
+		if err := stream.Send(pr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export implement pluginv1.PluginServer
+func (s *Server) Export(stream pluginv1.Plugin_ExportServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+
+	f, exist := s.exporters[req.Type]
+	if !exist {
+		return status.Errorf(codes.NotFound, "can not found exporter type %s", req.Type)
+	}
+
+	var config interface{}
+	if err := unmarshalJSON(req.Config, &config); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal config failed: %v", err)
+	}
+
+	e := f.Creator(s.exporterMeta(req.Type, req.Name))
+	if err := util.InitObjViaYaml(e, config); err != nil {
+		return status.Errorf(codes.InvalidArgument, "init config failed: %v", err)
+	}
+
+	if err := e.Complete(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "complete config failed: %v", err)
+	}
+
+	result := export.NewAllResult()
+	if err := unmarshalJSON(req.Result, result); err != nil {
+		return status.Errorf(codes.InvalidArgument, "unmarshal result failed: %v", err)
+	}
+	result.Diagnostics = []*export.DiagnosticResultItem{}
+
+	for {
+		item, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		if len(item.Diagnostic) == 0 {
+			continue
+		}
+
+		d := &export.DiagnosticResultItem{}
+		if err := unmarshalJSON(item.Diagnostic, d); err != nil {
+			return status.Errorf(codes.InvalidArgument, "unmarshal diagnostic failed: %v", err)
+		}
+		result.Diagnostics = append(result.Diagnostics, d)
+	}
+
+	if err := e.Export(stream.Context(), result); err != nil {
+		return status.Errorf(codes.Internal, "export failed: %v", err)
+	}
+	return stream.SendAndClose(&pluginv1.ExportResponse{})
+}
+
+func (s *Server) diagnosticMeta(typ, name string, f diagnose.Factory) *diagnose.MetaData {
+	return &diagnose.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: s.Translator.WithModule("diagnostics." + typ),
+			Logger: s.Logger.With(map[string]string{
+				"diagnostic": name,
+			}),
+			Type: typ,
+			Name: name,
+		},
+		Catalogue: f.Catalogue,
+	}
+}
+
+func (s *Server) exporterMeta(typ, name string) *export.MetaData {
+	return &export.MetaData{
+		MetaData: plugins.MetaData{
+			Translator: s.Translator.WithModule("exporters." + typ),
+			Logger: s.Logger.With(map[string]string{
+				"exporter": name,
+			}),
+			Type: typ,
+			Name: name,
+		},
+	}
+}
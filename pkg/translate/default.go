@@ -19,99 +19,252 @@ package translate
 
 import (
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
+	"sync"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v2"
+	"tkestack.io/kube-jarvis/pkg/logger"
+	"tkestack.io/kube-jarvis/translation"
 )
 
 // Default translate string to target language
 type Default struct {
 	module   string
+	defLang  string
+	defTag   language.Tag
+	lang     string
+	tag      language.Tag
 	bundle   *i18n.Bundle
 	localize *i18n.Localizer
+	// ids contains all loaded message IDs of every language
+	ids map[language.Tag]map[string]bool
+	// missing record missing messages that have been logged
+	missing *sync.Map
+	logger  logger.Logger
 }
 
 // NewDefault create a new default Translator
-// Translator will read translation message from "dir/defLang" and "dir/targetLang"
+// Translator will load built-in translation messages of all languages first,
+// then messages in "dir" will be layered on top of them if "dir" is not empty
+// Message will be translated to "targetLang", and fall back to "defLang"
+// if message is missing in "defLang" too, the message ID will be used
 func NewDefault(dir string, defLang string, targetLang string) (Translator, error) {
-	t := &Default{}
+	t := &Default{
+		defLang: defLang,
+		ids:     map[language.Tag]map[string]bool{},
+		missing: &sync.Map{},
+		logger: logger.NewLogger().With(map[string]string{
+			"module": "translate",
+		}),
+	}
 	defTag, err := language.Parse(defLang)
 	if err != nil {
 		return nil, err
 	}
 
-	targetTag := language.Make(targetLang)
+	t.defTag = defTag
 	t.bundle = i18n.NewBundle(defTag)
 	t.bundle.RegisterUnmarshalFunc("yaml", yaml.Unmarshal)
-	t.localize = i18n.NewLocalizer(t.bundle, targetLang)
 
-	// load default message
-	if err := t.addMessage(dir, defTag); err != nil {
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			t.logger.Infof("translation dir %s is not available, only built-in translations will be used: %v", dir, err)
+		}
+	}
+
+	for _, source := range Sources(dir) {
+		langs, err := Languages(source)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tag := range langs {
+			if err := t.addMessage(source, tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.ids[defTag] == nil {
+		return nil, fmt.Errorf("can not found translations of default language %s", defLang)
+	}
+
+	t.setLang(targetLang)
+	return t, nil
+}
+
+// Sources return all translation sources, the built-in translations is the first one
+// "dir" will be returned as the second one if it is not empty and exists
+func Sources(dir string) []fs.FS {
+	sources := []fs.FS{translation.FS}
+	if dir == "" {
+		return sources
+	}
+
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		sources = append(sources, os.DirFS(dir))
+	}
+	return sources
+}
+
+// Languages return all languages that have a translation directory in "source"
+func Languages(source fs.FS) ([]language.Tag, error) {
+	files, err := fs.ReadDir(source, ".")
+	if err != nil {
 		return nil, err
 	}
 
-	// load target message
-	return t, t.addMessage(dir, targetTag)
+	tags := make([]language.Tag, 0)
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+
+		tag, err := language.Parse(f.Name())
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
 }
 
-func (d *Default) addMessage(dir string, tag language.Tag) error {
-	return filepath.Walk(fmt.Sprintf("%s/%s", dir, tag.String()),
-		func(path string, info os.FileInfo, err error) error {
+// LoadMessages return all messages of language "tag" in "source"
+// ID of messages will be "<module dir>.<file name>.<ID>"
+func LoadMessages(source fs.FS, tag language.Tag) ([]*i18n.Message, error) {
+	messages := make([]*i18n.Message, 0)
+	err := fs.WalkDir(source, tag.String(),
+		func(file string, info fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 
-			if info.Mode().IsRegular() && strings.HasSuffix(info.Name(), ".yaml") {
-				buf, err := ioutil.ReadFile(path)
+			if info.Type().IsRegular() && strings.HasSuffix(info.Name(), ".yaml") {
+				buf, err := fs.ReadFile(source, file)
 				if err != nil {
 					return err
 				}
-				mes, err := i18n.ParseMessageFileBytes(buf, path, map[string]i18n.UnmarshalFunc{
+				mes, err := i18n.ParseMessageFileBytes(buf, file, map[string]i18n.UnmarshalFunc{
 					"yaml": yaml.Unmarshal,
 				})
 				if err != nil {
-					return fmt.Errorf("load message file %s failed : %s", path, err.Error())
+					return fmt.Errorf("load message file %s failed : %s", file, err.Error())
 				}
 
 				for _, m := range mes.Messages {
-					m.ID = fmt.Sprintf("%s.%s.%s", filepath.Base(filepath.Dir(path)),
+					m.ID = fmt.Sprintf("%s.%s.%s", path.Base(path.Dir(file)),
 						strings.TrimSuffix(info.Name(), ".yaml"), m.ID)
 				}
-
-				if err := d.bundle.AddMessages(tag, mes.Messages...); err != nil {
-					return fmt.Errorf("add message failed : %s", err.Error())
-				}
+				messages = append(messages, mes.Messages...)
 			}
 			return nil
 		})
+	return messages, err
+}
+
+func (d *Default) addMessage(source fs.FS, tag language.Tag) error {
+	messages, err := LoadMessages(source, tag)
+	if err != nil {
+		return err
+	}
+
+	if err := d.bundle.AddMessages(tag, messages...); err != nil {
+		return fmt.Errorf("add message failed : %s", err.Error())
+	}
+
+	if d.ids[tag] == nil {
+		d.ids[tag] = map[string]bool{}
+	}
+
+	for _, m := range messages {
+		d.ids[tag][m.ID] = true
+	}
+	return nil
+}
+
+// setLang set target language of d
+// d.tag will be the best matched language of loaded languages
+func (d *Default) setLang(lang string) {
+	d.lang = lang
+	d.localize = i18n.NewLocalizer(d.bundle, lang, d.defLang)
+
+	langs := d.bundle.LanguageTags()
+	wants, _, err := language.ParseAcceptLanguage(lang)
+	if err != nil || len(wants) == 0 {
+		d.tag = langs[0]
+		return
+	}
+
+	_, index, _ := language.NewMatcher(langs).Match(wants...)
+	d.tag = langs[index]
+}
+
+// clone return a copy of d that shares loaded messages
+func (d *Default) clone() *Default {
+	nd := *d
+	return &nd
 }
 
 // WithModule attach a module label to a Translator
 // module will be add before ID when you call Translator.Message
 func (d *Default) WithModule(module string) Translator {
-	return &Default{
-		module:   module,
-		bundle:   d.bundle,
-		localize: d.localize,
-	}
+	nd := d.clone()
+	nd.module = module
+	return nd
+}
+
+// WithLang return a Translator that translate Message to target language
+// lang can be a language tag or the value of http header "Accept-Language"
+func (d *Default) WithLang(lang string) Translator {
+	nd := d.clone()
+	nd.setLang(lang)
+	return nd
 }
 
-// Message get translated message from Translator
+// Message return a untranslated Message
 // t.module will be add before ID
 // example:
 //         ID = "message"  and module = "diagnostics.example"
 //         then real ID will be "diagnostics.example.message"
 func (d *Default) Message(ID string, templateData map[string]interface{}) Message {
-	mes, _ := d.localize.Localize(&i18n.LocalizeConfig{
-		DefaultMessage: &i18n.Message{
-			ID: fmt.Sprintf("%s.%s", d.module, ID),
-		},
-		TemplateData: templateData,
+	return Message{
+		ID:   fmt.Sprintf("%s.%s", d.module, ID),
+		Data: templateData,
+	}
+}
+
+// Translate return the text of Message in language of Translator
+// Message will be translated to default language if it is missing in target language
+// and the ID of Message will be returned if it is missing in default language too
+// every missing message will only be logged once
+func (d *Default) Translate(m Message) string {
+	if m.ID == "" {
+		return m.Text
+	}
+
+	if d.tag != d.defTag && !d.ids[d.tag][m.ID] {
+		d.logMissing(m.ID, d.tag.String(), "default language")
+	}
+
+	mes, err := d.localize.Localize(&i18n.LocalizeConfig{
+		MessageID:    m.ID,
+		TemplateData: m.Data,
 	})
-	return Message(mes)
+	if err != nil {
+		d.logMissing(m.ID, d.defLang, "message ID")
+		return m.ID
+	}
+	return mes
+}
+
+func (d *Default) logMissing(ID string, lang string, fallback string) {
+	if _, logged := d.missing.LoadOrStore(lang+"/"+ID, true); logged {
+		return
+	}
+	d.logger.Errorf("translation of %s is missing in language %s, fall back to %s", ID, lang, fallback)
 }
@@ -17,7 +17,16 @@
  */
 package translate
 
-import "testing"
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tkestack.io/kube-jarvis/pkg/logger"
+)
 
 func TestTranslator_Message(t *testing.T) {
 	tr, err := NewDefault("../../translation", "en", "zh")
@@ -26,7 +35,202 @@ func TestTranslator_Message(t *testing.T) {
 	}
 
 	tr = tr.WithModule("diagnostics.example")
-	t.Log(tr.Message("message", map[string]interface{}{
+	t.Log(tr.Translate(tr.Message("message", map[string]interface{}{
 		"Mes": "test",
-	}))
+	})))
+}
+
+func TestDefault_WithLang(t *testing.T) {
+	tr, err := NewDefault("../../translation", "en", "en")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	ms := tr.WithModule("diagnostics.node-sys").Message("kernel-para-desc", map[string]interface{}{
+		"Node":   "node1",
+		"Name":   "net.ipv4.tcp_tw_reuse",
+		"CurVal": 100000000,
+	})
+
+	// messages should be translatable after a json round trip
+	data, err := json.Marshal(ms)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	newMs := Message{}
+	if err := json.Unmarshal(data, &newMs); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var cases = []struct {
+		lang   string
+		expect string
+	}{
+		{
+			lang:   "",
+			expect: "Node node1 Parameters[ net.ipv4.tcp_tw_reuse=100000000 ] is not recommended",
+		},
+		{
+			lang:   "zh",
+			expect: "节点 node1 参数[ net.ipv4.tcp_tw_reuse=100000000 ] 不是推荐设置",
+		},
+		{
+			lang:   "zh-CN,zh;q=0.9,en;q=0.8",
+			expect: "节点 node1 参数[ net.ipv4.tcp_tw_reuse=100000000 ] 不是推荐设置",
+		},
+		{
+			lang:   "fr",
+			expect: "Node node1 Parameters[ net.ipv4.tcp_tw_reuse=100000000 ] is not recommended",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			if got := tr.WithLang(cs.lang).Translate(newMs); got != cs.expect {
+				t.Fatalf("want %s but get %s", cs.expect, got)
+			}
+		})
+	}
+}
+
+func TestMessage_UnmarshalJSON(t *testing.T) {
+	ms := Message{}
+	if err := json.Unmarshal([]byte(`"literal text"`), &ms); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if ms.ID != "" || ms.Text != "literal text" {
+		t.Fatalf("literal message should be decoded from json string")
+	}
+
+	data, err := json.Marshal(ms)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if string(data) != `"literal text"` {
+		t.Fatalf("literal message should be encoded as json string, get %s", string(data))
+	}
+}
+
+type countLogger struct {
+	errors int
+}
+
+func (c *countLogger) With(labels map[string]string) logger.Logger { return c }
+func (c *countLogger) Infof(format string, args ...interface{})    {}
+func (c *countLogger) Debugf(format string, args ...interface{})   {}
+func (c *countLogger) Errorf(format string, args ...interface{})   { c.errors++ }
+
+func TestDefault_Translate_Missing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "translation")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"en/diagnostics/test.yaml": "a: \"A\"\nb: \"B\"\n",
+		"zh/diagnostics/test.yaml": "a: \"甲\"\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf(err.Error())
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+
+	tr, err := NewDefault(dir, "en", "zh")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	lg := &countLogger{}
+	tr.(*Default).logger = lg
+	tr = tr.WithModule("diagnostics.test")
+
+	var cases = []struct {
+		ID     string
+		expect string
+	}{
+		{
+			ID:     "a",
+			expect: "甲",
+		},
+		{
+			ID:     "b",
+			expect: "B",
+		},
+		{
+			ID:     "c",
+			expect: "diagnostics.test.c",
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		for _, cs := range cases {
+			if got := tr.Translate(tr.Message(cs.ID, nil)); got != cs.expect {
+				t.Fatalf("want %s but get %s", cs.expect, got)
+			}
+		}
+	}
+
+	// "b" missing in zh, "c" missing in zh and en
+	if lg.errors != 3 {
+		t.Fatalf("missing messages should be logged once, want 3 logs but get %d", lg.errors)
+	}
+}
+
+func TestNewDefault_BuiltIn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "translation")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "en", "diagnostics", "example.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if err := ioutil.WriteFile(path, []byte("message: \"overridden\"\n"), 0644); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	var cases = []struct {
+		dir     string
+		message string
+	}{
+		{
+			dir:     filepath.Join(dir, "not-exist"),
+			message: "This is a example diagnostic",
+		},
+		{
+			dir:     dir,
+			message: "overridden",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(fmt.Sprintf("%+v", cs), func(t *testing.T) {
+			tr, err := NewDefault(cs.dir, "en", "en")
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+
+			tr = tr.WithModule("diagnostics.example")
+			if got := tr.Translate(tr.Message("message", nil)); got != cs.message {
+				t.Fatalf("want %s but get %s", cs.message, got)
+			}
+
+			proposal := "This is a example proposal"
+			if got := tr.Translate(tr.Message("proposal", nil)); got != proposal {
+				t.Fatalf("want %s but get %s", proposal, got)
+			}
+		})
+	}
 }
@@ -26,13 +26,12 @@ func NewFake() Translator {
 	return &Fake{}
 }
 
-// Message get translated message from Translator
-// t.module will be add before ID
-// example:
-//         ID = "message"  and module = "diagnostics.example"
-//         then real ID will be "diagnostics.example.message"
+// Message return a untranslated Message with ID
 func (f *Fake) Message(ID string, templateData map[string]interface{}) Message {
-	return Message(ID)
+	return Message{
+		ID:   ID,
+		Data: templateData,
+	}
 }
 
 // WithModule attach a module label to a Translator
@@ -40,3 +39,13 @@ func (f *Fake) Message(ID string, templateData map[string]interface{}) Message {
 func (f *Fake) WithModule(module string) Translator {
 	return f
 }
+
+// WithLang return f directly
+func (f *Fake) WithLang(lang string) Translator {
+	return f
+}
+
+// Translate return ID of Message as text directly
+func (f *Fake) Translate(m Message) string {
+	return m.String()
+}
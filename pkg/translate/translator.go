@@ -17,18 +17,101 @@
  */
 package translate
 
-// Message is a translated string
-type Message string
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Message is a language independent message
+// it keeps the message ID and template data, so that it can be
+// translated into any language when it is exported or queried
+// a Message with empty ID is a literal message, Text will be used directly
+type Message struct {
+	// ID is the full ID of message, e.g. "diagnostics.example.message"
+	ID string
+	// Data is the template data of message
+	Data map[string]interface{}
+	// Text is the literal text of message
+	Text string
+}
+
+// Literal return a Message that will not be translated
+func Literal(text string) Message {
+	return Message{
+		Text: text,
+	}
+}
+
+// String return Text if m is a literal message, or return ID
+func (m Message) String() string {
+	if m.ID == "" {
+		return m.Text
+	}
+	return m.ID
+}
+
+type jsonMessage struct {
+	ID   string
+	Data map[string]interface{} `json:",omitempty"`
+}
+
+// MarshalJSON marshal a literal message as a json string
+// and marshal other messages as a json object with ID and Data
+func (m Message) MarshalJSON() ([]byte, error) {
+	if m.ID == "" {
+		return json.Marshal(m.Text)
+	}
+
+	return json.Marshal(&jsonMessage{
+		ID:   m.ID,
+		Data: m.Data,
+	})
+}
+
+// UnmarshalJSON support both json string and json object
+// numbers in Data will be decoded as json.Number to keep their original format
+func (m *Message) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) != 0 && data[0] == '"' {
+		*m = Message{}
+		return json.Unmarshal(data, &m.Text)
+	}
+
+	jm := &jsonMessage{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(jm); err != nil {
+		return err
+	}
+
+	*m = Message{
+		ID:   jm.ID,
+		Data: jm.Data,
+	}
+	return nil
+}
 
 // Translator translate string to target language
 type Translator interface {
-	// WithModule attach a module label to a Translator
-	// module will be add before ID when you call Translator.Message
-	Message(ID string, templateData map[string]interface{}) Message
-	// Message get translated message from Translator
+	// Message return a untranslated Message
 	// t.module will be add before ID
 	// example:
 	//         ID = "message"  and module = "diagnostics.example"
 	//         then real ID will be "diagnostics.example.message"
+	Message(ID string, templateData map[string]interface{}) Message
+	// WithModule attach a module label to a Translator
+	// module will be add before ID when you call Translator.Message
 	WithModule(module string) Translator
+	// WithLang return a Translator that translate Message to target language
+	// lang can be a language tag or the value of http header "Accept-Language"
+	WithLang(lang string) Translator
+	// Translate return the text of Message in language of Translator
+	Translate(m Message) string
+}
+
+// MessageLister is implemented by plugins that can list the messages they use
+// "kube-jarvis i18n check" expect all listed messages to be translated in every language
+type MessageLister interface {
+	// MessageIDs return all message IDs that may be used, without module
+	MessageIDs() []string
 }
@@ -17,7 +17,11 @@
  */
 package util
 
-import "gopkg.in/yaml.v2"
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
 
 // InitObjViaYaml marshal "config" to yaml data, then unMarshal data to "obj"
 func InitObjViaYaml(obj interface{}, config interface{}) error {
@@ -31,3 +35,24 @@ func InitObjViaYaml(obj interface{}, config interface{}) error {
 	}
 	return yaml.Unmarshal(data, obj)
 }
+
+// JSONCompatible convert map[interface{}]interface{} decoded by yaml to map[string]interface{}
+// so that the value can be marshaled to json
+func JSONCompatible(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, item := range val {
+			m[fmt.Sprint(k)] = JSONCompatible(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			list = append(list, JSONCompatible(item))
+		}
+		return list
+	default:
+		return v
+	}
+}
@@ -18,6 +18,7 @@
 package util
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -47,3 +48,19 @@ func TestInitObjViaYaml(t *testing.T) {
 		t.Fatalf("b.A != a.A")
 	}
 }
+
+func TestJSONCompatible(t *testing.T) {
+	v := map[interface{}]interface{}{
+		"a": 1,
+		2:   []interface{}{map[interface{}]interface{}{"b": "c"}},
+	}
+
+	data, err := json.Marshal(JSONCompatible(v))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if string(data) != `{"2":[{"b":"c"}],"a":1}` {
+		t.Fatalf("wrong json %s", string(data))
+	}
+}